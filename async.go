@@ -0,0 +1,214 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const asyncSize = 32 * writerSize
+
+// async represents the background goroutine
+// which drains filled buffers from a Writer
+// to the underlying io.Writer.
+
+type async struct {
+	wtr io.Writer
+	ful chan []byte
+	emp chan []byte
+	don chan struct{}
+	wgp sync.WaitGroup
+	bad int32
+	lck sync.Mutex
+	err error
+	cls bool
+}
+
+// NewWriterAsync creates a new Writer which writes
+// to an underlying io.Writer from a background
+// goroutine. The Writer fills one of n buffers,
+// while the filled buffers are written in the
+// background. If all buffers are full, then
+// writes block until a buffer is available.
+// Any error from a background write is returned
+// from the next write, or from Flush or Close.
+// The Writer must be closed with Close in order
+// to stop the background goroutine.
+func NewWriterAsync(w io.Writer, n int) *Writer {
+
+	if n < 2 {
+		n = 2
+	}
+
+	a := &async{
+		wtr: w,
+		ful: make(chan []byte, n),
+		emp: make(chan []byte, n),
+		don: make(chan struct{}),
+	}
+
+	for i := 1; i < n; i++ {
+		a.emp <- make([]byte, asyncSize)
+	}
+
+	go a.run()
+
+	return track(&Writer{dst: w, asy: a, buf: make([]byte, asyncSize)})
+
+}
+
+func (w *Writer) swap() error {
+
+	// Return any background write error.
+
+	err := w.asy.fault()
+	if err != nil {
+		w.err = err
+		return err
+	}
+
+	// Hand the filled buffer to the goroutine.
+
+	w.asy.wgp.Add(1)
+	w.asy.ful <- w.buf[:w.pos]
+
+	// Wait for an empty buffer to fill.
+
+	w.buf = <-w.asy.emp
+	w.pos = 0
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+func (w *Writer) wait() error {
+
+	// Hand over any buffered data.
+
+	err := w.flush()
+	if err != nil {
+		return err
+	}
+
+	// Wait for all buffers to be written.
+
+	w.asy.wgp.Wait()
+
+	// Return any background write error.
+
+	return w.fault()
+
+}
+
+func (w *Writer) fault() error {
+	if w.err == nil && w.asy != nil {
+		w.err = w.asy.fault()
+	}
+	return w.err
+}
+
+func (a *async) run() {
+
+	defer close(a.don)
+
+	for b := range a.ful {
+
+		// Write the buffer unless already failed.
+
+		if !a.failed() {
+			a.write(b)
+		}
+
+		// Return the buffer for filling.
+
+		a.emp <- b[:cap(b)]
+		a.wgp.Done()
+
+	}
+
+}
+
+func (a *async) write(b []byte) {
+	for len(b) > 0 {
+		n, err := a.wtr.Write(b)
+		if n > len(b) {
+			n = len(b)
+		}
+		if err == nil && n == 0 {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			a.fail(err)
+			return
+		}
+		b = b[n:]
+	}
+}
+
+func (a *async) stop() error {
+	if !a.cls {
+		a.cls = true
+		close(a.ful)
+		<-a.don
+	}
+	return a.fault()
+}
+
+func (a *async) reset(i io.Writer) {
+
+	// Wait for any pending writes.
+
+	a.wgp.Wait()
+
+	// Clear any background write error.
+
+	a.lck.Lock()
+	a.err = nil
+	a.lck.Unlock()
+	atomic.StoreInt32(&a.bad, 0)
+
+	a.wtr = i
+
+	// Restart the goroutine if stopped.
+
+	if a.cls {
+		a.cls = false
+		a.ful = make(chan []byte, cap(a.emp))
+		a.don = make(chan struct{})
+		go a.run()
+	}
+
+}
+
+func (a *async) fail(err error) {
+	a.lck.Lock()
+	defer a.lck.Unlock()
+	a.err = err
+	atomic.StoreInt32(&a.bad, 1)
+}
+
+func (a *async) fault() error {
+	a.lck.Lock()
+	defer a.lck.Unlock()
+	return a.err
+}
+
+func (a *async) failed() bool {
+	return atomic.LoadInt32(&a.bad) != 0
+}
@@ -0,0 +1,125 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// gateWriter blocks each write until
+// the gate channel is closed.
+
+type gateWriter struct {
+	syncBuffer
+	gate chan struct{}
+}
+
+func (g *gateWriter) Write(p []byte) (int, error) {
+	<-g.gate
+	return g.syncBuffer.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+
+	Convey("Async Writer should write all data in order", t, func() {
+		b := &syncBuffer{}
+		w := NewWriterAsync(b, 3)
+		for i := 0; i < 10; i++ {
+			chunkWriteBytes(w, big)
+			chunkWriteString(w, string(txt))
+		}
+		So(w.Close(), ShouldBeNil)
+		var x []byte
+		for i := 0; i < 10; i++ {
+			x = append(x, big...)
+			x = append(x, txt...)
+		}
+		So(b.Bytes(), ShouldResemble, x)
+	})
+
+	Convey("Async Writer should wait for all buffers when flushing", t, func() {
+		g := &gateWriter{gate: make(chan struct{})}
+		w := NewWriterAsync(g, 2)
+		w.WriteBytes(txt)
+		var done int32
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			atomic.StoreInt32(&done, 1)
+			close(g.gate)
+		}()
+		So(w.Flush(), ShouldBeNil)
+		So(atomic.LoadInt32(&done), ShouldEqual, 1)
+		So(g.Bytes(), ShouldResemble, txt)
+		So(w.Close(), ShouldBeNil)
+	})
+
+	Convey("Async Writer should bound the number of buffers in flight", t, func() {
+		g := &gateWriter{gate: make(chan struct{})}
+		w := NewWriterAsync(g, 2)
+		var written int32
+		go func() {
+			for i := 0; i < 10; i++ {
+				w.WriteBytes(make([]byte, asyncSize))
+				atomic.AddInt32(&written, 1)
+			}
+		}()
+		time.Sleep(20 * time.Millisecond)
+		So(atomic.LoadInt32(&written), ShouldBeLessThanOrEqualTo, 2)
+		close(g.gate)
+		for atomic.LoadInt32(&written) < 10 {
+			time.Sleep(time.Millisecond)
+		}
+		So(w.Close(), ShouldBeNil)
+		So(len(g.Bytes()), ShouldEqual, 10*asyncSize)
+	})
+
+	Convey("Async Writer should return background errors from later writes", t, func() {
+		b := &syncBuffer{err: errors.New("disk full")}
+		w := NewWriterAsync(b, 2)
+		w.WriteBytes(make([]byte, asyncSize))
+		w.WriteByte(1)
+		for i := 0; i < 100 && w.WriteByte(1) == nil; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		So(w.WriteByte(1), ShouldEqual, b.err)
+		So(w.Flush(), ShouldEqual, b.err)
+		So(w.Close(), ShouldEqual, b.err)
+	})
+
+	Convey("Async Writer should return background errors from Close", t, func() {
+		b := &syncBuffer{err: errors.New("disk full")}
+		w := NewWriterAsync(b, 2)
+		w.WriteBytes(txt)
+		So(w.Close(), ShouldEqual, b.err)
+		So(w.WriteByte(1), ShouldEqual, ErrClosed)
+	})
+
+	Convey("Async Writer should be reusable after closing and resetting", t, func() {
+		w := NewWriterAsync(&syncBuffer{}, 2)
+		for i := 0; i < 5; i++ {
+			b := &syncBuffer{}
+			So(w.Reset(b), ShouldBeNil)
+			chunkWriteBytes(w, big)
+			So(w.Close(), ShouldBeNil)
+			So(b.Bytes(), ShouldResemble, big)
+		}
+	})
+
+}
@@ -0,0 +1,225 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cbor implements the CBOR format, as defined
+// in RFC 8949, on top of bump Readers and Writers,
+// without any buffering of its own. An Encoder and
+// Decoder provide a token API, for writing and reading
+// one item at a time, including indefinite-length
+// items and tags, and a value API, for writing and
+// reading whole Go values using reflection. Values of
+// type time.Time, big.Int and UUID are written using
+// the standard datetime, bignum and UUID tags.
+package cbor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Major types as defined in RFC 8949.
+
+const (
+	majorUint   = 0
+	majorInt    = 1
+	majorBytes  = 2
+	majorString = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// Additional information values, which
+// describe the argument of an item.
+
+const (
+	infoUint8      = 24
+	infoUint16     = 25
+	infoUint32     = 26
+	infoUint64     = 27
+	infoIndefinite = 31
+)
+
+// Initial bytes of the simple values,
+// floating point numbers and break code.
+
+const (
+	codeFalse     = 0xf4
+	codeTrue      = 0xf5
+	codeNull      = 0xf6
+	codeUndefined = 0xf7
+	codeSimple8   = 0xf8
+	codeFloat16   = 0xf9
+	codeFloat32   = 0xfa
+	codeFloat64   = 0xfb
+	codeBreak     = 0xff
+)
+
+// Tag numbers which are supported
+// by the Encoder and Decoder.
+
+const (
+	// TagDateTime tags a datetime string.
+	TagDateTime = 0
+	// TagEpochTime tags a number of
+	// seconds since the Unix epoch.
+	TagEpochTime = 1
+	// TagPosBignum tags a positive bignum.
+	TagPosBignum = 2
+	// TagNegBignum tags a negative bignum.
+	TagNegBignum = 3
+	// TagUUID tags a binary UUID.
+	TagUUID = 37
+)
+
+// defaultMaxDepth limits how deeply values can be
+// nested when decoding or skipping values, unless
+// the Decoder is configured otherwise.
+const defaultMaxDepth = 10000
+
+// maxLength is the largest length of a string,
+// array or map which will be read or written.
+const maxLength = 1<<31 - 1
+
+// Type represents the type of a CBOR item.
+type Type int
+
+const (
+	InvalidType Type = iota
+	UintType
+	IntType
+	BytesType
+	StringType
+	ArrayType
+	MapType
+	TagType
+	BoolType
+	NilType
+	UndefinedType
+	SimpleType
+	FloatType
+	BreakType
+)
+
+var typeNames = [...]string{
+	InvalidType:   "invalid",
+	UintType:      "uint",
+	IntType:       "int",
+	BytesType:     "bytes",
+	StringType:    "string",
+	ArrayType:     "array",
+	MapType:       "map",
+	TagType:       "tag",
+	BoolType:      "bool",
+	NilType:       "null",
+	UndefinedType: "undefined",
+	SimpleType:    "simple",
+	FloatType:     "float",
+	BreakType:     "break",
+}
+
+func (t Type) String() string {
+	if t >= 0 && int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+	return typeNames[InvalidType]
+}
+
+var majorTypes = [...]Type{
+	majorUint:   UintType,
+	majorInt:    IntType,
+	majorBytes:  BytesType,
+	majorString: StringType,
+	majorArray:  ArrayType,
+	majorMap:    MapType,
+	majorTag:    TagType,
+}
+
+// typeOf returns the type of the item which
+// starts with the specified initial byte.
+
+func typeOf(c byte) Type {
+	m, i := c>>5, c&0x1f
+	switch {
+	case i > infoUint64 && i < infoIndefinite:
+		return InvalidType
+	case i == infoIndefinite:
+		switch m {
+		case majorBytes, majorString, majorArray, majorMap:
+			return majorTypes[m]
+		case majorSimple:
+			return BreakType
+		}
+		return InvalidType
+	case m != majorSimple:
+		return majorTypes[m]
+	}
+	switch c {
+	case codeFalse, codeTrue:
+		return BoolType
+	case codeNull:
+		return NilType
+	case codeUndefined:
+		return UndefinedType
+	case codeFloat16, codeFloat32, codeFloat64:
+		return FloatType
+	}
+	return SimpleType
+}
+
+var (
+	// ErrInvalidCode is returned when an item starts
+	// with a reserved initial byte, or is not well
+	// formed, such as an unexpected break code.
+	ErrInvalidCode = errors.New("cbor: invalid initial byte")
+	// ErrInvalidTag is returned when a tag has an
+	// unexpected number, or invalid content.
+	ErrInvalidTag = errors.New("cbor: invalid tag content")
+	// ErrMaxDepth is returned when a value which is
+	// being decoded or skipped is nested too deeply.
+	ErrMaxDepth = errors.New("cbor: maximum nesting depth exceeded")
+	// ErrLength is returned when a string, byte slice,
+	// array or map is too long to be read or written.
+	ErrLength = errors.New("cbor: length too large")
+	// ErrCanonical is returned when an item which can
+	// not be written in canonical mode is written.
+	ErrCanonical = errors.New("cbor: indefinite length in canonical mode")
+)
+
+// TypeError is returned when an item of one type
+// is read as, or decoded into, another type.
+type TypeError struct {
+	Want Type
+	Got  Type
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("cbor: cannot read %s as %s", e.Got, e.Want)
+}
+
+// Tag represents a tagged value with
+// an application-specific tag number.
+type Tag struct {
+	Number  uint64
+	Content interface{}
+}
+
+// Simple represents a simple value which is
+// not a boolean, null or undefined value.
+type Simple uint8
+
+// UUID represents a binary UUID, which is
+// written using the UUID tag.
+type UUID [16]byte
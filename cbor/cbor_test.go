@@ -0,0 +1,388 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/surrealdb/bump"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// tokenWrite encodes items using the
+// specified function into a byte slice.
+
+func tokenWrite(fn func(e *Encoder) error) []byte {
+	var b []byte
+	if err := fn(NewEncoder(bump.NewWriterBytes(&b))); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// tokenRead returns a Decoder which reads
+// from the specified hex encoded bytes.
+
+func tokenRead(h string) *Decoder {
+	b, err := hex.DecodeString(h)
+	if err != nil {
+		panic(err)
+	}
+	return NewDecoder(bump.NewReaderBytes(b))
+}
+
+// bigInt parses a decimal integer of any size.
+
+func bigInt(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
+
+func TestTokens(t *testing.T) {
+
+	Convey("Decoder and canonical Encoder should match the RFC 8949 examples", t, func() {
+		cases := []struct {
+			h string
+			v interface{}
+		}{
+			{"00", uint64(0)},
+			{"17", uint64(23)},
+			{"1818", uint64(24)},
+			{"1903e8", uint64(1000)},
+			{"1a000f4240", uint64(1000000)},
+			{"1b000000e8d4a51000", uint64(1000000000000)},
+			{"1bffffffffffffffff", uint64(math.MaxUint64)},
+			{"c249010000000000000000", bigInt("18446744073709551616")},
+			{"3bffffffffffffffff", bigInt("-18446744073709551616")},
+			{"c349010000000000000000", bigInt("-18446744073709551617")},
+			{"20", int64(-1)},
+			{"3863", int64(-100)},
+			{"3903e7", int64(-1000)},
+			{"f90000", 0.0},
+			{"f93c00", 1.0},
+			{"fb3ff199999999999a", 1.1},
+			{"f93e00", 1.5},
+			{"f97bff", 65504.0},
+			{"fa47c35000", 100000.0},
+			{"fa7f7fffff", 3.4028234663852886e+38},
+			{"fb7e37e43c8800759c", 1.0e+300},
+			{"f90001", 5.960464477539063e-8},
+			{"f90400", 0.00006103515625},
+			{"f9c400", -4.0},
+			{"fbc010666666666666", -4.1},
+			{"f97c00", math.Inf(1)},
+			{"f9fc00", math.Inf(-1)},
+			{"f4", false},
+			{"f5", true},
+			{"f6", nil},
+			{"f0", Simple(16)},
+			{"f8ff", Simple(255)},
+			{"40", []byte{}},
+			{"4401020304", []byte{1, 2, 3, 4}},
+			{"60", ""},
+			{"6449455446", "IETF"},
+			{"62c3bc", "ü"},
+			{"63e6b0b4", "水"},
+			{"80", []interface{}{}},
+			{"8301820203820405", []interface{}{uint64(1), []interface{}{uint64(2), uint64(3)}, []interface{}{uint64(4), uint64(5)}}},
+			{"a0", map[string]interface{}{}},
+			{"a201020304", map[interface{}]interface{}{uint64(1): uint64(2), uint64(3): uint64(4)}},
+			{"a26161016162820203", map[string]interface{}{"a": uint64(1), "b": []interface{}{uint64(2), uint64(3)}}},
+			{"d74401020304", Tag{Number: 23, Content: []byte{1, 2, 3, 4}}},
+		}
+		for _, c := range cases {
+			v, err := tokenRead(c.h).DecodeValue()
+			So(err, ShouldBeNil)
+			So(v, ShouldResemble, c.v)
+			b := tokenWrite(func(e *Encoder) error {
+				e.Canonical(true)
+				return e.Encode(c.v)
+			})
+			So(hex.EncodeToString(b), ShouldEqual, c.h)
+		}
+	})
+
+	Convey("Decoder should read the RFC 8949 indefinite-length examples", t, func() {
+		cases := []struct {
+			h string
+			v interface{}
+		}{
+			{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+			{"7f657374726561646d696e67ff", "streaming"},
+			{"9fff", []interface{}{}},
+			{"9f018202039f0405ffff", []interface{}{uint64(1), []interface{}{uint64(2), uint64(3)}, []interface{}{uint64(4), uint64(5)}}},
+			{"bf61610161629f0203ffff", map[string]interface{}{"a": uint64(1), "b": []interface{}{uint64(2), uint64(3)}}},
+		}
+		for _, c := range cases {
+			v, err := tokenRead(c.h).DecodeValue()
+			So(err, ShouldBeNil)
+			So(v, ShouldResemble, c.v)
+			d := tokenRead(c.h + "00")
+			So(d.Skip(), ShouldBeNil)
+			n, err := d.ReadUint()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+		}
+	})
+
+	Convey("Encoder should write indefinite-length items", t, func() {
+		b := tokenWrite(func(e *Encoder) error {
+			e.WriteBeginArray()
+			e.WriteUint(1)
+			e.WriteBeginMap()
+			e.WriteString("a")
+			e.WriteBeginString()
+			e.WriteString("strea")
+			e.WriteString("ming")
+			e.WriteBreak()
+			e.WriteBreak()
+			e.WriteBeginBytes()
+			e.WriteBreak()
+			return e.WriteBreak()
+		})
+		So(hex.EncodeToString(b), ShouldEqual, "9f01bf61617f657374726561646d696e67ffff5fffff")
+		var e Encoder
+		e.Canonical(true)
+		So(e.WriteBeginArray(), ShouldEqual, ErrCanonical)
+	})
+
+	Convey("Decoder should read indefinite-length headers and break codes", t, func() {
+		d := tokenRead("9f01ff")
+		n, err := d.ReadArrayHeader()
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, -1)
+		ok, err := d.ReadBreak()
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeFalse)
+		v, _ := d.ReadUint()
+		So(v, ShouldEqual, 1)
+		ok, err = d.ReadBreak()
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+	})
+
+	Convey("Decoder should reject malformed items", t, func() {
+		_, err := tokenRead("1c").PeekType()
+		So(err, ShouldEqual, ErrInvalidCode)
+		_, err = tokenRead("1f").ReadUint()
+		So(err, ShouldEqual, ErrInvalidCode)
+		_, err = tokenRead("ff").DecodeValue()
+		So(err, ShouldEqual, ErrInvalidCode)
+		_, err = tokenRead("5f6161ff").ReadBytes()
+		So(err, ShouldResemble, &TypeError{Want: BytesType, Got: StringType})
+		_, err = tokenRead("5f5f4101ffff").ReadBytes()
+		So(err, ShouldEqual, ErrInvalidCode)
+		_, err = tokenRead("f801").ReadSimple()
+		So(err, ShouldEqual, ErrInvalidCode)
+		So(tokenRead("81ff").Skip(), ShouldEqual, ErrInvalidCode)
+		_, err = tokenRead("9a80000000").ReadArrayHeader()
+		So(err, ShouldEqual, ErrLength)
+	})
+
+	Convey("Decoder should read integers in any size", t, func() {
+		v, err := tokenRead("1b0000000000000005").ReadInt()
+		So(err, ShouldBeNil)
+		So(v, ShouldEqual, 5)
+		_, err = tokenRead("20").ReadUint()
+		So(err, ShouldEqual, bump.ErrOverflow)
+		_, err = tokenRead("3bffffffffffffffff").ReadInt()
+		So(err, ShouldEqual, bump.ErrOverflow)
+		_, err = tokenRead("1bffffffffffffffff").ReadInt()
+		So(err, ShouldEqual, bump.ErrOverflow)
+		i, err := tokenRead("3b7fffffffffffffff").ReadInt()
+		So(err, ShouldBeNil)
+		So(i, ShouldEqual, math.MinInt64)
+	})
+
+	Convey("Encoder should write bignums only when needed", t, func() {
+		cases := []struct {
+			v string
+			h string
+		}{
+			{"0", "00"},
+			{"-1", "20"},
+			{"18446744073709551615", "1bffffffffffffffff"},
+			{"18446744073709551616", "c249010000000000000000"},
+			{"-18446744073709551617", "c349010000000000000000"},
+		}
+		for _, c := range cases {
+			b := tokenWrite(func(e *Encoder) error { return e.WriteBigInt(bigInt(c.v)) })
+			So(hex.EncodeToString(b), ShouldEqual, c.h)
+			v, err := NewDecoder(bump.NewReaderBytes(b)).ReadBigInt()
+			So(err, ShouldBeNil)
+			So(v.String(), ShouldEqual, c.v)
+		}
+	})
+
+	Convey("Encoder should write floats at full size unless canonical", t, func() {
+		b := tokenWrite(func(e *Encoder) error {
+			e.WriteFloat32(1.5)
+			return e.WriteFloat64(1.5)
+		})
+		So(hex.EncodeToString(b), ShouldEqual, "fa3fc00000fb3ff8000000000000")
+		b = tokenWrite(func(e *Encoder) error {
+			e.Canonical(true)
+			e.WriteFloat32(1.5)
+			e.WriteFloat64(math.NaN())
+			e.WriteFloat64(math.Copysign(0, -1))
+			return e.WriteFloat32(0.1)
+		})
+		So(hex.EncodeToString(b), ShouldEqual, "f93e00f97e00f98000fa3dcccccd")
+		f, err := tokenRead("f97e00").ReadFloat()
+		So(err, ShouldBeNil)
+		So(math.IsNaN(f), ShouldBeTrue)
+		f, err = tokenRead("f903ff").ReadFloat()
+		So(err, ShouldBeNil)
+		So(f, ShouldEqual, 0.000060975551605224609375)
+	})
+
+	Convey("Encoder should write times, UUIDs and tags", t, func() {
+		b := tokenWrite(func(e *Encoder) error { return e.WriteTime(time.Unix(1363896240, 0)) })
+		So(hex.EncodeToString(b), ShouldEqual, "c11a514b67b0")
+		x := time.Date(2013, 3, 21, 20, 4, 0, 500, time.UTC)
+		b = tokenWrite(func(e *Encoder) error { return e.WriteTime(x) })
+		So(string(b[3:]), ShouldEqual, "2013-03-21T20:04:00.0000005Z")
+		v, err := NewDecoder(bump.NewReaderBytes(b)).ReadTime()
+		So(err, ShouldBeNil)
+		So(v.Equal(x), ShouldBeTrue)
+		u := UUID{0: 1, 15: 2}
+		b = tokenWrite(func(e *Encoder) error { return e.WriteUUID(u) })
+		So(hex.EncodeToString(b), ShouldEqual, "d8255001000000000000000000000000000002")
+		w, err := NewDecoder(bump.NewReaderBytes(b)).ReadUUID()
+		So(err, ShouldBeNil)
+		So(w, ShouldEqual, u)
+		b = tokenWrite(func(e *Encoder) error { return e.WriteTag(55799) })
+		So(hex.EncodeToString(b), ShouldEqual, "d9d9f7")
+	})
+
+	Convey("Decoder should read the RFC 8949 time examples", t, func() {
+		v, err := tokenRead("c074323031332d30332d32315432303a30343a30305a").ReadTime()
+		So(err, ShouldBeNil)
+		So(v.Equal(time.Unix(1363896240, 0)), ShouldBeTrue)
+		v, err = tokenRead("c11a514b67b0").ReadTime()
+		So(err, ShouldBeNil)
+		So(v.Equal(time.Unix(1363896240, 0)), ShouldBeTrue)
+		v, err = tokenRead("c1fb41d452d9ec200000").ReadTime()
+		So(err, ShouldBeNil)
+		So(v.Equal(time.Unix(1363896240, 5e8)), ShouldBeTrue)
+		_, err = tokenRead("c26161").ReadTime()
+		So(err, ShouldEqual, ErrInvalidTag)
+		_, err = tokenRead("c06161").ReadTime()
+		So(err, ShouldEqual, ErrInvalidTag)
+		_, err = tokenRead("d8254101").ReadUUID()
+		So(err, ShouldEqual, ErrInvalidTag)
+	})
+
+	Convey("Decoder should report the type of the next item", t, func() {
+		d := tokenRead("0020404060609f80a0c0f4f6f7f0f9000000ff")
+		for _, want := range []Type{UintType, IntType, BytesType, BytesType, StringType, StringType, ArrayType, ArrayType, MapType, TagType, BoolType, NilType, UndefinedType, SimpleType, FloatType} {
+			typ, err := d.PeekType()
+			So(err, ShouldBeNil)
+			So(typ, ShouldEqual, want)
+			if want == ArrayType {
+				d.ReadArrayHeader()
+				continue
+			}
+			if want == TagType {
+				d.ReadTag()
+				continue
+			}
+			So(d.Skip(), ShouldBeNil)
+		}
+		So(d.Skip(), ShouldBeNil)
+		typ, err := d.PeekType()
+		So(err, ShouldBeNil)
+		So(typ, ShouldEqual, BreakType)
+		d.ReadBreak()
+		_, err = d.PeekType()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Decoder should not consume items of the wrong type", t, func() {
+		d := tokenRead("6161")
+		_, err := d.ReadInt()
+		So(err, ShouldResemble, &TypeError{Want: IntType, Got: StringType})
+		So(err.Error(), ShouldEqual, "cbor: cannot read string as int")
+		s, err := d.ReadString()
+		So(err, ShouldBeNil)
+		So(s, ShouldEqual, "a")
+	})
+
+	Convey("Decoder should read strings without copying", t, func() {
+		b := tokenWrite(func(e *Encoder) error {
+			e.WriteStringBytes([]byte("abc"))
+			return e.WriteBytes([]byte("def"))
+		})
+		d := NewDecoder(bump.NewReaderBytes(b))
+		s, _ := d.ReadStringBytes()
+		So(string(s), ShouldEqual, "abc")
+		v, _ := d.ReadBytes()
+		So(string(v), ShouldEqual, "def")
+		b[1], b[5] = 'A', 'D'
+		So(string(s), ShouldEqual, "Abc")
+		So(string(v), ShouldEqual, "Def")
+	})
+
+	Convey("Decoder should limit the nesting depth when skipping", t, func() {
+		d := tokenRead("818181818100")
+		d.MaxDepth(4)
+		So(d.Skip(), ShouldEqual, ErrMaxDepth)
+		d = tokenRead("8181818100")
+		d.MaxDepth(4)
+		So(d.Skip(), ShouldBeNil)
+		d = tokenRead("9f9f9f9f9fffffffffff")
+		d.MaxDepth(4)
+		So(d.Skip(), ShouldEqual, ErrMaxDepth)
+	})
+
+	Convey("Encoder and Decoder should stream through an io.Writer", t, func() {
+		buf := bytes.NewBuffer(nil)
+		w := bump.NewWriter(buf)
+		e := NewEncoder(w)
+		for i := 0; i < 1000; i++ {
+			e.WriteBeginArray()
+			e.WriteInt(int64(-i - 1))
+			e.WriteString(strings.Repeat("v", i))
+			e.WriteBreak()
+		}
+		So(w.Flush(), ShouldBeNil)
+		d := NewDecoder(bump.NewReader(buf))
+		for i := 0; i < 1000; i++ {
+			v, err := d.DecodeValue()
+			So(err, ShouldBeNil)
+			So(v, ShouldResemble, []interface{}{int64(-i - 1), strings.Repeat("v", i)})
+		}
+	})
+
+	Convey("Decoder should return an error for truncated items", t, func() {
+		_, err := tokenRead("1901").ReadUint()
+		So(err, ShouldNotBeNil)
+		_, err = tokenRead("6561").ReadString()
+		So(err, ShouldNotBeNil)
+		_, err = tokenRead("7f6161").ReadString()
+		So(err, ShouldNotBeNil)
+		So(tokenRead("8201").Skip(), ShouldNotBeNil)
+		So(tokenRead("9f01").Skip(), ShouldNotBeNil)
+	})
+
+}
@@ -0,0 +1,643 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cbor
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/surrealdb/bump"
+)
+
+// Decoder reads CBOR items and values from a bump
+// Reader. Each item is checked before it is read, so
+// an item which has the wrong type can still be read
+// using another method, or skipped.
+type Decoder struct {
+	rdr *bump.Reader
+	max int
+}
+
+// NewDecoder creates a new Decoder which
+// reads from the specified Reader.
+func NewDecoder(r *bump.Reader) *Decoder {
+	return &Decoder{rdr: r, max: defaultMaxDepth}
+}
+
+// Reset instructs the Decoder to read
+// from the specified Reader.
+func (d *Decoder) Reset(r *bump.Reader) {
+	d.rdr = r
+}
+
+// MaxDepth specifies how deeply values can be
+// nested when decoding or skipping values, after
+// which ErrMaxDepth is returned. By default values
+// can be nested 10000 levels deep.
+func (d *Decoder) MaxDepth(n int) {
+	d.max = n
+}
+
+// PeekType returns the type of the next item,
+// without advancing the position of the Reader.
+func (d *Decoder) PeekType() (Type, error) {
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return InvalidType, err
+	}
+	if t := typeOf(c); t != InvalidType {
+		return t, nil
+	}
+	return InvalidType, ErrInvalidCode
+}
+
+// code returns the initial byte of the next item,
+// if the item has the specified type, and advances
+// past the initial byte.
+
+func (d *Decoder) code(t Type) (byte, error) {
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return 0, err
+	}
+	if typeOf(c) != t {
+		return 0, d.fail(t, c)
+	}
+	return d.rdr.ReadByte()
+}
+
+// fail returns the error for an item with the
+// specified initial byte which can not be read.
+// A break code is never valid where an item of
+// another type is expected.
+
+func (d *Decoder) fail(t Type, c byte) error {
+	switch g := typeOf(c); g {
+	case InvalidType, BreakType:
+		return ErrInvalidCode
+	default:
+		return &TypeError{Want: t, Got: g}
+	}
+}
+
+// arg reads the argument which follows the
+// specified initial byte. Indefinite-length
+// items have no argument.
+
+func (d *Decoder) arg(c byte) (uint64, error) {
+	switch i := c & 0x1f; i {
+	case infoUint8:
+		v, err := d.rdr.ReadUint8()
+		return uint64(v), err
+	case infoUint16:
+		v, err := d.rdr.ReadUint16()
+		return uint64(v), err
+	case infoUint32:
+		v, err := d.rdr.ReadUint32()
+		return uint64(v), err
+	case infoUint64:
+		return d.rdr.ReadUint64()
+	case infoIndefinite:
+		return 0, nil
+	default:
+		return uint64(i), nil
+	}
+}
+
+// head reads the initial byte and argument of
+// an item, if the item has the specified type.
+
+func (d *Decoder) head(t Type) (byte, uint64, error) {
+	c, err := d.code(t)
+	if err != nil {
+		return 0, 0, err
+	}
+	v, err := d.arg(c)
+	return c, v, err
+}
+
+// size reads the length of an item, returning
+// -1 for indefinite-length items.
+
+func (d *Decoder) size(t Type) (int, error) {
+	c, v, err := d.head(t)
+	if err != nil {
+		return 0, err
+	}
+	if c&0x1f == infoIndefinite {
+		return -1, nil
+	}
+	if v > maxLength {
+		return 0, ErrLength
+	}
+	return int(v), nil
+}
+
+// ReadNil reads a null item.
+func (d *Decoder) ReadNil() error {
+	_, err := d.code(NilType)
+	return err
+}
+
+// ReadUndefined reads an undefined item.
+func (d *Decoder) ReadUndefined() error {
+	_, err := d.code(UndefinedType)
+	return err
+}
+
+// ReadBool reads a boolean item.
+func (d *Decoder) ReadBool() (bool, error) {
+	c, err := d.code(BoolType)
+	return c == codeTrue, err
+}
+
+// ReadSimple reads a simple value item which
+// is not a boolean, null or undefined value.
+func (d *Decoder) ReadSimple() (Simple, error) {
+	c, v, err := d.head(SimpleType)
+	if err != nil {
+		return 0, err
+	}
+	if c == codeSimple8 && v < 32 {
+		return 0, ErrInvalidCode
+	}
+	return Simple(v), nil
+}
+
+// ReadInt reads an integer item as a signed integer,
+// returning bump.ErrOverflow if it is too large.
+func (d *Decoder) ReadInt() (int64, error) {
+
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return 0, err
+	}
+
+	// Read unsigned integers if they fit.
+
+	if typeOf(c) == UintType {
+		v, err := d.ReadUint()
+		if err == nil && v > math.MaxInt64 {
+			return 0, bump.ErrOverflow
+		}
+		return int64(v), err
+	}
+
+	// Otherwise read the negative integer.
+
+	_, v, err := d.head(IntType)
+	if err != nil {
+		return 0, err
+	}
+
+	if v > math.MaxInt64 {
+		return 0, bump.ErrOverflow
+	}
+
+	return ^int64(v), nil
+
+}
+
+// ReadUint reads an integer item as an unsigned
+// integer, returning bump.ErrOverflow if it is
+// negative.
+func (d *Decoder) ReadUint() (uint64, error) {
+
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return 0, err
+	}
+
+	// Negative integers never fit.
+
+	if typeOf(c) == IntType {
+		if _, _, err := d.head(IntType); err != nil {
+			return 0, err
+		}
+		return 0, bump.ErrOverflow
+	}
+
+	_, v, err := d.head(UintType)
+
+	return v, err
+
+}
+
+// ReadBigInt reads an integer item, or a
+// bignum, as an integer of any size.
+func (d *Decoder) ReadBigInt() (*big.Int, error) {
+
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch typeOf(c) {
+	case UintType:
+		_, v, err := d.head(UintType)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetUint64(v), nil
+	case IntType:
+		_, v, err := d.head(IntType)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Not(new(big.Int).SetUint64(v)), nil
+	}
+
+	n, err := d.ReadTag()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.bignum(n)
+
+}
+
+// bignum reads the content of a bignum tag.
+
+func (d *Decoder) bignum(n uint64) (*big.Int, error) {
+
+	if n != TagPosBignum && n != TagNegBignum {
+		return nil, ErrInvalidTag
+	}
+
+	b, err := d.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(big.Int).SetBytes(b)
+
+	if n == TagNegBignum {
+		v.Not(v)
+	}
+
+	return v, nil
+
+}
+
+// ReadFloat reads a 16-bit, 32-bit or 64-bit
+// floating point item.
+func (d *Decoder) ReadFloat() (float64, error) {
+
+	c, err := d.code(FloatType)
+	if err != nil {
+		return 0, err
+	}
+
+	switch c {
+	case codeFloat16:
+		v, err := d.rdr.ReadUint16()
+		return float64(fromHalf(v)), err
+	case codeFloat32:
+		v, err := d.rdr.ReadFloat32()
+		return float64(v), err
+	}
+
+	return d.rdr.ReadFloat64()
+
+}
+
+// chunks reads the chunks of an indefinite-length
+// string item, which must have the specified type,
+// and returns them as a single byte slice.
+
+func (d *Decoder) chunks(t Type) ([]byte, error) {
+
+	b := []byte{}
+
+	for {
+
+		ok, err := d.ReadBreak()
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return b, nil
+		}
+
+		// Chunks must not be indefinite themselves.
+
+		n, err := d.size(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if n < 0 {
+			return nil, ErrInvalidCode
+		}
+
+		p, err := d.rdr.ReadBytes(n)
+		if err != nil {
+			return nil, err
+		}
+
+		b = append(b, p...)
+
+	}
+
+}
+
+// ReadBytes reads a byte string item. When reading
+// from a byte slice, the data of definite-length items
+// refers to the byte slice instead of being copied.
+func (d *Decoder) ReadBytes() ([]byte, error) {
+	n, err := d.size(BytesType)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return d.chunks(BytesType)
+	}
+	return d.rdr.ReadBytes(n)
+}
+
+// ReadString reads a text string item.
+func (d *Decoder) ReadString() (string, error) {
+	n, err := d.size(StringType)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		b, err := d.chunks(StringType)
+		return string(b), err
+	}
+	return d.rdr.ReadString(n)
+}
+
+// ReadStringBytes reads a text string item as a byte
+// slice. When reading from a byte slice, the data of
+// definite-length items refers to the byte slice
+// instead of being copied.
+func (d *Decoder) ReadStringBytes() ([]byte, error) {
+	n, err := d.size(StringType)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return d.chunks(StringType)
+	}
+	return d.rdr.ReadBytes(n)
+}
+
+// ReadArrayHeader reads the header of an array item,
+// and returns the number of items which follow it,
+// or -1 if the array has an indefinite length, in
+// which case the items are followed by a break code.
+func (d *Decoder) ReadArrayHeader() (int, error) {
+	return d.size(ArrayType)
+}
+
+// ReadMapHeader reads the header of a map item, and
+// returns the number of pairs of items which follow it,
+// or -1 if the map has an indefinite length, in which
+// case the pairs are followed by a break code.
+func (d *Decoder) ReadMapHeader() (int, error) {
+	return d.size(MapType)
+}
+
+// ReadBreak reads a break code if it is the next
+// item, and returns whether a break code was read.
+func (d *Decoder) ReadBreak() (bool, error) {
+	c, err := d.rdr.PeekByte()
+	if err != nil || c != codeBreak {
+		return false, err
+	}
+	_, err = d.rdr.ReadByte()
+	return true, err
+}
+
+// ReadTag reads a tag number, which is
+// followed by the tag content item.
+func (d *Decoder) ReadTag() (uint64, error) {
+	_, v, err := d.head(TagType)
+	return v, err
+}
+
+// ReadTime reads a time using either the datetime
+// tag or the epoch time tag. The time is returned
+// in the local time zone, unless it is a datetime
+// string which specifies another time zone.
+func (d *Decoder) ReadTime() (time.Time, error) {
+	n, err := d.ReadTag()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.time(n)
+}
+
+// time reads the content of a time tag.
+
+func (d *Decoder) time(n uint64) (time.Time, error) {
+
+	switch n {
+	case TagDateTime:
+		s, err := d.ReadString()
+		if err != nil {
+			return time.Time{}, err
+		}
+		v, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return time.Time{}, ErrInvalidTag
+		}
+		return v, nil
+	case TagEpochTime:
+		t, err := d.PeekType()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t == FloatType {
+			f, err := d.ReadFloat()
+			if err != nil {
+				return time.Time{}, err
+			}
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return time.Time{}, ErrInvalidTag
+			}
+			s, x := math.Modf(f)
+			return time.Unix(int64(s), int64(x*1e9)), nil
+		}
+		s, err := d.ReadInt()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(s, 0), nil
+	}
+
+	return time.Time{}, ErrInvalidTag
+
+}
+
+// ReadUUID reads a UUID using the UUID tag.
+func (d *Decoder) ReadUUID() (UUID, error) {
+	n, err := d.ReadTag()
+	if err != nil {
+		return UUID{}, err
+	}
+	return d.uuid(n)
+}
+
+// uuid reads the content of a UUID tag.
+
+func (d *Decoder) uuid(n uint64) (UUID, error) {
+
+	var v UUID
+
+	if n != TagUUID {
+		return v, ErrInvalidTag
+	}
+
+	b, err := d.ReadBytes()
+	if err != nil {
+		return v, err
+	}
+
+	if len(b) != len(v) {
+		return v, ErrInvalidTag
+	}
+
+	copy(v[:], b)
+
+	return v, nil
+
+}
+
+// Skip skips the next item, including all of the
+// items within it if it is an array, map or tag, or
+// all of its chunks if it is an indefinite-length
+// string. Nested items are skipped without recursion,
+// but are still limited by the maximum nesting depth.
+func (d *Decoder) Skip() error {
+
+	// Each entry holds the number of items left in an
+	// enclosing item, or -1 for an indefinite-length
+	// item, which is ended by a break code.
+
+	var arr [16]int
+
+	stk := append(arr[:0], 1)
+
+	for len(stk) > 0 {
+
+		top := len(stk) - 1
+
+		if stk[top] == 0 {
+			stk = stk[:top]
+			continue
+		}
+
+		c, err := d.rdr.PeekByte()
+		if err != nil {
+			return err
+		}
+
+		if stk[top] < 0 && c == codeBreak {
+			d.rdr.ReadByte()
+			stk = stk[:top]
+			continue
+		}
+
+		if stk[top] > 0 {
+			stk[top]--
+		}
+
+		// Find the length of the item data, or
+		// the number of items within the item.
+
+		var l int
+
+		switch t := typeOf(c); t {
+		case UintType, IntType, BoolType, NilType, UndefinedType, SimpleType, FloatType:
+			d.rdr.ReadByte()
+			if i := c & 0x1f; i >= infoUint8 && i <= infoUint64 {
+				l = 1 << (i - infoUint8)
+			}
+		case BytesType, StringType:
+			l, err = d.size(t)
+			if l < 0 {
+				stk, l = append(stk, -1), 0
+			}
+		case ArrayType:
+			l, err = d.size(t)
+			if l != 0 {
+				stk, l = append(stk, l), 0
+			}
+		case MapType:
+			l, err = d.size(t)
+			if l > 0 {
+				l *= 2
+			}
+			if l != 0 {
+				stk, l = append(stk, l), 0
+			}
+		case TagType:
+			_, err = d.ReadTag()
+			stk = append(stk, 1)
+		default:
+			return d.fail(t, c)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(stk) > d.max+1 {
+			return ErrMaxDepth
+		}
+
+		// Skip past the item data.
+
+		if l > 0 {
+			if _, err := d.rdr.Discard(l); err != nil {
+				return err
+			}
+		}
+
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// fromHalf converts the bits of a 16-bit floating
+// point number to a 32-bit floating point number.
+
+func fromHalf(h uint16) float32 {
+
+	s := uint32(h&0x8000) << 16
+	x := uint32(h>>10) & 0x1f
+	m := uint32(h & 0x3ff)
+
+	switch x {
+	case 0:
+		f := float32(m) / (1 << 24)
+		if s != 0 {
+			f = -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(s | 0x7f800000 | m<<13)
+	}
+
+	return math.Float32frombits(s | (x+127-15)<<23 | m<<13)
+
+}
@@ -0,0 +1,381 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cbor
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/surrealdb/bump"
+)
+
+// Encoder writes CBOR items and values to a bump
+// Writer. Each item argument is written in the
+// smallest form which can represent it.
+type Encoder struct {
+	wtr *bump.Writer
+	tmp [9]byte
+	cnl bool
+}
+
+// NewEncoder creates a new Encoder which
+// writes to the specified Writer.
+func NewEncoder(w *bump.Writer) *Encoder {
+	return &Encoder{wtr: w}
+}
+
+// Reset instructs the Encoder to write
+// to the specified Writer.
+func (e *Encoder) Reset(w *bump.Writer) {
+	e.wtr = w
+}
+
+// Canonical specifies whether the Encoder uses the
+// deterministic encoding of RFC 8949 section 4.2.
+// In canonical mode floating point numbers are
+// written in the smallest form which preserves
+// their value, map keys are sorted by their
+// encoded bytes, and indefinite-length items
+// can not be written.
+func (e *Encoder) Canonical(v bool) {
+	e.cnl = v
+}
+
+// head writes the initial byte for a major
+// type, followed by the smallest argument
+// which can represent the specified value.
+
+func (e *Encoder) head(m byte, v uint64) error {
+	m <<= 5
+	switch {
+	case v < infoUint8:
+		return e.wtr.WriteByte(m | byte(v))
+	case v <= math.MaxUint8:
+		e.tmp[0], e.tmp[1] = m|infoUint8, byte(v)
+		return e.wtr.WriteBytes(e.tmp[:2])
+	case v <= math.MaxUint16:
+		e.tmp[0] = m | infoUint16
+		binary.BigEndian.PutUint16(e.tmp[1:], uint16(v))
+		return e.wtr.WriteBytes(e.tmp[:3])
+	case v <= math.MaxUint32:
+		e.tmp[0] = m | infoUint32
+		binary.BigEndian.PutUint32(e.tmp[1:], uint32(v))
+		return e.wtr.WriteBytes(e.tmp[:5])
+	}
+	e.tmp[0] = m | infoUint64
+	binary.BigEndian.PutUint64(e.tmp[1:], v)
+	return e.wtr.WriteBytes(e.tmp[:9])
+}
+
+// size writes the initial byte and argument
+// for an item with the specified length.
+
+func (e *Encoder) size(m byte, n int) error {
+	if n < 0 || n > maxLength {
+		return ErrLength
+	}
+	return e.head(m, uint64(n))
+}
+
+// indefinite writes the initial byte for an
+// indefinite-length item of a major type.
+
+func (e *Encoder) indefinite(m byte) error {
+	if e.cnl {
+		return ErrCanonical
+	}
+	return e.wtr.WriteByte(m<<5 | infoIndefinite)
+}
+
+// WriteNil writes a null item.
+func (e *Encoder) WriteNil() error {
+	return e.wtr.WriteByte(codeNull)
+}
+
+// WriteUndefined writes an undefined item.
+func (e *Encoder) WriteUndefined() error {
+	return e.wtr.WriteByte(codeUndefined)
+}
+
+// WriteBool writes a boolean item.
+func (e *Encoder) WriteBool(v bool) error {
+	if v {
+		return e.wtr.WriteByte(codeTrue)
+	}
+	return e.wtr.WriteByte(codeFalse)
+}
+
+// WriteSimple writes a simple value item. The
+// values from 24 to 31 are reserved, and return
+// ErrInvalidCode.
+func (e *Encoder) WriteSimple(v Simple) error {
+	switch {
+	case v < infoUint8:
+		return e.wtr.WriteByte(majorSimple<<5 | byte(v))
+	case v < 32:
+		return ErrInvalidCode
+	}
+	e.tmp[0], e.tmp[1] = codeSimple8, byte(v)
+	return e.wtr.WriteBytes(e.tmp[:2])
+}
+
+// WriteInt writes a signed integer item. Positive
+// integers are written as unsigned integers.
+func (e *Encoder) WriteInt(v int64) error {
+	if v >= 0 {
+		return e.head(majorUint, uint64(v))
+	}
+	return e.head(majorInt, uint64(^v))
+}
+
+// WriteUint writes an unsigned integer item.
+func (e *Encoder) WriteUint(v uint64) error {
+	return e.head(majorUint, v)
+}
+
+// WriteBigInt writes an integer of any size. The
+// integer is written as an integer item if it fits,
+// and otherwise as a bignum.
+func (e *Encoder) WriteBigInt(v *big.Int) error {
+
+	// Write small integers as integer items.
+
+	if v.Sign() >= 0 {
+		if v.IsUint64() {
+			return e.head(majorUint, v.Uint64())
+		}
+		if err := e.head(majorTag, TagPosBignum); err != nil {
+			return err
+		}
+		return e.WriteBytes(v.Bytes())
+	}
+
+	// Negative integers store -1 minus the value.
+
+	n := new(big.Int).Not(v)
+
+	if n.IsUint64() {
+		return e.head(majorInt, n.Uint64())
+	}
+
+	if err := e.head(majorTag, TagNegBignum); err != nil {
+		return err
+	}
+
+	return e.WriteBytes(n.Bytes())
+
+}
+
+// WriteFloat32 writes a 32-bit floating point item,
+// or in canonical mode the smallest floating point
+// item which preserves its value.
+func (e *Encoder) WriteFloat32(v float32) error {
+	if e.cnl {
+		return e.float(float64(v))
+	}
+	e.tmp[0] = codeFloat32
+	binary.BigEndian.PutUint32(e.tmp[1:], math.Float32bits(v))
+	return e.wtr.WriteBytes(e.tmp[:5])
+}
+
+// WriteFloat64 writes a 64-bit floating point item,
+// or in canonical mode the smallest floating point
+// item which preserves its value.
+func (e *Encoder) WriteFloat64(v float64) error {
+	if e.cnl {
+		return e.float(v)
+	}
+	e.tmp[0] = codeFloat64
+	binary.BigEndian.PutUint64(e.tmp[1:], math.Float64bits(v))
+	return e.wtr.WriteBytes(e.tmp[:9])
+}
+
+// float writes the smallest floating point item
+// which preserves the value. Any NaN value is
+// written as the 16-bit quiet NaN.
+
+func (e *Encoder) float(v float64) error {
+
+	if math.IsNaN(v) {
+		e.tmp[0], e.tmp[1], e.tmp[2] = codeFloat16, 0x7e, 0x00
+		return e.wtr.WriteBytes(e.tmp[:3])
+	}
+
+	// Use a 64-bit item if the value needs it.
+
+	f := float32(v)
+
+	if float64(f) != v {
+		e.tmp[0] = codeFloat64
+		binary.BigEndian.PutUint64(e.tmp[1:], math.Float64bits(v))
+		return e.wtr.WriteBytes(e.tmp[:9])
+	}
+
+	// Otherwise use a 16-bit item if possible.
+
+	if h, ok := toHalf(f); ok {
+		e.tmp[0] = codeFloat16
+		binary.BigEndian.PutUint16(e.tmp[1:], h)
+		return e.wtr.WriteBytes(e.tmp[:3])
+	}
+
+	e.tmp[0] = codeFloat32
+	binary.BigEndian.PutUint32(e.tmp[1:], math.Float32bits(f))
+
+	return e.wtr.WriteBytes(e.tmp[:5])
+
+}
+
+// WriteBytes writes a byte string item.
+func (e *Encoder) WriteBytes(v []byte) error {
+	if err := e.size(majorBytes, len(v)); err != nil {
+		return err
+	}
+	return e.wtr.WriteBytes(v)
+}
+
+// WriteString writes a text string item.
+func (e *Encoder) WriteString(v string) error {
+	if err := e.size(majorString, len(v)); err != nil {
+		return err
+	}
+	return e.wtr.WriteString(v)
+}
+
+// WriteStringBytes writes a text string item
+// from the specified byte slice.
+func (e *Encoder) WriteStringBytes(v []byte) error {
+	if err := e.size(majorString, len(v)); err != nil {
+		return err
+	}
+	return e.wtr.WriteBytes(v)
+}
+
+// WriteArrayHeader writes the header of an array
+// item, which must be followed by n items.
+func (e *Encoder) WriteArrayHeader(n int) error {
+	return e.size(majorArray, n)
+}
+
+// WriteMapHeader writes the header of a map item,
+// which must be followed by n pairs of items.
+func (e *Encoder) WriteMapHeader(n int) error {
+	return e.size(majorMap, n)
+}
+
+// WriteBeginBytes writes the header of an
+// indefinite-length byte string item, which must be
+// followed by byte string items, and a break code.
+func (e *Encoder) WriteBeginBytes() error {
+	return e.indefinite(majorBytes)
+}
+
+// WriteBeginString writes the header of an
+// indefinite-length text string item, which must be
+// followed by text string items, and a break code.
+func (e *Encoder) WriteBeginString() error {
+	return e.indefinite(majorString)
+}
+
+// WriteBeginArray writes the header of an
+// indefinite-length array item, which must be
+// followed by any number of items, and a break code.
+func (e *Encoder) WriteBeginArray() error {
+	return e.indefinite(majorArray)
+}
+
+// WriteBeginMap writes the header of an
+// indefinite-length map item, which must be followed
+// by any number of pairs of items, and a break code.
+func (e *Encoder) WriteBeginMap() error {
+	return e.indefinite(majorMap)
+}
+
+// WriteBreak writes the break code which ends
+// an indefinite-length item.
+func (e *Encoder) WriteBreak() error {
+	return e.wtr.WriteByte(codeBreak)
+}
+
+// WriteTag writes a tag number, which must
+// be followed by the tag content item.
+func (e *Encoder) WriteTag(n uint64) error {
+	return e.head(majorTag, n)
+}
+
+// WriteTime writes a time using the epoch time tag
+// with an integer number of seconds if the time has
+// no fractional seconds, and otherwise using the
+// datetime tag, so that no precision is lost.
+func (e *Encoder) WriteTime(v time.Time) error {
+
+	if v.Nanosecond() == 0 {
+		if err := e.head(majorTag, TagEpochTime); err != nil {
+			return err
+		}
+		return e.WriteInt(v.Unix())
+	}
+
+	if err := e.head(majorTag, TagDateTime); err != nil {
+		return err
+	}
+
+	return e.WriteString(v.UTC().Format(time.RFC3339Nano))
+
+}
+
+// WriteUUID writes a UUID using the UUID tag.
+func (e *Encoder) WriteUUID(v UUID) error {
+	if err := e.head(majorTag, TagUUID); err != nil {
+		return err
+	}
+	return e.WriteBytes(v[:])
+}
+
+// toHalf converts a 32-bit floating point number
+// to the bits of a 16-bit floating point number,
+// and returns whether the conversion is exact.
+
+func toHalf(f float32) (uint16, bool) {
+
+	b := math.Float32bits(f)
+	s := uint16(b>>16) & 0x8000
+	x := int(b>>23&0xff) - 127
+	m := b & 0x7fffff
+
+	switch {
+	case x == 128 && m == 0:
+		return s | 0x7c00, true
+	case x == -127 && m == 0:
+		return s, true
+	case x >= -14 && x <= 15:
+		if m&0x1fff != 0 {
+			return 0, false
+		}
+		return s | uint16(x+15)<<10 | uint16(m>>13), true
+	case x >= -24 && x < -14:
+		m |= 0x800000
+		n := uint(-x - 1)
+		if m&(1<<n-1) != 0 {
+			return 0, false
+		}
+		return s | uint16(m>>n), true
+	}
+
+	return 0, false
+
+}
@@ -0,0 +1,894 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cbor
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/surrealdb/bump"
+	"github.com/surrealdb/bump/internal/reflectx"
+)
+
+// field holds the details of a single
+// struct field which is encoded by name.
+
+type field struct {
+	idx int
+	nme string
+	omt bool
+}
+
+// fields holds the encoded fields of a struct
+// type, in declaration order and in canonical
+// order, and an index by name.
+
+type fields struct {
+	lst []field
+	srt []field
+	idx map[string]int
+}
+
+var (
+	structs    sync.Map
+	timeType   = reflect.TypeOf(time.Time{})
+	bigType    = reflect.TypeOf(big.Int{})
+	uuidType   = reflect.TypeOf(UUID{})
+	tagType    = reflect.TypeOf(Tag{})
+	simpleType = reflect.TypeOf(Simple(0))
+)
+
+// Encode writes the specified value as a single
+// item. Booleans, numbers, strings and byte slices
+// are written as the corresponding items, and
+// time.Time, big.Int, UUID, Tag and Simple values
+// are written using tags and simple values. Other
+// slices and arrays are written as arrays, and maps
+// are written as maps, in key order when the keys
+// are booleans, numbers or strings. Pointers and
+// interfaces are written as the value which they
+// refer to, or null. Structs are written as maps of
+// field names to values. In canonical mode, map
+// entries and struct fields are written in the
+// order of their encoded keys.
+//
+// Fields can be renamed or omitted when empty using
+// a struct tag such as `cbor:"name,omitempty"`, and
+// are ignored using `cbor:"-"`.
+func (e *Encoder) Encode(v interface{}) error {
+
+	// Write common types without reflection.
+
+	switch x := v.(type) {
+	case nil:
+		return e.WriteNil()
+	case bool:
+		return e.WriteBool(x)
+	case int:
+		return e.WriteInt(int64(x))
+	case int64:
+		return e.WriteInt(x)
+	case uint64:
+		return e.WriteUint(x)
+	case float64:
+		return e.WriteFloat64(x)
+	case string:
+		return e.WriteString(x)
+	case []byte:
+		if x == nil {
+			return e.WriteNil()
+		}
+		return e.WriteBytes(x)
+	case time.Time:
+		return e.WriteTime(x)
+	case *big.Int:
+		if x == nil {
+			return e.WriteNil()
+		}
+		return e.WriteBigInt(x)
+	case UUID:
+		return e.WriteUUID(x)
+	}
+
+	// Otherwise write the value using reflection.
+
+	return e.encode(reflect.ValueOf(v), 0)
+
+}
+
+func (e *Encoder) encode(v reflect.Value, dep int) error {
+
+	if dep > defaultMaxDepth {
+		return ErrMaxDepth
+	}
+
+	if !v.IsValid() {
+		return e.WriteNil()
+	}
+
+	switch v.Type() {
+	case timeType:
+		return e.WriteTime(v.Interface().(time.Time))
+	case bigType:
+		x := v.Interface().(big.Int)
+		return e.WriteBigInt(&x)
+	case uuidType:
+		return e.WriteUUID(v.Interface().(UUID))
+	case simpleType:
+		return e.WriteSimple(Simple(v.Uint()))
+	case tagType:
+		x := v.Interface().(Tag)
+		if err := e.WriteTag(x.Number); err != nil {
+			return err
+		}
+		return e.encode(reflect.ValueOf(x.Content), dep+1)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return e.WriteBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.WriteInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.WriteUint(v.Uint())
+	case reflect.Float32:
+		return e.WriteFloat32(float32(v.Float()))
+	case reflect.Float64:
+		return e.WriteFloat64(v.Float())
+	case reflect.String:
+		return e.WriteString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			return e.WriteNil()
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.WriteBytes(v.Bytes())
+		}
+		return e.encodeArray(v, dep)
+	case reflect.Array:
+		return e.encodeArray(v, dep)
+	case reflect.Map:
+		if v.IsNil() {
+			return e.WriteNil()
+		}
+		return e.encodeMap(v, dep)
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return e.WriteNil()
+		}
+		return e.encode(v.Elem(), dep+1)
+	case reflect.Struct:
+		return e.encodeStruct(v, dep)
+	}
+
+	return &bump.UnsupportedTypeError{Type: v.Type()}
+
+}
+
+func (e *Encoder) encodeArray(v reflect.Value, dep int) error {
+	if err := e.WriteArrayHeader(v.Len()); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i), dep+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeMap(v reflect.Value, dep int) error {
+
+	if err := e.WriteMapHeader(v.Len()); err != nil {
+		return err
+	}
+
+	// Canonical order depends on the encoded keys,
+	// otherwise write the entries in key order if
+	// possible.
+
+	keys, vals := reflectx.Entries(v)
+
+	if e.cnl {
+		return e.encodeSorted(keys, vals, dep)
+	}
+
+	for i := range keys {
+		if err := e.encode(keys[i], dep+1); err != nil {
+			return err
+		}
+		if err := e.encode(vals[i], dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// encodeSorted writes the entries of a map in
+// the bytewise order of their encoded keys, as
+// required by the deterministic encoding.
+
+func (e *Encoder) encodeSorted(keys, vals []reflect.Value, dep int) error {
+
+	// Encode each of the keys into one buffer.
+
+	var buf []byte
+
+	end := make([]int, len(keys))
+	enc := Encoder{wtr: bump.NewWriterBytes(&buf), cnl: true}
+
+	for i, k := range keys {
+		if err := enc.encode(k, dep+1); err != nil {
+			return err
+		}
+		end[i] = len(buf)
+	}
+
+	key := func(i int) []byte {
+		if i == 0 {
+			return buf[:end[0]]
+		}
+		return buf[end[i-1]:end[i]]
+	}
+
+	// Sort the entries by their encoded keys.
+
+	idx := make([]int, len(keys))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.Slice(idx, func(i, j int) bool {
+		return bytes.Compare(key(idx[i]), key(idx[j])) < 0
+	})
+
+	// Write the encoded key and value of each entry.
+
+	for _, i := range idx {
+		if err := e.wtr.WriteBytes(key(i)); err != nil {
+			return err
+		}
+		if err := e.encode(vals[i], dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+func (e *Encoder) encodeStruct(v reflect.Value, dep int) error {
+
+	f := fieldsOf(v.Type())
+
+	lst := f.lst
+	if e.cnl {
+		lst = f.srt
+	}
+
+	// Count the fields which are not omitted.
+
+	n := 0
+	for _, x := range lst {
+		if !x.omt || !reflectx.IsEmpty(v.Field(x.idx)) {
+			n++
+		}
+	}
+
+	if err := e.WriteMapHeader(n); err != nil {
+		return err
+	}
+
+	// Write the name and value of each field.
+
+	for _, x := range lst {
+		if x.omt && reflectx.IsEmpty(v.Field(x.idx)) {
+			continue
+		}
+		if err := e.WriteString(x.nme); err != nil {
+			return err
+		}
+		if err := e.encode(v.Field(x.idx), dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// Decode reads a single item, and stores it in the
+// value which the specified pointer points to. Items
+// are converted to the type of the value where this
+// is possible, and null and undefined items set the
+// value to its zero value. Any existing value is
+// replaced. When decoding into an empty interface,
+// items are decoded as nil, bool, int64, uint64,
+// float64, string, []byte, []interface{}, Simple,
+// time.Time, *big.Int, UUID and Tag values, and maps
+// are decoded as map[string]interface{} if all of the
+// keys are strings, and otherwise as
+// map[interface{}]interface{}. Negative integers which
+// do not fit in an int64 are decoded as *big.Int values.
+// Unknown struct fields are skipped.
+func (d *Decoder) Decode(v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return bump.ErrInvalidDecode
+	}
+
+	return d.decode(rv.Elem(), 0)
+
+}
+
+// DecodeValue reads a single item, and returns it
+// as one of the types used when decoding into an
+// empty interface.
+func (d *Decoder) DecodeValue() (interface{}, error) {
+	return d.value(0)
+}
+
+func (d *Decoder) decode(v reflect.Value, dep int) error {
+
+	if dep > d.max {
+		return ErrMaxDepth
+	}
+
+	t, err := d.PeekType()
+	if err != nil {
+		return err
+	}
+
+	// Set the zero value for null and undefined items.
+
+	if t == NilType || t == UndefinedType {
+		v.Set(reflect.Zero(v.Type()))
+		_, err := d.rdr.ReadByte()
+		return err
+	}
+
+	switch v.Type() {
+	case timeType:
+		x, err := d.ReadTime()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x))
+		return nil
+	case bigType:
+		x, err := d.ReadBigInt()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x).Elem())
+		return nil
+	case uuidType:
+		x, err := d.ReadUUID()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x))
+		return nil
+	case simpleType:
+		x, err := d.ReadSimple()
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(x))
+		return nil
+	case tagType:
+		n, err := d.ReadTag()
+		if err != nil {
+			return err
+		}
+		x, err := d.value(dep + 1)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(Tag{Number: n, Content: x}))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		x, err := d.ReadBool()
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := d.ReadInt()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(x) {
+			return bump.ErrOverflow
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, err := d.ReadUint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(x) {
+			return bump.ErrOverflow
+		}
+		v.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := d.ReadFloat()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	case reflect.String:
+		b, err := d.readBytes(t)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.readBytes(t)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append(make([]byte, 0, len(b)), b...))
+			return nil
+		}
+		return d.decodeSlice(v, dep)
+	case reflect.Array:
+		return d.decodeArray(v, dep)
+	case reflect.Map:
+		return d.decodeMap(v, dep)
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := d.decode(p.Elem(), dep+1); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return &bump.UnsupportedTypeError{Type: v.Type()}
+		}
+		x, err := d.value(dep)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&x).Elem())
+	case reflect.Struct:
+		return d.decodeStruct(v, dep)
+	default:
+		return &bump.UnsupportedTypeError{Type: v.Type()}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// readBytes reads a text or byte string item as
+// a byte slice, which may refer to the underlying
+// byte slice being read from.
+
+func (d *Decoder) readBytes(t Type) ([]byte, error) {
+	if t == BytesType {
+		return d.ReadBytes()
+	}
+	return d.ReadStringBytes()
+}
+
+// next returns whether another item follows in an
+// array or map with the specified number of items,
+// reading the break code of indefinite-length items.
+
+func (d *Decoder) next(n, i int) (bool, error) {
+	if n >= 0 {
+		return i < n, nil
+	}
+	ok, err := d.ReadBreak()
+	return !ok && err == nil, err
+}
+
+func (d *Decoder) decodeSlice(v reflect.Value, dep int) error {
+
+	n, err := d.ReadArrayHeader()
+	if err != nil {
+		return err
+	}
+
+	s := reflect.MakeSlice(v.Type(), 0, reflectx.InitialSize(n))
+	z := reflect.Zero(v.Type().Elem())
+
+	for i := 0; ; i++ {
+		ok, err := d.next(n, i)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		s = reflect.Append(s, z)
+		if err := d.decode(s.Index(i), dep+1); err != nil {
+			return err
+		}
+	}
+
+	v.Set(s)
+
+	return nil
+
+}
+
+func (d *Decoder) decodeArray(v reflect.Value, dep int) error {
+
+	n, err := d.ReadArrayHeader()
+	if err != nil {
+		return err
+	}
+
+	// Decode as many items as fit in the array,
+	// and skip any items which do not fit.
+
+	i := 0
+
+	for ; ; i++ {
+		ok, err := d.next(n, i)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if i >= v.Len() {
+			if err := d.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(v.Index(i), dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Zero any elements which were not decoded.
+
+	for ; i < v.Len(); i++ {
+		v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+	}
+
+	return nil
+
+}
+
+func (d *Decoder) decodeMap(v reflect.Value, dep int) error {
+
+	n, err := d.ReadMapHeader()
+	if err != nil {
+		return err
+	}
+
+	t := v.Type()
+	m := reflect.MakeMapWithSize(t, reflectx.InitialSize(n))
+
+	for i := 0; ; i++ {
+		ok, err := d.next(n, i)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		k := reflect.New(t.Key()).Elem()
+		if err := d.decode(k, dep+1); err != nil {
+			return err
+		}
+		e := reflect.New(t.Elem()).Elem()
+		if err := d.decode(e, dep+1); err != nil {
+			return err
+		}
+		m.SetMapIndex(k, e)
+	}
+
+	v.Set(m)
+
+	return nil
+
+}
+
+func (d *Decoder) decodeStruct(v reflect.Value, dep int) error {
+
+	n, err := d.ReadMapHeader()
+	if err != nil {
+		return err
+	}
+
+	// Replace any existing field values.
+
+	f := fieldsOf(v.Type())
+
+	v.Set(reflect.Zero(v.Type()))
+
+	// Decode each known field, by name.
+
+	for i := 0; ; i++ {
+		ok, err := d.next(n, i)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		k, err := d.ReadStringBytes()
+		if err != nil {
+			return err
+		}
+		x, ok := f.idx[string(k)]
+		if !ok {
+			if err := d.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(v.Field(x), dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// value reads a single item as one of the
+// types used for empty interfaces.
+
+func (d *Decoder) value(dep int) (interface{}, error) {
+
+	if dep > d.max {
+		return nil, ErrMaxDepth
+	}
+
+	t, err := d.PeekType()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case NilType:
+		return nil, d.ReadNil()
+	case UndefinedType:
+		return nil, d.ReadUndefined()
+	case BoolType:
+		return d.ReadBool()
+	case SimpleType:
+		return d.ReadSimple()
+	case UintType:
+		return d.ReadUint()
+	case IntType:
+		_, v, err := d.head(IntType)
+		if err != nil {
+			return nil, err
+		}
+		if v > math.MaxInt64 {
+			return new(big.Int).Not(new(big.Int).SetUint64(v)), nil
+		}
+		return ^int64(v), nil
+	case FloatType:
+		return d.ReadFloat()
+	case StringType:
+		return d.ReadString()
+	case BytesType:
+		b, err := d.ReadBytes()
+		if err != nil {
+			return nil, err
+		}
+		return append(make([]byte, 0, len(b)), b...), nil
+	case TagType:
+		return d.valueTag(dep)
+	case ArrayType:
+		n, err := d.ReadArrayHeader()
+		if err != nil {
+			return nil, err
+		}
+		a := make([]interface{}, 0, reflectx.InitialSize(n))
+		for i := 0; ; i++ {
+			ok, err := d.next(n, i)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			x, err := d.value(dep + 1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, x)
+		}
+		return a, nil
+	case MapType:
+		return d.valueMap(dep)
+	}
+
+	return nil, ErrInvalidCode
+
+}
+
+// valueTag reads a tagged item, converting the
+// supported tags to the corresponding types.
+
+func (d *Decoder) valueTag(dep int) (interface{}, error) {
+
+	n, err := d.ReadTag()
+	if err != nil {
+		return nil, err
+	}
+
+	switch n {
+	case TagDateTime, TagEpochTime:
+		return d.time(n)
+	case TagPosBignum, TagNegBignum:
+		return d.bignum(n)
+	case TagUUID:
+		return d.uuid(n)
+	}
+
+	x, err := d.value(dep + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return Tag{Number: n, Content: x}, nil
+
+}
+
+// valueMap reads a map item as a map with string
+// keys, switching to a map with interface keys if
+// any of the keys are not strings.
+
+func (d *Decoder) valueMap(dep int) (interface{}, error) {
+
+	n, err := d.ReadMapHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{}, reflectx.InitialSize(n))
+
+	var a map[interface{}]interface{}
+
+	for i := 0; ; i++ {
+
+		ok, err := d.next(n, i)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		// Ensure that the key can be used in a map.
+
+		t, err := d.PeekType()
+		if err != nil {
+			return nil, err
+		}
+
+		if t == ArrayType || t == MapType {
+			return nil, &TypeError{Want: StringType, Got: t}
+		}
+
+		k, err := d.value(dep + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch x := k.(type) {
+		case []byte:
+			k = string(x)
+		case Tag:
+			return nil, &TypeError{Want: StringType, Got: TagType}
+		}
+
+		e, err := d.value(dep + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		// Switch to interface keys if needed.
+
+		s, ok := k.(string)
+
+		if ok && a == nil {
+			m[s] = e
+			continue
+		}
+
+		if a == nil {
+			a = make(map[interface{}]interface{}, reflectx.InitialSize(n))
+			for x, y := range m {
+				a[x] = y
+			}
+		}
+
+		a[k] = e
+
+	}
+
+	if a != nil {
+		return a, nil
+	}
+
+	return m, nil
+
+}
+
+// fieldsOf returns the cached encoded
+// fields of a struct type.
+
+func fieldsOf(t reflect.Type) *fields {
+
+	if f, ok := structs.Load(t); ok {
+		return f.(*fields)
+	}
+
+	f := &fields{idx: make(map[string]int)}
+
+	for _, x := range reflectx.Fields(t, "cbor") {
+		f.idx[x.Name] = x.Index
+		f.lst = append(f.lst, field{
+			idx: x.Index,
+			nme: x.Name,
+			omt: x.OmitEmpty,
+		})
+	}
+
+	// Encoded text strings sort by length, and
+	// then bytewise, in the canonical order.
+
+	f.srt = append([]field(nil), f.lst...)
+
+	sort.Slice(f.srt, func(i, j int) bool {
+		a, b := f.srt[i].nme, f.srt[j].nme
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+
+	// Everything went ok.
+
+	structs.Store(t, f)
+
+	return f
+
+}
@@ -0,0 +1,266 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/surrealdb/bump"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type valueInner struct {
+	Name string
+	Tags []string `cbor:"tags,omitempty"`
+}
+
+type valueRecord struct {
+	ID      uint64 `cbor:"id"`
+	Active  bool
+	Count   int16
+	Ratio   float32
+	Score   float64
+	Data    []byte
+	Items   []valueInner
+	Lookup  map[string]int
+	Pointer *valueInner
+	Missing *valueInner
+	Array   [3]uint8
+	When    time.Time
+	Big     *big.Int
+	UUID    UUID
+	Tag     Tag
+	Any     interface{}
+	Ignored string `cbor:"-"`
+	hidden  string
+}
+
+func valueSample() *valueRecord {
+	return &valueRecord{
+		ID:      math.MaxUint64,
+		Active:  true,
+		Count:   -300,
+		Ratio:   0.5,
+		Score:   -1.25,
+		Data:    []byte("data"),
+		Items:   []valueInner{{Name: "first", Tags: []string{"a"}}, {Name: "second"}},
+		Lookup:  map[string]int{"one": 1, "two": 2, "three": 3},
+		Pointer: &valueInner{Name: "pointer"},
+		Array:   [3]uint8{1, 2, 3},
+		When:    time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Big:     bigInt("-123456789012345678901234567890"),
+		UUID:    UUID{1, 2, 3, 4},
+		Tag:     Tag{Number: 1000, Content: "content"},
+		Any:     "any",
+	}
+}
+
+// valueWrite encodes the specified value into a
+// byte slice, optionally in canonical mode.
+
+func valueWrite(v interface{}, cnl bool) []byte {
+	var b []byte
+	e := NewEncoder(bump.NewWriterBytes(&b))
+	e.Canonical(cnl)
+	if err := e.Encode(v); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestValues(t *testing.T) {
+
+	Convey("Encode should round trip a struct through a byte slice", t, func() {
+		v := valueSample()
+		for _, cnl := range []bool{false, true} {
+			b := valueWrite(v, cnl)
+			var o valueRecord
+			So(NewDecoder(bump.NewReaderBytes(b)).Decode(&o), ShouldBeNil)
+			So(o.When.Equal(v.When), ShouldBeTrue)
+			o.When = v.When
+			So(o, ShouldResemble, *v)
+		}
+	})
+
+	Convey("Encode should round trip values through an io.Writer", t, func() {
+		buf := bytes.NewBuffer(nil)
+		w := bump.NewWriter(buf)
+		e := NewEncoder(w)
+		for i := 0; i < 100; i++ {
+			So(e.Encode(valueSample()), ShouldBeNil)
+		}
+		So(w.Flush(), ShouldBeNil)
+		d := NewDecoder(bump.NewReader(buf))
+		for i := 0; i < 100; i++ {
+			var o valueRecord
+			So(d.Decode(&o), ShouldBeNil)
+			So(o.Lookup, ShouldResemble, valueSample().Lookup)
+		}
+	})
+
+	Convey("Encode should write structs as maps of field names", t, func() {
+		b := valueWrite(valueInner{Name: "x"}, false)
+		So(hex.EncodeToString(b), ShouldEqual, "a1644e616d656178")
+		b = valueWrite(valueInner{Name: "x", Tags: []string{}}, false)
+		So(hex.EncodeToString(b), ShouldEqual, "a1644e616d656178")
+	})
+
+	Convey("Encode should write map entries and fields in canonical order", t, func() {
+		m := map[interface{}]interface{}{"aa": 1, "b": 2, 10: 3, -1: 4, false: 5, 100: 6}
+		b := valueWrite(m, true)
+		So(hex.EncodeToString(b), ShouldEqual, "a60a03186406200461620262616101f405")
+		v := struct {
+			Long  int
+			B     int
+			Short int `cbor:"aa"`
+		}{1, 2, 3}
+		b = valueWrite(v, true)
+		So(hex.EncodeToString(b), ShouldEqual, "a361420262616103644c6f6e6701")
+		b = valueWrite(v, false)
+		So(hex.EncodeToString(b), ShouldEqual, "a3644c6f6e670161420262616103")
+	})
+
+	Convey("DecodeValue should decode generic values", t, func() {
+		b := valueWrite(map[string]interface{}{
+			"nil":   nil,
+			"bool":  true,
+			"int":   -5,
+			"uint":  5,
+			"f32":   float32(1.5),
+			"f64":   2.5,
+			"str":   "s",
+			"bin":   []byte("b"),
+			"array": []interface{}{1, "two"},
+			"map":   map[int]string{1: "one"},
+			"tag":   Tag{Number: 99, Content: []interface{}{"x"}},
+			"time":  time.Unix(1, 2),
+			"big":   bigInt("-99999999999999999999"),
+			"uuid":  UUID{15: 1},
+		}, false)
+		v, err := NewDecoder(bump.NewReaderBytes(b)).DecodeValue()
+		So(err, ShouldBeNil)
+		m := v.(map[string]interface{})
+		So(m["nil"], ShouldBeNil)
+		So(m["bool"], ShouldEqual, true)
+		So(m["int"], ShouldEqual, int64(-5))
+		So(m["uint"], ShouldEqual, uint64(5))
+		So(m["f32"], ShouldEqual, 1.5)
+		So(m["f64"], ShouldEqual, 2.5)
+		So(m["str"], ShouldEqual, "s")
+		So(m["bin"], ShouldResemble, []byte("b"))
+		So(m["array"], ShouldResemble, []interface{}{uint64(1), "two"})
+		So(m["map"], ShouldResemble, map[interface{}]interface{}{uint64(1): "one"})
+		So(m["tag"], ShouldResemble, Tag{Number: 99, Content: []interface{}{"x"}})
+		So(m["time"].(time.Time).Equal(time.Unix(1, 2)), ShouldBeTrue)
+		So(m["big"], ShouldResemble, bigInt("-99999999999999999999"))
+		So(m["uuid"], ShouldEqual, UUID{15: 1})
+	})
+
+	Convey("Decode should convert items to the type of the value", t, func() {
+		var b []byte
+		e := NewEncoder(bump.NewWriterBytes(&b))
+		e.Encode("str")
+		e.Encode([]byte("bin"))
+		e.Encode(nil)
+		e.Encode([]int{1, 2, 3, 4})
+		e.WriteUndefined()
+		e.Encode(12)
+		d := NewDecoder(bump.NewReaderBytes(b))
+		var x []byte
+		So(d.Decode(&x), ShouldBeNil)
+		So(x, ShouldResemble, []byte("str"))
+		var s string
+		So(d.Decode(&s), ShouldBeNil)
+		So(s, ShouldEqual, "bin")
+		p := &valueInner{}
+		So(d.Decode(&p), ShouldBeNil)
+		So(p, ShouldBeNil)
+		var a [2]int
+		So(d.Decode(&a), ShouldBeNil)
+		So(a, ShouldResemble, [2]int{1, 2})
+		i := 5
+		So(d.Decode(&i), ShouldBeNil)
+		So(i, ShouldEqual, 0)
+		var n big.Int
+		So(d.Decode(&n), ShouldBeNil)
+		So(n.Int64(), ShouldEqual, 12)
+	})
+
+	Convey("Decode should read indefinite-length items", t, func() {
+		b, _ := hex.DecodeString("bf644e616d657f6178627979ff64746167739f61616162ffff")
+		var o valueInner
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&o), ShouldBeNil)
+		So(o, ShouldResemble, valueInner{Name: "xyy", Tags: []string{"a", "b"}})
+		b, _ = hex.DecodeString("9f010203ff")
+		var a [2]int
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&a), ShouldBeNil)
+		So(a, ShouldResemble, [2]int{1, 2})
+		var m map[int]bool
+		b, _ = hex.DecodeString("bf01f502f4ff")
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&m), ShouldBeNil)
+		So(m, ShouldResemble, map[int]bool{1: true, 2: false})
+	})
+
+	Convey("Decode should skip unknown struct fields", t, func() {
+		b := valueWrite(map[string]interface{}{
+			"Name":    "x",
+			"Unknown": []interface{}{map[string]int{"a": 1}, Tag{Number: 5, Content: 1}},
+		}, false)
+		o := valueInner{Tags: []string{"old"}}
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&o), ShouldBeNil)
+		So(o, ShouldResemble, valueInner{Name: "x"})
+	})
+
+	Convey("Decode should return errors for invalid values", t, func() {
+		b := valueWrite(1000, false)
+		var i int8
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&i), ShouldEqual, bump.ErrOverflow)
+		var s string
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&s), ShouldResemble, &TypeError{Want: StringType, Got: UintType})
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(s), ShouldEqual, bump.ErrInvalidDecode)
+		var c chan int
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&c), ShouldHaveSameTypeAs, &bump.UnsupportedTypeError{})
+		b, _ = hex.DecodeString("a1810100")
+		_, err := NewDecoder(bump.NewReaderBytes(b)).DecodeValue()
+		So(err, ShouldResemble, &TypeError{Want: StringType, Got: ArrayType})
+	})
+
+	Convey("Decode should limit the nesting depth", t, func() {
+		b := bytes.Repeat([]byte{0x81}, 10)
+		b = append(b, 0x00)
+		d := NewDecoder(bump.NewReaderBytes(b))
+		d.MaxDepth(9)
+		var v interface{}
+		So(d.Decode(&v), ShouldEqual, ErrMaxDepth)
+		d = NewDecoder(bump.NewReaderBytes(b))
+		d.MaxDepth(10)
+		So(d.Decode(&v), ShouldBeNil)
+		b = bytes.Repeat([]byte{0x81}, defaultMaxDepth+2)
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&v), ShouldEqual, ErrMaxDepth)
+	})
+
+	Convey("Decode should not allocate for large corrupt counts", t, func() {
+		b := []byte{0x9a, 0x7f, 0xff, 0xff, 0xff}
+		var v []int
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&v), ShouldNotBeNil)
+	})
+
+}
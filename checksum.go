@@ -0,0 +1,140 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"hash"
+	"hash/crc32"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// NewCRC32C returns a new hash.Hash32 which computes
+// a CRC-32 checksum using the Castagnoli polynomial.
+func NewCRC32C() hash.Hash32 {
+	return crc32.New(castagnoli)
+}
+
+// Checksum instructs the Writer to keep a running
+// checksum, using the specified hash.Hash, of all
+// data written from this point onwards. Passing
+// nil disables the running checksum.
+func (w *Writer) Checksum(h hash.Hash) {
+	if h != nil {
+		h.Reset()
+	}
+	w.sum = h
+}
+
+// Sum returns the running checksum of all data
+// written since the checksum was enabled, or
+// since the last call to WriteChecksum.
+func (w *Writer) Sum() []byte {
+	if w.sum == nil {
+		return nil
+	}
+	return w.sum.Sum(nil)
+}
+
+// WriteChecksum writes the running checksum to the
+// underlying io.Writer, or byte slice, and then
+// resets the running checksum. The checksum bytes
+// are not included in the next running checksum.
+func (w *Writer) WriteChecksum() error {
+
+	// Ensure that a checksum is enabled.
+
+	h := w.sum
+	if h == nil {
+		return ErrNoChecksum
+	}
+
+	// Write the checksum without hashing it.
+
+	w.hsh = h.Sum(w.hsh[:0])
+	w.sum = nil
+	err := w.WriteBytes(w.hsh)
+	w.sum = h
+
+	// Start a new running checksum.
+
+	h.Reset()
+
+	return err
+
+}
+
+// Checksum instructs the Reader to keep a running
+// checksum, using the specified hash.Hash, of all
+// data read from this point onwards. Passing nil
+// disables the running checksum. Peeked bytes are
+// not included until they have been read.
+func (r *Reader) Checksum(h hash.Hash) {
+	if h != nil {
+		h.Reset()
+	}
+	r.sum = h
+}
+
+// Sum returns the running checksum of all data
+// read since the checksum was enabled, or since
+// the last call to ReadChecksum.
+func (r *Reader) Sum() []byte {
+	if r.sum == nil {
+		return nil
+	}
+	return r.sum.Sum(nil)
+}
+
+// ReadChecksum reads a checksum from the underlying
+// io.Reader, or byte slice, and verifies it against
+// the running checksum, before resetting the running
+// checksum. If the checksums do not match, then
+// ErrChecksumMismatch is returned.
+func (r *Reader) ReadChecksum() error {
+
+	// Ensure that a checksum is enabled.
+
+	h := r.sum
+	if h == nil {
+		return ErrNoChecksum
+	}
+
+	// Read the checksum without hashing it.
+
+	r.sum = nil
+	b, err := r.ReadBytes(h.Size())
+	r.sum = h
+	if err != nil {
+		return err
+	}
+
+	// Start a new running checksum.
+
+	r.hsh = h.Sum(r.hsh[:0])
+	h.Reset()
+
+	// Verify the checksum which was read.
+
+	if !bytes.Equal(b, r.hsh) {
+		return ErrChecksumMismatch
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
@@ -0,0 +1,198 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestChecksum(t *testing.T) {
+
+	Convey("Writer should keep a running checksum of all written data", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		w.Checksum(NewCRC32C())
+		chunkWriteBytes(w, txt)
+		w.WriteString("end")
+		h := crc32.New(castagnoli)
+		h.Write(txt)
+		h.Write([]byte("end"))
+		So(w.Sum(), ShouldResemble, h.Sum(nil))
+		So(w.Flush(), ShouldBeNil)
+	})
+
+	Convey("Writer and Reader should round trip a trailing checksum", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		w.Checksum(sha256.New())
+		chunkWriteString(w, string(txt))
+		So(w.WriteChecksum(), ShouldBeNil)
+		w.Flush()
+		So(b.Len(), ShouldEqual, len(txt)+sha256.Size)
+		r := NewReader(b)
+		r.Checksum(sha256.New())
+		o, _ := r.ReadString(len(txt))
+		So(o, ShouldEqual, string(txt))
+		So(r.ReadChecksum(), ShouldBeNil)
+	})
+
+	Convey("Reader should not include peeked bytes in the running checksum", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.Checksum(NewCRC32C())
+		w.WriteBytes(txt)
+		w.WriteChecksum()
+		r := NewReaderBytes(b)
+		r.Checksum(NewCRC32C())
+		for i := 0; i < len(txt); i++ {
+			r.PeekByte()
+			r.PeekByte()
+			r.ReadByte()
+		}
+		So(r.ReadChecksum(), ShouldBeNil)
+	})
+
+	Convey("Reader should error if the trailing checksum does not match", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.Checksum(NewCRC32C())
+		w.WriteBytes(txt)
+		w.WriteChecksum()
+		b[10] ^= 0xff
+		r := NewReaderBytes(b)
+		r.Checksum(NewCRC32C())
+		r.ReadBytes(len(txt))
+		So(r.ReadChecksum(), ShouldEqual, ErrChecksumMismatch)
+	})
+
+	Convey("Reader should error if the trailing checksum is truncated", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.Checksum(NewCRC32C())
+		w.WriteBytes(txt)
+		w.WriteChecksum()
+		r := NewReader(bytes.NewReader(b[:len(b)-2]))
+		r.Checksum(NewCRC32C())
+		r.ReadBytes(len(txt))
+		So(r.ReadChecksum(), ShouldNotBeNil)
+	})
+
+	Convey("Writer and Reader should error if no checksum is enabled", t, func() {
+		var b []byte
+		So(NewWriterBytes(&b).WriteChecksum(), ShouldEqual, ErrNoChecksum)
+		So(NewReaderBytes(b).ReadChecksum(), ShouldEqual, ErrNoChecksum)
+	})
+
+}
+
+func TestFrame(t *testing.T) {
+
+	Convey("Writer and Reader should round trip frames without checksums", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		for i := 0; i < 10; i++ {
+			So(w.WriteFrame(txt[:i*50], nil), ShouldBeNil)
+		}
+		w.Flush()
+		r := NewReader(b)
+		for i := 0; i < 10; i++ {
+			o, e := r.ReadFrame(nil)
+			So(e, ShouldBeNil)
+			So(o, ShouldResemble, txt[:i*50])
+		}
+	})
+
+	Convey("Writer and Reader should round trip frames with checksums", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		for i := 0; i < 10; i++ {
+			So(w.WriteFrame(txt[:i*50], NewCRC32C()), ShouldBeNil)
+		}
+		r := NewReaderBytes(b)
+		for i := 0; i < 10; i++ {
+			o, e := r.ReadFrame(NewCRC32C())
+			So(e, ShouldBeNil)
+			So(o, ShouldResemble, txt[:i*50])
+		}
+	})
+
+	Convey("Reader should error if a frame checksum does not match", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.WriteFrame(txt, NewCRC32C())
+		b[len(b)-10] ^= 0xff
+		r := NewReaderBytes(b)
+		_, e := r.ReadFrame(NewCRC32C())
+		So(e, ShouldEqual, ErrChecksumMismatch)
+	})
+
+	Convey("Reader should error if a frame is truncated", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.WriteFrame(txt, nil)
+		r := NewReader(bytes.NewReader(b[:len(b)-10]))
+		_, e := r.ReadFrame(nil)
+		So(e, ShouldNotBeNil)
+	})
+
+	Convey("Reader should error if a frame length is too large", t, func() {
+		r := NewReaderBytes([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+		_, e := r.ReadFrame(nil)
+		So(e, ShouldEqual, ErrFrameTooLarge)
+	})
+
+	Convey("Reader should not allocate the length of a corrupt frame", t, func() {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		a := m.TotalAlloc
+		r := NewReader(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x07}))
+		_, e := r.ReadFrame(nil)
+		So(e, ShouldEqual, io.EOF)
+		runtime.ReadMemStats(&m)
+		So(m.TotalAlloc-a, ShouldBeLessThan, 1<<20)
+	})
+
+	Convey("Reader should read long frames which grow as they arrive", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.WriteFrame(big, NewCRC32C())
+		r := NewReader(bytes.NewReader(b))
+		o, e := r.ReadFrame(NewCRC32C())
+		So(e, ShouldBeNil)
+		So(o, ShouldResemble, big)
+	})
+
+	Convey("Per-frame checksums should combine with a running checksum", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.Checksum(sha256.New())
+		w.WriteFrame(txt, NewCRC32C())
+		w.WriteFrame(txt, NewCRC32C())
+		w.WriteChecksum()
+		r := NewReaderBytes(b)
+		r.Checksum(sha256.New())
+		r.ReadFrame(NewCRC32C())
+		r.ReadFrame(NewCRC32C())
+		So(r.ReadChecksum(), ShouldBeNil)
+	})
+
+}
@@ -0,0 +1,126 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type closeBuffer struct {
+	bytes.Buffer
+	closed int
+}
+
+func (c *closeBuffer) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestClose(t *testing.T) {
+
+	Convey("Writer should flush and close the underlying io.Writer", t, func() {
+		b := &closeBuffer{}
+		w := NewWriter(b)
+		w.WriteBytes(txt)
+		So(b.Len(), ShouldEqual, 0)
+		So(w.Close(), ShouldBeNil)
+		So(b.Bytes(), ShouldResemble, txt)
+		So(b.closed, ShouldEqual, 1)
+	})
+
+	Convey("Writer should not close the underlying io.Writer if disabled", t, func() {
+		b := &closeBuffer{}
+		w := NewWriter(b)
+		w.CloseUnderlying(false)
+		w.WriteBytes(txt)
+		So(w.Close(), ShouldBeNil)
+		So(b.Bytes(), ShouldResemble, txt)
+		So(b.closed, ShouldEqual, 0)
+	})
+
+	Convey("Writer should close the underlying io.Writer after finalising the codec", t, func() {
+		b := &closeBuffer{}
+		w, _ := NewCompressedWriter(b, Gzip, DefaultCompression)
+		w.WriteBytes(txt)
+		So(w.Close(), ShouldBeNil)
+		So(b.closed, ShouldEqual, 1)
+		r, _ := NewCompressedReader(b, Gzip)
+		o, _ := r.ReadBytes(len(txt))
+		So(o, ShouldResemble, txt)
+	})
+
+	Convey("Writer should return ErrClosed from all methods after closing", t, func() {
+		b := &closeBuffer{}
+		w := NewWriter(b)
+		So(w.Close(), ShouldBeNil)
+		So(w.WriteByte(1), ShouldEqual, ErrClosed)
+		So(w.WriteBytes(txt), ShouldEqual, ErrClosed)
+		So(w.WriteString("test"), ShouldEqual, ErrClosed)
+		So(w.WriteFrame(txt, nil), ShouldEqual, ErrClosed)
+		So(w.Flush(), ShouldEqual, ErrClosed)
+		So(w.Close(), ShouldEqual, ErrClosed)
+		So(b.closed, ShouldEqual, 1)
+	})
+
+	Convey("Writer should be reusable after closing and resetting", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		So(w.Close(), ShouldBeNil)
+		So(w.WriteByte(1), ShouldEqual, ErrClosed)
+		w.ResetBytes(&b)
+		So(w.WriteBytes(txt), ShouldBeNil)
+		So(b, ShouldResemble, txt)
+	})
+
+	Convey("Reader should close the underlying io.Reader", t, func() {
+		b := &closeBuffer{}
+		b.Write(txt)
+		r := NewReader(b)
+		So(r.Close(), ShouldBeNil)
+		So(b.closed, ShouldEqual, 1)
+	})
+
+	Convey("Reader should not close the underlying io.Reader if disabled", t, func() {
+		b := &closeBuffer{}
+		b.Write(txt)
+		r := NewReader(b)
+		r.CloseUnderlying(false)
+		So(r.Close(), ShouldBeNil)
+		So(b.closed, ShouldEqual, 0)
+	})
+
+	Convey("Reader should return ErrClosed from all methods after closing", t, func() {
+		r := NewReaderBytes(txt)
+		So(r.Close(), ShouldBeNil)
+		_, e := r.PeekByte()
+		So(e, ShouldEqual, ErrClosed)
+		_, e = r.ReadByte()
+		So(e, ShouldEqual, ErrClosed)
+		_, e = r.ReadBytes(5)
+		So(e, ShouldEqual, ErrClosed)
+		_, e = r.ReadString(5)
+		So(e, ShouldEqual, ErrClosed)
+		_, e = r.ReadFrame(nil)
+		So(e, ShouldEqual, ErrClosed)
+		So(r.Close(), ShouldEqual, ErrClosed)
+		r.ResetBytes(txt)
+		_, e = r.ReadBytes(5)
+		So(e, ShouldBeNil)
+	})
+
+}
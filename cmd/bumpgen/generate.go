@@ -0,0 +1,846 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const bumpPath = "github.com/surrealdb/bump"
+
+// generator holds the state used when generating
+// the methods for the types in a single package.
+
+type generator struct {
+	buf bytes.Buffer
+	pkg *types.Package
+	imp map[string]*types.Package
+	set map[*types.Named]bool
+	que []*types.Named
+	stk map[types.Type]bool
+	end []string
+	seq int
+}
+
+// generate type-checks the package in the specified
+// directory, and returns the formatted source code
+// of the methods for the specified types. The file
+// which is being generated is ignored, so that any
+// existing generated methods are replaced.
+func generate(dir string, names []string, skip string) ([]byte, error) {
+
+	pkg, err := load(dir, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		pkg: pkg,
+		imp: make(map[string]*types.Package),
+		set: make(map[*types.Named]bool),
+		stk: make(map[types.Type]bool),
+	}
+
+	// Find each of the specified types.
+
+	for _, name := range names {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Name())
+		}
+		t, ok := obj.Type().(*types.Named)
+		if !ok || obj.IsAlias() {
+			return nil, fmt.Errorf("%s is not a named type", name)
+		}
+		g.want(t)
+	}
+
+	// Generate the methods for each type, along
+	// with any further types which are found.
+
+	for len(g.que) > 0 {
+		t := g.que[0]
+		g.que = g.que[1:]
+		if err := g.methods(t); err != nil {
+			return nil, err
+		}
+	}
+
+	// Everything went ok.
+
+	return g.source()
+
+}
+
+// load parses and type-checks the non-test Go files
+// in the specified directory, other than the file
+// which is being generated.
+
+func load(dir, skip string) (*types.Package, error) {
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+
+	var files []*ast.File
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if name == skip || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files found in %s", dir)
+	}
+
+	// Type-check the package from source.
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+	}
+
+	pkg, err := conf.Check(files[0].Name.Name, fset, files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything went ok.
+
+	return pkg, nil
+
+}
+
+// source returns the formatted source code of
+// the file, including the imports which were
+// used by the generated methods.
+
+func (g *generator) source() ([]byte, error) {
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by bumpgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", g.pkg.Name())
+
+	// Write the imports in sorted order.
+
+	var paths []string
+	for p := range g.imp {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	fmt.Fprintf(&b, "import (\n")
+	for _, p := range paths {
+		if p != bumpPath {
+			fmt.Fprintf(&b, "%q\n", p)
+		}
+	}
+	fmt.Fprintf(&b, "\n%q\n)\n", bumpPath)
+
+	b.Write(g.buf.Bytes())
+
+	// Format the generated source code.
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated code: %v", err)
+	}
+
+	return src, nil
+
+}
+
+// want adds a named type to the set of types
+// for which methods are generated.
+
+func (g *generator) want(t *types.Named) {
+	if !g.set[t] {
+		g.set[t] = true
+		g.que = append(g.que, t)
+	}
+}
+
+// method returns whether values of the named type
+// are encoded by calling its MarshalBump method,
+// queueing the type if the method is generated.
+
+func (g *generator) method(t *types.Named) bool {
+
+	if g.set[t] {
+		return true
+	}
+
+	// Use any methods which are defined by hand.
+
+	m := types.NewMethodSet(types.NewPointer(t))
+	if m.Lookup(g.pkg, "MarshalBump") != nil && m.Lookup(g.pkg, "UnmarshalBump") != nil {
+		return true
+	}
+
+	// Generate methods for structs in this package.
+
+	if t.Obj().Pkg() == g.pkg && !isTime(t) {
+		if _, ok := t.Underlying().(*types.Struct); ok {
+			g.want(t)
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// qualify returns the name used to refer to a
+// package, and records the package as an import.
+
+func (g *generator) qualify(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+	g.imp[p.Path()] = p
+	return p.Name()
+}
+
+// typ returns the source code for a type.
+
+func (g *generator) typ(t types.Type) string {
+	return types.TypeString(t, g.qualify)
+}
+
+// tmp returns a new unique variable name.
+
+func (g *generator) tmp(p string) string {
+	g.seq++
+	return fmt.Sprintf("%s%d", p, g.seq)
+}
+
+// p writes a line of source code.
+
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+// check writes a call which returns an error, and
+// returns the error after ending any open scopes.
+
+func (g *generator) check(format string, args ...interface{}) {
+	g.p("if err := "+format+"; err != nil {", args...)
+	for i := len(g.end) - 1; i >= 0; i-- {
+		g.p("%s.End()", g.end[i])
+	}
+	g.p("return err")
+	g.p("}")
+}
+
+// read writes a call which returns a value and an
+// error, storing the value in a new variable.
+
+func (g *generator) read(v, format string, args ...interface{}) {
+	g.p("%s, err := "+format, append([]interface{}{v}, args...)...)
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+}
+
+// size writes the code which caps the initial
+// capacity of slices and maps at the same size
+// as the reflection based decoder does.
+
+func (g *generator) size(l string) string {
+	c := g.tmp("c")
+	g.p("%s := %s", c, l)
+	g.p("if %s > 1024 {", c)
+	g.p("%s = 1024", c)
+	g.p("}")
+	return c
+}
+
+// methods writes the MarshalBump and UnmarshalBump
+// methods for the specified named type.
+
+func (g *generator) methods(t *types.Named) error {
+
+	n := t.Obj().Name()
+
+	// Write the MarshalBump method.
+
+	g.p("")
+	g.p("// MarshalBump writes the %s to the Writer, using", n)
+	g.p("// the same format as bump.Encode.")
+	g.p("func (v *%s) MarshalBump(w *bump.Writer) error {", n)
+	if err := g.encodeValue("(*v)", t, "w"); err != nil {
+		return fmt.Errorf("%s: %v", n, err)
+	}
+	g.p("return nil")
+	g.p("}")
+
+	// Write the UnmarshalBump method.
+
+	g.p("")
+	g.p("// UnmarshalBump reads the %s from the Reader, using", n)
+	g.p("// the same format as bump.Decode.")
+	g.p("func (v *%s) UnmarshalBump(r *bump.Reader) error {", n)
+	if err := g.decodeValue("(*v)", t, "r"); err != nil {
+		return fmt.Errorf("%s: %v", n, err)
+	}
+	g.p("return nil")
+	g.p("}")
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// field holds the details of a single
+// struct field which is encoded by name.
+
+type field struct {
+	nme string
+	src string
+	typ types.Type
+	omt bool
+}
+
+// fields returns the encoded fields of a struct
+// type, in the same way as bump.Encode.
+
+func fields(s *types.Struct) []field {
+
+	var f []field
+
+	for i := 0; i < s.NumFields(); i++ {
+
+		v := s.Field(i)
+
+		// Skip unexported and ignored fields.
+
+		if !v.Exported() {
+			continue
+		}
+
+		tag := reflect.StructTag(s.Tag(i)).Get("bump")
+		if tag == "-" {
+			continue
+		}
+
+		// Parse the field name and options.
+
+		nme, opt := tag, ""
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			nme, opt = tag[:j], tag[j+1:]
+		}
+		if nme == "" {
+			nme = v.Name()
+		}
+
+		f = append(f, field{
+			nme: nme,
+			src: v.Name(),
+			typ: v.Type(),
+			omt: opt == "omitempty",
+		})
+
+	}
+
+	// Everything went ok.
+
+	return f
+
+}
+
+// isTime returns whether the type is time.Time,
+// which is encoded using MarshalBinary.
+
+func isTime(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "time" && n.Obj().Name() == "Time"
+}
+
+// isBytes returns whether the type is a slice
+// of bytes, which is written as a single value.
+
+func isBytes(s *types.Slice) bool {
+	return types.Identical(s.Elem(), types.Typ[types.Uint8])
+}
+
+// isOrdered returns whether map keys of the type
+// are written in sorted order.
+
+func isOrdered(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&(types.IsBoolean|types.IsInteger|types.IsFloat|types.IsString) != 0
+}
+
+// nonEmpty returns an expression which is true
+// when a field with the omitempty option should
+// be written, in the same way as bump.Encode.
+
+func (g *generator) nonEmpty(x string, t types.Type) (string, error) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return x, nil
+		case u.Info()&types.IsString != 0:
+			return fmt.Sprintf("len(%s) != 0", x), nil
+		case u.Info()&types.IsNumeric != 0:
+			return fmt.Sprintf("%s != 0", x), nil
+		}
+	case *types.Slice, *types.Map:
+		return fmt.Sprintf("len(%s) != 0", x), nil
+	case *types.Pointer:
+		return fmt.Sprintf("%s != nil", x), nil
+	case *types.Struct, *types.Array:
+		if types.Comparable(t) {
+			return fmt.Sprintf("%s != (%s{})", x, g.typ(t)), nil
+		}
+	}
+	return "", fmt.Errorf("omitempty is not supported for type %s", t)
+}
+
+// encode writes the code which encodes the value
+// of the expression x, of type t, to the Writer w.
+
+func (g *generator) encode(x string, t types.Type, w string) error {
+
+	// Call the methods of named types if possible.
+
+	if n, ok := t.(*types.Named); ok && g.method(n) {
+		g.check("%s.MarshalBump(%s)", x, w)
+		return nil
+	}
+
+	return g.encodeValue(x, t, w)
+
+}
+
+// encodeValue writes the code which encodes the
+// value of the expression x using its structure.
+
+func (g *generator) encodeValue(x string, t types.Type, w string) error {
+
+	if isTime(t) {
+		b := g.tmp("b")
+		g.p("%s, err := %s.MarshalBinary()", b, x)
+		g.p("if err != nil {")
+		for i := len(g.end) - 1; i >= 0; i-- {
+			g.p("%s.End()", g.end[i])
+		}
+		g.p("return err")
+		g.p("}")
+		g.check("%s.WriteUvarint(uint64(len(%s)))", w, b)
+		g.check("%s.WriteBytes(%s)", w, b)
+		return nil
+	}
+
+	switch u := t.Underlying().(type) {
+
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Bool:
+			g.check("%s.WriteBool(bool(%s))", w, x)
+		case types.Int8:
+			g.check("%s.WriteInt8(int8(%s))", w, x)
+		case types.Int, types.Int16, types.Int32, types.Int64:
+			g.check("%s.WriteVarint(int64(%s))", w, x)
+		case types.Uint8:
+			g.check("%s.WriteUint8(uint8(%s))", w, x)
+		case types.Uint, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+			g.check("%s.WriteUvarint(uint64(%s))", w, x)
+		case types.Float32:
+			g.check("%s.WriteFloat32(float32(%s))", w, x)
+		case types.Float64:
+			g.check("%s.WriteFloat64(float64(%s))", w, x)
+		case types.String:
+			g.check("%s.WriteUvarint(uint64(len(%s)))", w, x)
+			g.check("%s.WriteString(string(%s))", w, x)
+		default:
+			return fmt.Errorf("unsupported type %s", t)
+		}
+
+	case *types.Slice:
+		g.check("%s.WriteUvarint(uint64(len(%s)))", w, x)
+		if isBytes(u) {
+			g.check("%s.WriteBytes(%s)", w, x)
+			return nil
+		}
+		i := g.tmp("i")
+		g.p("for %s := range %s {", i, x)
+		if err := g.encode(x+"["+i+"]", u.Elem(), w); err != nil {
+			return err
+		}
+		g.p("}")
+
+	case *types.Array:
+		i := g.tmp("i")
+		g.p("for %s := range %s {", i, x)
+		if err := g.encode(x+"["+i+"]", u.Elem(), w); err != nil {
+			return err
+		}
+		g.p("}")
+
+	case *types.Map:
+		g.check("%s.WriteUvarint(uint64(len(%s)))", w, x)
+		k, e := g.tmp("k"), g.tmp("e")
+		if isOrdered(u.Key()) {
+			// Collect the values along with their keys,
+			// as entries with NaN keys can not be looked
+			// up, and sort them in the same order as the
+			// keys of maps written using reflection.
+			t, s, v := g.tmp("t"), g.tmp("s"), g.tmp("v")
+			g.p("type %s struct {", t)
+			g.p("k %s", g.typ(u.Key()))
+			g.p("e %s", g.typ(u.Elem()))
+			g.p("}")
+			g.p("%s := make([]%s, 0, len(%s))", s, t, x)
+			g.p("for %s, %s := range %s {", k, e, x)
+			g.p("%s = append(%s, %s{%s, %s})", s, s, t, k, e)
+			g.p("}")
+			g.imp["sort"] = nil
+			g.p("sort.Slice(%s, func(i, j int) bool {", s)
+			b, _ := u.Key().Underlying().(*types.Basic)
+			switch {
+			case b != nil && b.Info()&types.IsBoolean != 0:
+				g.p("return !%s[i].k && %s[j].k", s, s)
+			case b != nil && b.Info()&types.IsFloat != 0:
+				g.imp["math"] = nil
+				g.p("a, b := float64(%s[i].k), float64(%s[j].k)", s, s)
+				g.p("switch {")
+				g.p("case a == a && b == b:")
+				g.p("return a < b")
+				g.p("case a != a && b != b:")
+				g.p("return math.Float64bits(a) < math.Float64bits(b)")
+				g.p("}")
+				g.p("return b != b")
+			default:
+				g.p("return %s[i].k < %s[j].k", s, s)
+			}
+			g.p("})")
+			g.p("for _, %s := range %s {", v, s)
+			g.p("%s, %s := %s.k, %s.e", k, e, v, v)
+		} else {
+			g.p("for %s, %s := range %s {", k, e, x)
+		}
+		if err := g.encode(k, u.Key(), w); err != nil {
+			return err
+		}
+		if err := g.encode(e, u.Elem(), w); err != nil {
+			return err
+		}
+		g.p("}")
+
+	case *types.Pointer:
+		g.p("if %s == nil {", x)
+		g.check("%s.WriteBool(false)", w)
+		g.p("} else {")
+		g.check("%s.WriteBool(true)", w)
+		if err := g.encode("(*"+x+")", u.Elem(), w); err != nil {
+			return err
+		}
+		g.p("}")
+
+	case *types.Struct:
+		if g.stk[t] {
+			return fmt.Errorf("unsupported recursive type %s", t)
+		}
+		g.stk[t] = true
+		defer delete(g.stk, t)
+		return g.encodeStruct(x, u, w)
+
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// encodeStruct writes the code which encodes
+// the fields of a struct, along with a count
+// of the fields which are not omitted.
+
+func (g *generator) encodeStruct(x string, s *types.Struct, w string) error {
+
+	f := fields(s)
+
+	// Count the fields which are not omitted.
+
+	n, c := g.tmp("n"), 0
+	for i := range f {
+		if !f[i].omt {
+			c++
+		}
+	}
+
+	g.p("%s := %d", n, c)
+
+	for i := range f {
+		if f[i].omt {
+			e, err := g.nonEmpty(x+"."+f[i].src, f[i].typ)
+			if err != nil {
+				return fmt.Errorf("field %s: %v", f[i].src, err)
+			}
+			g.p("if %s {", e)
+			g.p("%s++", n)
+			g.p("}")
+		}
+	}
+
+	g.check("%s.WriteUvarint(uint64(%s))", w, n)
+
+	// Write each field within its own scope.
+
+	for i := range f {
+		if f[i].omt {
+			e, _ := g.nonEmpty(x+"."+f[i].src, f[i].typ)
+			g.p("if %s {", e)
+		}
+		s := g.tmp("s")
+		g.p("%s := %s.BeginField(%q)", s, w, f[i].nme)
+		g.end = append(g.end, s)
+		if err := g.encode(x+"."+f[i].src, f[i].typ, w); err != nil {
+			return fmt.Errorf("field %s: %v", f[i].src, err)
+		}
+		g.end = g.end[:len(g.end)-1]
+		g.check("%s.End()", s)
+		if f[i].omt {
+			g.p("}")
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// decode writes the code which decodes a value of
+// type t from the Reader r into the expression x.
+
+func (g *generator) decode(x string, t types.Type, r string) error {
+
+	// Call the methods of named types if possible.
+
+	if n, ok := t.(*types.Named); ok && g.method(n) {
+		g.check("%s.UnmarshalBump(%s)", x, r)
+		return nil
+	}
+
+	return g.decodeValue(x, t, r)
+
+}
+
+// decodeValue writes the code which decodes the
+// value into the expression x using its structure.
+
+func (g *generator) decodeValue(x string, t types.Type, r string) error {
+
+	if isTime(t) {
+		l, b := g.tmp("l"), g.tmp("b")
+		g.read(l, "%s.ReadLength()", r)
+		g.read(b, "%s.ReadBytes(%s)", r, l)
+		g.check("%s.UnmarshalBinary(%s)", x, b)
+		return nil
+	}
+
+	switch u := t.Underlying().(type) {
+
+	case *types.Basic:
+		v := g.tmp("v")
+		switch u.Kind() {
+		case types.Bool:
+			g.read(v, "%s.ReadBool()", r)
+		case types.Int8:
+			g.read(v, "%s.ReadInt8()", r)
+		case types.Int, types.Int16, types.Int32, types.Int64:
+			g.read(v, "%s.ReadVarint()", r)
+			if u.Kind() != types.Int64 {
+				g.p("if int64(%s(%s)) != %s {", g.typ(t), v, v)
+				g.p("return bump.ErrOverflow")
+				g.p("}")
+			}
+		case types.Uint8:
+			g.read(v, "%s.ReadUint8()", r)
+		case types.Uint, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+			g.read(v, "%s.ReadUvarint()", r)
+			if u.Kind() != types.Uint64 {
+				g.p("if uint64(%s(%s)) != %s {", g.typ(t), v, v)
+				g.p("return bump.ErrOverflow")
+				g.p("}")
+			}
+		case types.Float32:
+			g.read(v, "%s.ReadFloat32()", r)
+		case types.Float64:
+			g.read(v, "%s.ReadFloat64()", r)
+		case types.String:
+			l := g.tmp("l")
+			g.read(l, "%s.ReadLength()", r)
+			g.read(v, "%s.ReadString(%s)", r, l)
+		default:
+			return fmt.Errorf("unsupported type %s", t)
+		}
+		g.p("%s = %s(%s)", x, g.typ(t), v)
+
+	case *types.Slice:
+		l := g.tmp("l")
+		g.read(l, "%s.ReadLength()", r)
+		g.p("if %s == 0 {", l)
+		g.p("%s = nil", x)
+		g.p("} else {")
+		if isBytes(u) {
+			b := g.tmp("b")
+			g.read(b, "%s.ReadBytes(%s)", r, l)
+			g.p("%s = %s(append([]byte(nil), %s...))", x, g.typ(t), b)
+		} else {
+			s, z, i := g.tmp("s"), g.tmp("z"), g.tmp("i")
+			g.p("%s := make(%s, 0, %s)", s, g.typ(t), g.size(l))
+			g.p("var %s %s", z, g.typ(u.Elem()))
+			g.p("for %s := 0; %s < %s; %s++ {", i, i, l, i)
+			g.p("%s = append(%s, %s)", s, s, z)
+			if err := g.decode(s+"["+i+"]", u.Elem(), r); err != nil {
+				return err
+			}
+			g.p("}")
+			g.p("%s = %s", x, s)
+		}
+		g.p("}")
+
+	case *types.Array:
+		i := g.tmp("i")
+		g.p("for %s := range %s {", i, x)
+		if err := g.decode(x+"["+i+"]", u.Elem(), r); err != nil {
+			return err
+		}
+		g.p("}")
+
+	case *types.Map:
+		l, m, i := g.tmp("l"), g.tmp("m"), g.tmp("i")
+		g.read(l, "%s.ReadLength()", r)
+		g.p("if %s == 0 {", l)
+		g.p("%s = nil", x)
+		g.p("} else {")
+		g.p("%s := make(%s, %s)", m, g.typ(t), g.size(l))
+		g.p("for %s := 0; %s < %s; %s++ {", i, i, l, i)
+		k, e := g.tmp("k"), g.tmp("e")
+		g.p("var %s %s", k, g.typ(u.Key()))
+		if err := g.decode(k, u.Key(), r); err != nil {
+			return err
+		}
+		g.p("var %s %s", e, g.typ(u.Elem()))
+		if err := g.decode(e, u.Elem(), r); err != nil {
+			return err
+		}
+		g.p("%s[%s] = %s", m, k, e)
+		g.p("}")
+		g.p("%s = %s", x, m)
+		g.p("}")
+
+	case *types.Pointer:
+		ok, p := g.tmp("ok"), g.tmp("p")
+		g.read(ok, "%s.ReadBool()", r)
+		g.p("if !%s {", ok)
+		g.p("%s = nil", x)
+		g.p("} else {")
+		g.p("%s := new(%s)", p, g.typ(u.Elem()))
+		if err := g.decode("(*"+p+")", u.Elem(), r); err != nil {
+			return err
+		}
+		g.p("%s = %s", x, p)
+		g.p("}")
+
+	case *types.Struct:
+		if g.stk[t] {
+			return fmt.Errorf("unsupported recursive type %s", t)
+		}
+		g.stk[t] = true
+		defer delete(g.stk, t)
+		return g.decodeStruct(x, t, u, r)
+
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// decodeStruct writes the code which decodes
+// the fields of a struct, skipping any fields
+// which are not known.
+
+func (g *generator) decodeStruct(x string, t types.Type, s *types.Struct, r string) error {
+
+	f := fields(s)
+
+	// Replace any existing field values.
+
+	g.p("%s = %s{}", x, g.typ(t))
+
+	// Decode each known field from its section.
+
+	n, c := g.tmp("n"), g.tmp("f")
+
+	g.p("if err := %s.ReadFields(func(%s []byte, %s *bump.Reader) error {", r, n, c)
+	g.p("switch string(%s) {", n)
+	for i := range f {
+		g.p("case %q:", f[i].nme)
+		if err := g.decode(x+"."+f[i].src, f[i].typ, c); err != nil {
+			return fmt.Errorf("field %s: %v", f[i].src, err)
+		}
+	}
+	g.p("}")
+	g.p("return nil")
+	g.p("}); err != nil {")
+	g.p("return err")
+	g.p("}")
+
+	// Everything went ok.
+
+	return nil
+
+}
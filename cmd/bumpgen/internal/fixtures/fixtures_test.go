@@ -0,0 +1,238 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fixtures
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/surrealdb/bump"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// The plain types mirror the fixture types, but
+// have no generated methods, so that they are
+// always encoded using reflection.
+
+type plainInner struct {
+	Name  string
+	Score float64
+	Tags  []string `bump:"tags,omitempty"`
+}
+
+type plainNode struct {
+	Value int
+	Next  *plainNode
+}
+
+type plainRecord struct {
+	ID       uint64 `bump:"id"`
+	Active   bool
+	Small    int8
+	Byte     uint8
+	Count    int32
+	Level    Level
+	Ratio    float32
+	Data     []byte
+	Raw      Raw
+	Items    []plainInner
+	Lookup   map[string]int
+	Flags    map[bool][]int16
+	Scores   map[float64]string
+	Pointer  *plainInner
+	Missing  *plainInner
+	Array    [3]uint16
+	When     time.Time
+	List     *plainNode
+	Anon     struct{ X, Y int }
+	Nested   map[uint32]*plainInner
+	Empty    struct{}
+	Optional string     `bump:"optional,omitempty"`
+	Maybe    *plainNode `bump:"maybe,omitempty"`
+	Stamp    time.Time  `bump:"stamp,omitempty"`
+	Ignored  string     `bump:"-"`
+	hidden   string
+}
+
+func sample() *Record {
+	v := &Record{
+		ID:      math.MaxUint64,
+		Active:  true,
+		Small:   -5,
+		Byte:    200,
+		Count:   -123456,
+		Level:   -300,
+		Ratio:   0.5,
+		Data:    []byte("data"),
+		Raw:     Raw("raw"),
+		Items:   []Inner{{Name: "first", Score: 1.5, Tags: []string{"a", "b"}}, {Name: "second", Score: -2}},
+		Lookup:  map[string]int{"one": 1, "two": 2, "three": 3},
+		Flags:   map[bool][]int16{true: {1, -1}, false: {math.MinInt16}},
+		Scores:  map[float64]string{2: "two", math.Inf(-1): "low", 0.5: "half"},
+		Pointer: &Inner{Name: "pointer"},
+		Array:   [3]uint16{1, 2, math.MaxUint16},
+		When:    time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		List:    &Node{1, &Node{2, &Node{3, nil}}},
+		Nested:  map[uint32]*Inner{1: {Name: "one"}, 2: nil},
+	}
+	v.Anon.X, v.Anon.Y = -1, 1
+	return v
+}
+
+func plainSample() *plainRecord {
+	v := &plainRecord{
+		ID:      math.MaxUint64,
+		Active:  true,
+		Small:   -5,
+		Byte:    200,
+		Count:   -123456,
+		Level:   -300,
+		Ratio:   0.5,
+		Data:    []byte("data"),
+		Raw:     Raw("raw"),
+		Items:   []plainInner{{Name: "first", Score: 1.5, Tags: []string{"a", "b"}}, {Name: "second", Score: -2}},
+		Lookup:  map[string]int{"one": 1, "two": 2, "three": 3},
+		Flags:   map[bool][]int16{true: {1, -1}, false: {math.MinInt16}},
+		Scores:  map[float64]string{2: "two", math.Inf(-1): "low", 0.5: "half"},
+		Pointer: &plainInner{Name: "pointer"},
+		Array:   [3]uint16{1, 2, math.MaxUint16},
+		When:    time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		List:    &plainNode{1, &plainNode{2, &plainNode{3, nil}}},
+		Nested:  map[uint32]*plainInner{1: {Name: "one"}, 2: nil},
+	}
+	v.Anon.X, v.Anon.Y = -1, 1
+	return v
+}
+
+func TestFixtures(t *testing.T) {
+
+	Convey("Generated methods should write the same data as Encode", t, func() {
+		var b, c []byte
+		So(sample().MarshalBump(bump.NewWriterBytes(&b)), ShouldBeNil)
+		So(bump.Encode(bump.NewWriterBytes(&c), plainSample()), ShouldBeNil)
+		So(b, ShouldResemble, c)
+	})
+
+	Convey("Generated methods should write omitted fields in the same way as Encode", t, func() {
+		var b, c []byte
+		v, p := sample(), plainSample()
+		v.Optional, p.Optional = "set", "set"
+		v.Maybe, p.Maybe = &Node{Value: 9}, &plainNode{Value: 9}
+		v.Stamp, p.Stamp = time.Unix(1, 0).UTC(), time.Unix(1, 0).UTC()
+		v.Ignored, p.Ignored = "ignored", "ignored"
+		So(v.MarshalBump(bump.NewWriterBytes(&b)), ShouldBeNil)
+		So(bump.Encode(bump.NewWriterBytes(&c), p), ShouldBeNil)
+		So(b, ShouldResemble, c)
+		So(bytes.Contains(b, []byte("ignored")), ShouldBeFalse)
+	})
+
+	Convey("Generated methods should write NaN map keys in the same way as Encode", t, func() {
+		var b, c []byte
+		v, p := sample(), plainSample()
+		for _, m := range []map[float64]string{v.Scores, p.Scores} {
+			m[math.NaN()] = "nan"
+			m[math.Float64frombits(0xfff8000000000001)] = "negative nan"
+			m[math.Inf(1)] = "high"
+		}
+		So(v.MarshalBump(bump.NewWriterBytes(&b)), ShouldBeNil)
+		So(bump.Encode(bump.NewWriterBytes(&c), p), ShouldBeNil)
+		So(b, ShouldResemble, c)
+		So(bytes.Contains(b, []byte("negative nan")), ShouldBeTrue)
+	})
+
+	Convey("Generated methods should read data written by Encode", t, func() {
+		var b []byte
+		So(bump.Encode(bump.NewWriterBytes(&b), plainSample()), ShouldBeNil)
+		o := &Record{Optional: "stale"}
+		So(o.UnmarshalBump(bump.NewReaderBytes(b)), ShouldBeNil)
+		So(o, ShouldResemble, sample())
+	})
+
+	Convey("Decode should read data written by generated methods", t, func() {
+		var b []byte
+		So(sample().MarshalBump(bump.NewWriterBytes(&b)), ShouldBeNil)
+		var o plainRecord
+		So(bump.Decode(bump.NewReaderBytes(b), &o), ShouldBeNil)
+		So(&o, ShouldResemble, plainSample())
+	})
+
+	Convey("Generated methods should round trip through an io.Writer", t, func() {
+		buf := bytes.NewBuffer(nil)
+		w := bump.NewWriter(buf)
+		v := sample()
+		So(v.MarshalBump(w), ShouldBeNil)
+		So(v.MarshalBump(w), ShouldBeNil)
+		So(w.Flush(), ShouldBeNil)
+		r := bump.NewReader(buf)
+		for i := 0; i < 2; i++ {
+			var o Record
+			So(o.UnmarshalBump(r), ShouldBeNil)
+			So(&o, ShouldResemble, v)
+		}
+	})
+
+	Convey("Generated methods should encode named non-struct types", t, func() {
+		var b, c []byte
+		v := IDs{1, 2, math.MaxUint64}
+		So(v.MarshalBump(bump.NewWriterBytes(&b)), ShouldBeNil)
+		So(bump.Encode(bump.NewWriterBytes(&c), []uint64(v)), ShouldBeNil)
+		So(b, ShouldResemble, c)
+		var o IDs
+		So(o.UnmarshalBump(bump.NewReaderBytes(b)), ShouldBeNil)
+		So(o, ShouldResemble, v)
+	})
+
+	Convey("Generated methods should skip unknown fields", t, func() {
+		var b []byte
+		w := bump.NewWriterBytes(&b)
+		w.WriteUvarint(2)
+		s := w.BeginField("unknown")
+		w.WriteString("skipped")
+		s.End()
+		s = w.BeginField("Value")
+		w.WriteVarint(7)
+		s.End()
+		var o Node
+		So(o.UnmarshalBump(bump.NewReaderBytes(b)), ShouldBeNil)
+		So(o, ShouldResemble, Node{Value: 7})
+	})
+
+	Convey("Generated methods should return an error on overflow", t, func() {
+		var b []byte
+		w := bump.NewWriterBytes(&b)
+		w.WriteUvarint(1)
+		s := w.BeginField("Level")
+		w.WriteVarint(math.MaxInt32)
+		s.End()
+		var o Record
+		So(o.UnmarshalBump(bump.NewReaderBytes(b)), ShouldEqual, bump.ErrOverflow)
+	})
+
+	Convey("Generated methods should not allocate when writing", t, func() {
+		b := make([]byte, 0, 4096)
+		v := &Node{1, &Node{2, &Node{3, nil}}}
+		w := bump.NewWriterBytes(&b)
+		n := testing.AllocsPerRun(10, func() {
+			b = b[:0]
+			w.ResetBytes(&b)
+			v.MarshalBump(w)
+		})
+		So(n, ShouldEqual, 0)
+	})
+
+}
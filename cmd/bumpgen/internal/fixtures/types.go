@@ -0,0 +1,70 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fixtures contains types which are used to
+// test that the code generated by bumpgen uses the
+// same format as bump.Encode and bump.Decode.
+package fixtures
+
+import (
+	"time"
+)
+
+//go:generate go run github.com/surrealdb/bump/cmd/bumpgen -type=Record,IDs -output=fixtures_bump.go
+
+type Level int16
+
+type Raw []byte
+
+type IDs []uint64
+
+type Inner struct {
+	Name  string
+	Score float64
+	Tags  []string `bump:"tags,omitempty"`
+}
+
+type Node struct {
+	Value int
+	Next  *Node
+}
+
+type Record struct {
+	ID       uint64 `bump:"id"`
+	Active   bool
+	Small    int8
+	Byte     uint8
+	Count    int32
+	Level    Level
+	Ratio    float32
+	Data     []byte
+	Raw      Raw
+	Items    []Inner
+	Lookup   map[string]int
+	Flags    map[bool][]int16
+	Scores   map[float64]string
+	Pointer  *Inner
+	Missing  *Inner
+	Array    [3]uint16
+	When     time.Time
+	List     *Node
+	Anon     struct{ X, Y int }
+	Nested   map[uint32]*Inner
+	Empty    struct{}
+	Optional string    `bump:"optional,omitempty"`
+	Maybe    *Node     `bump:"maybe,omitempty"`
+	Stamp    time.Time `bump:"stamp,omitempty"`
+	Ignored  string    `bump:"-"`
+	hidden   string
+}
@@ -0,0 +1,94 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command bumpgen generates MarshalBump and UnmarshalBump
+// methods for Go types, which read and write values using
+// the bump.Reader and bump.Writer primitives directly. The
+// generated methods use exactly the same format as the
+// reflection-based bump.Encode and bump.Decode functions,
+// without the cost of reflection.
+//
+// It is designed to be used with go generate:
+//
+//	//go:generate bumpgen -type=Person,Address
+//
+// Any named struct types from the same package which are
+// used by the specified types also have methods generated,
+// so that they can be encoded without reflection. Types
+// which already have a MarshalBump method are encoded by
+// calling that method.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; must be set")
+	output    = flag.String("output", "", "output file name; default <type>_bump.go")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of bumpgen:\n")
+	fmt.Fprintf(os.Stderr, "\tbumpgen [flags] -type T [directory]\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+
+	log.SetFlags(0)
+	log.SetPrefix("bumpgen: ")
+
+	flag.Usage = usage
+	flag.Parse()
+
+	if len(*typeNames) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Find the package directory and output file.
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	types := strings.Split(*typeNames, ",")
+
+	name := *output
+	if name == "" {
+		name = strings.ToLower(types[0]) + "_bump.go"
+	}
+
+	name = filepath.Join(dir, name)
+
+	// Generate and write the methods.
+
+	src, err := generate(dir, types, filepath.Base(name))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(name, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+
+}
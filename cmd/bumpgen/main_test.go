@@ -0,0 +1,50 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGenerate(t *testing.T) {
+
+	dir := filepath.Join("internal", "fixtures")
+
+	Convey("Generated fixtures should be up to date", t, func() {
+		src, err := generate(dir, []string{"Record", "IDs"}, "fixtures_bump.go")
+		So(err, ShouldBeNil)
+		old, err := os.ReadFile(filepath.Join(dir, "fixtures_bump.go"))
+		So(err, ShouldBeNil)
+		So(string(src), ShouldEqual, string(old))
+	})
+
+	Convey("Generation should fail for unknown types", t, func() {
+		_, err := generate(dir, []string{"Unknown"}, "fixtures_bump.go")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Generation should fail for unsupported types", t, func() {
+		tmp := t.TempDir()
+		err := os.WriteFile(filepath.Join(tmp, "types.go"), []byte("package tmp\n\ntype T struct{ F func() }\n"), 0644)
+		So(err, ShouldBeNil)
+		_, err = generate(tmp, []string{"T"}, "t_bump.go")
+		So(err, ShouldNotBeNil)
+	})
+
+}
@@ -0,0 +1,181 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"sync"
+)
+
+// Compression levels which can be passed
+// to NewCompressedWriter. Any other level
+// which is supported by the Codec can
+// also be used.
+const (
+	NoCompression      = flate.NoCompression
+	BestSpeed          = flate.BestSpeed
+	BestCompression    = flate.BestCompression
+	DefaultCompression = flate.DefaultCompression
+	HuffmanOnly        = flate.HuffmanOnly
+)
+
+// Codec represents a compression algorithm
+// which can be layered underneath a Writer,
+// or a Reader.
+type Codec interface {
+	// NewWriter returns a compressing writer
+	// which writes to the specified io.Writer.
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+	// NewReader returns a decompressing reader
+	// which reads from the specified io.Reader.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Built-in compression codecs. Snappy uses the
+// Snappy framing format, which compresses less
+// than the others, but is much faster. It stores
+// data uncompressed at NoCompression, and uses
+// the same compression at every other level.
+var (
+	Gzip   Codec = gzipCodec{}
+	Zlib   Codec = zlibCodec{}
+	Flate  Codec = flateCodec{}
+	Snappy Codec = snappyCodec{}
+)
+
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{
+	m: map[string]Codec{
+		"gzip":   Gzip,
+		"zlib":   Zlib,
+		"flate":  Flate,
+		"snappy": Snappy,
+	},
+}
+
+// RegisterCodec registers a compression Codec
+// under the specified name, replacing any
+// Codec which was previously registered.
+func RegisterCodec(name string, c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.m[name] = c
+}
+
+// LookupCodec returns the compression Codec
+// which was registered under the specified
+// name, if there is one.
+func LookupCodec(name string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.m[name]
+	return c, ok
+}
+
+// flusher represents a compressing io.Writer
+// which can flush any pending compressed data.
+
+type flusher interface {
+	Flush() error
+}
+
+// writeResetter represents a compressing io.Writer
+// which can be reused with a new io.Writer.
+
+type writeResetter interface {
+	Reset(io.Writer)
+}
+
+// readResetter represents a decompressing io.Reader
+// which can be reused with a new io.Reader.
+
+type readResetter interface {
+	Reset(io.Reader) error
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, level)
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type zlibCodec struct{}
+
+func (zlibCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return zlib.NewWriterLevel(w, level)
+}
+
+func (zlibCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	z, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return dictReader{z}, nil
+}
+
+type flateCodec struct{}
+
+func (flateCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return flate.NewWriter(w, level)
+}
+
+func (flateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return dictReader{flate.NewReader(r)}, nil
+}
+
+// dictReader adapts a zlib or flate reader,
+// which is reset with a dictionary, so that
+// it satisfies the readResetter interface.
+
+type dictReader struct {
+	io.ReadCloser
+}
+
+func (d dictReader) Reset(r io.Reader) error {
+	return d.ReadCloser.(flate.Resetter).Reset(r, nil)
+}
+
+// NewCompressedWriter creates a new Writer which
+// compresses all data using the specified Codec,
+// before writing it to the underlying io.Writer.
+// The Writer must be closed with Close in order
+// to finalise the compressed stream.
+func NewCompressedWriter(w io.Writer, c Codec, level int) (*Writer, error) {
+	z, err := c.NewWriter(w, level)
+	if err != nil {
+		return nil, err
+	}
+	return track(&Writer{wtr: z, dst: w, zip: z, cdc: c, lvl: level}), nil
+}
+
+// NewCompressedReader creates a new Reader which
+// decompresses all data using the specified Codec,
+// after reading it from the underlying io.Reader.
+func NewCompressedReader(r io.Reader, c Codec) (*Reader, error) {
+	z, err := c.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{rdr: z, src: r, zip: z, cdc: c}, nil
+}
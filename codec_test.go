@@ -0,0 +1,127 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type nopCodec struct{}
+
+func (nopCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (nopCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(r), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func TestCodec(t *testing.T) {
+
+	for _, name := range []string{"gzip", "zlib", "flate", "snappy"} {
+
+		c, _ := LookupCodec(name)
+
+		Convey("Compressed Writer and Reader should round trip data using "+name, t, func() {
+			b := bytes.NewBuffer(nil)
+			w, e := NewCompressedWriter(b, c, BestSpeed)
+			So(e, ShouldBeNil)
+			chunkWriteBytes(w, txt)
+			So(w.Close(), ShouldBeNil)
+			So(b.Len(), ShouldBeGreaterThan, 0)
+			r, e := NewCompressedReader(b, c)
+			So(e, ShouldBeNil)
+			o := chunkReadBytes(r, len(txt))
+			So(o, ShouldResemble, txt)
+			So(r.Close(), ShouldBeNil)
+		})
+
+		Convey("Compressed Writer and Reader should be reusable after resetting using "+name, t, func() {
+			b := bytes.NewBuffer(nil)
+			w, _ := NewCompressedWriter(b, c, DefaultCompression)
+			r := NewReader(nil)
+			for i := 0; i < 10; i++ {
+				b = bytes.NewBuffer(nil)
+				So(w.Reset(b), ShouldBeNil)
+				w.WriteString(string(txt))
+				So(w.Close(), ShouldBeNil)
+				if i == 0 {
+					r, _ = NewCompressedReader(b, c)
+				} else {
+					So(r.Reset(b), ShouldBeNil)
+				}
+				o, e := r.ReadBytes(len(txt))
+				So(e, ShouldBeNil)
+				So(o, ShouldResemble, txt)
+			}
+		})
+
+	}
+
+	Convey("Compressed Writer should flush data which can be read before closing", t, func() {
+		b := bytes.NewBuffer(nil)
+		w, _ := NewCompressedWriter(b, Gzip, DefaultCompression)
+		w.WriteBytes(txt)
+		So(w.Flush(), ShouldBeNil)
+		z, _ := gzip.NewReader(bytes.NewReader(b.Bytes()))
+		o := make([]byte, len(txt))
+		_, e := io.ReadFull(z, o)
+		So(e, ShouldBeNil)
+		So(o, ShouldResemble, txt)
+	})
+
+	Convey("Compressed Writer should error with an invalid compression level", t, func() {
+		_, e := NewCompressedWriter(bytes.NewBuffer(nil), Gzip, 100)
+		So(e, ShouldNotBeNil)
+	})
+
+	Convey("Compressed Reader should error with invalid compressed data", t, func() {
+		_, e := NewCompressedReader(bytes.NewReader(txt), Gzip)
+		So(e, ShouldNotBeNil)
+	})
+
+	Convey("Registered codecs should be available for lookup", t, func() {
+		_, ok := LookupCodec("unknown")
+		So(ok, ShouldBeFalse)
+		RegisterCodec("nop", nopCodec{})
+		c, ok := LookupCodec("nop")
+		So(ok, ShouldBeTrue)
+		b := bytes.NewBuffer(nil)
+		w, _ := NewCompressedWriter(b, c, 0)
+		w.WriteBytes(txt)
+		So(w.Close(), ShouldBeNil)
+		So(b.Bytes(), ShouldResemble, txt)
+		b = bytes.NewBuffer(nil)
+		So(w.Reset(b), ShouldBeNil)
+		w.WriteBytes(txt)
+		So(w.Close(), ShouldBeNil)
+		So(b.Bytes(), ShouldResemble, txt)
+	})
+
+}
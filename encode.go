@@ -0,0 +1,782 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/surrealdb/bump/internal/reflectx"
+)
+
+// UnsupportedTypeError is returned by Encode and
+// Decode when a value of a type which can not be
+// encoded is encountered.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "bump: unsupported type " + e.Type.String()
+}
+
+// coder holds the functions used to encode
+// and decode values of a specific type.
+
+type coder struct {
+	enc func(w *Writer, v reflect.Value) error
+	dec func(r *Reader, v reflect.Value) error
+}
+
+// field holds the details of a single
+// struct field which is encoded by name.
+
+type field struct {
+	idx int
+	nme string
+	omt bool
+	cdr *coder
+}
+
+var (
+	coders    sync.Map
+	codersMtx sync.Mutex
+	timeType  = reflect.TypeOf(time.Time{})
+)
+
+// limits holds child Readers which are used
+// for decoding length-prefixed struct fields.
+
+var limits = sync.Pool{
+	New: func() interface{} {
+		return new(Reader)
+	},
+}
+
+// Encode writes the specified value to the Writer
+// using reflection. Booleans are written as a byte,
+// 8-bit integers as a byte, other integers as
+// varints, floats in IEEE 754 format, and strings
+// and byte slices with a uvarint length. Slices
+// and maps are written with a uvarint count, and
+// pointers with a presence byte. Structs are written
+// as a uvarint field count, followed by the name and
+// the length-prefixed value of each field, so that
+// unknown fields can be skipped when decoding. Map
+// entries are written in key order when the keys
+// are booleans, numbers or strings, and otherwise
+// in map iteration order.
+//
+// Fields can be renamed or omitted when empty using
+// a struct tag such as `bump:"name,omitempty"`, and
+// are ignored using `bump:"-"`. A time.Time is
+// written using its MarshalBinary method, and any
+// value which implements Marshaler is written using
+// its MarshalBump method.
+func Encode(w *Writer, v interface{}) error {
+
+	// Ensure the value can be encoded.
+
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return ErrInvalidEncode
+	}
+
+	// Use the Marshaler implementation if possible.
+
+	if m, ok := v.(Marshaler); ok {
+		return m.MarshalBump(w)
+	}
+
+	// Encode the value a pointer points to.
+
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	// Encode the value using the cached coder.
+
+	c, err := coderFor(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	return c.enc(w, rv)
+
+}
+
+// Decode reads a value which was written using
+// Encode from the Reader, and stores it in the
+// value which the specified pointer points to.
+// Any existing value is replaced entirely. Any
+// value which implements Unmarshaler is read
+// using its UnmarshalBump method.
+func Decode(r *Reader, v interface{}) error {
+
+	// Ensure the value can be decoded into.
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return ErrInvalidDecode
+	}
+
+	// Use the Unmarshaler implementation if possible.
+
+	if u, ok := v.(Unmarshaler); ok {
+		return u.UnmarshalBump(r)
+	}
+
+	// Decode the value using the cached coder.
+
+	c, err := coderFor(rv.Type().Elem())
+	if err != nil {
+		return err
+	}
+
+	return c.dec(r, rv.Elem())
+
+}
+
+// coderFor returns the cached coder for the
+// specified type, building it if needed.
+
+func coderFor(t reflect.Type) (*coder, error) {
+
+	if c, ok := coders.Load(t); ok {
+		return c.(*coder), nil
+	}
+
+	codersMtx.Lock()
+	defer codersMtx.Unlock()
+
+	// Build the coder and any nested coders.
+
+	m := make(map[reflect.Type]*coder)
+
+	c, err := buildCoder(t, m)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache all of the coders once complete.
+
+	for k, v := range m {
+		coders.Store(k, v)
+	}
+
+	// Everything went ok.
+
+	return c, nil
+
+}
+
+// buildCoder creates a coder for the specified
+// type. Coders which are still being built are
+// kept in the map, so that recursive types
+// refer to the same coder.
+
+func buildCoder(t reflect.Type, m map[reflect.Type]*coder) (*coder, error) {
+
+	if c, ok := coders.Load(t); ok {
+		return c.(*coder), nil
+	}
+
+	if c, ok := m[t]; ok {
+		return c, nil
+	}
+
+	c := &coder{}
+	m[t] = c
+
+	if t == timeType {
+		c.enc, c.dec = encodeTime, decodeTime
+		return c, nil
+	}
+
+	// Use any MarshalBump and UnmarshalBump methods.
+
+	enc, dec := marshalerCoder(t)
+	if enc != nil && dec != nil {
+		c.enc, c.dec = enc, dec
+		return c, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		c.enc, c.dec = encodeBool, decodeBool
+	case reflect.Int8:
+		c.enc, c.dec = encodeInt8, decodeInt8
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		c.enc, c.dec = encodeInt, decodeInt
+	case reflect.Uint8:
+		c.enc, c.dec = encodeUint8, decodeUint8
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		c.enc, c.dec = encodeUint, decodeUint
+	case reflect.Float32:
+		c.enc, c.dec = encodeFloat32, decodeFloat32
+	case reflect.Float64:
+		c.enc, c.dec = encodeFloat64, decodeFloat64
+	case reflect.String:
+		c.enc, c.dec = encodeString, decodeString
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			c.enc, c.dec = encodeBytes, decodeBytes
+			break
+		}
+		e, err := buildCoder(t.Elem(), m)
+		if err != nil {
+			return nil, err
+		}
+		c.enc, c.dec = sliceCoder(t, e)
+	case reflect.Array:
+		e, err := buildCoder(t.Elem(), m)
+		if err != nil {
+			return nil, err
+		}
+		c.enc, c.dec = arrayCoder(e)
+	case reflect.Map:
+		k, err := buildCoder(t.Key(), m)
+		if err != nil {
+			return nil, err
+		}
+		e, err := buildCoder(t.Elem(), m)
+		if err != nil {
+			return nil, err
+		}
+		c.enc, c.dec = mapCoder(t, k, e)
+	case reflect.Ptr:
+		e, err := buildCoder(t.Elem(), m)
+		if err != nil {
+			return nil, err
+		}
+		c.enc, c.dec = ptrCoder(t, e)
+	case reflect.Struct:
+		f, err := buildFields(t, m)
+		if err != nil {
+			return nil, err
+		}
+		c.enc, c.dec = structCoder(t, f)
+	default:
+		return nil, &UnsupportedTypeError{t}
+	}
+
+	// Use whichever of the methods is defined.
+
+	if enc != nil {
+		c.enc = enc
+	}
+
+	if dec != nil {
+		c.dec = dec
+	}
+
+	// Everything went ok.
+
+	return c, nil
+
+}
+
+// buildFields returns the encoded fields of
+// a struct type, using any struct tags.
+
+func buildFields(t reflect.Type, m map[reflect.Type]*coder) ([]field, error) {
+
+	var f []field
+
+	for _, x := range reflectx.Fields(t, "bump") {
+
+		c, err := buildCoder(t.Field(x.Index).Type, m)
+		if err != nil {
+			return nil, err
+		}
+
+		f = append(f, field{
+			idx: x.Index,
+			nme: x.Name,
+			omt: x.OmitEmpty,
+			cdr: c,
+		})
+
+	}
+
+	// Everything went ok.
+
+	return f, nil
+
+}
+
+func encodeBool(w *Writer, v reflect.Value) error {
+	return w.WriteBool(v.Bool())
+}
+
+func decodeBool(r *Reader, v reflect.Value) error {
+	b, err := r.ReadBool()
+	if err != nil {
+		return err
+	}
+	v.SetBool(b)
+	return nil
+}
+
+func encodeInt8(w *Writer, v reflect.Value) error {
+	return w.WriteInt8(int8(v.Int()))
+}
+
+func decodeInt8(r *Reader, v reflect.Value) error {
+	i, err := r.ReadInt8()
+	if err != nil {
+		return err
+	}
+	v.SetInt(int64(i))
+	return nil
+}
+
+func encodeInt(w *Writer, v reflect.Value) error {
+	return w.WriteVarint(v.Int())
+}
+
+func decodeInt(r *Reader, v reflect.Value) error {
+	i, err := r.ReadVarint()
+	if err != nil {
+		return err
+	}
+	if v.OverflowInt(i) {
+		return ErrOverflow
+	}
+	v.SetInt(i)
+	return nil
+}
+
+func encodeUint8(w *Writer, v reflect.Value) error {
+	return w.WriteUint8(uint8(v.Uint()))
+}
+
+func decodeUint8(r *Reader, v reflect.Value) error {
+	i, err := r.ReadUint8()
+	if err != nil {
+		return err
+	}
+	v.SetUint(uint64(i))
+	return nil
+}
+
+func encodeUint(w *Writer, v reflect.Value) error {
+	return w.WriteUvarint(v.Uint())
+}
+
+func decodeUint(r *Reader, v reflect.Value) error {
+	i, err := r.ReadUvarint()
+	if err != nil {
+		return err
+	}
+	if v.OverflowUint(i) {
+		return ErrOverflow
+	}
+	v.SetUint(i)
+	return nil
+}
+
+func encodeFloat32(w *Writer, v reflect.Value) error {
+	return w.WriteFloat32(float32(v.Float()))
+}
+
+func decodeFloat32(r *Reader, v reflect.Value) error {
+	f, err := r.ReadFloat32()
+	if err != nil {
+		return err
+	}
+	v.SetFloat(float64(f))
+	return nil
+}
+
+func encodeFloat64(w *Writer, v reflect.Value) error {
+	return w.WriteFloat64(v.Float())
+}
+
+func decodeFloat64(r *Reader, v reflect.Value) error {
+	f, err := r.ReadFloat64()
+	if err != nil {
+		return err
+	}
+	v.SetFloat(f)
+	return nil
+}
+
+func encodeString(w *Writer, v reflect.Value) error {
+	s := v.String()
+	err := w.WriteUvarint(uint64(len(s)))
+	if err != nil {
+		return err
+	}
+	return w.WriteString(s)
+}
+
+func decodeString(r *Reader, v reflect.Value) error {
+	l, err := r.ReadLength()
+	if err != nil {
+		return err
+	}
+	s, err := r.ReadString(l)
+	if err != nil {
+		return err
+	}
+	v.SetString(s)
+	return nil
+}
+
+func encodeBytes(w *Writer, v reflect.Value) error {
+	b := v.Bytes()
+	err := w.WriteUvarint(uint64(len(b)))
+	if err != nil {
+		return err
+	}
+	return w.WriteBytes(b)
+}
+
+func decodeBytes(r *Reader, v reflect.Value) error {
+	l, err := r.ReadLength()
+	if err != nil {
+		return err
+	}
+	if l == 0 {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	b, err := r.ReadBytes(l)
+	if err != nil {
+		return err
+	}
+	v.SetBytes(append([]byte(nil), b...))
+	return nil
+}
+
+func encodeTime(w *Writer, v reflect.Value) error {
+	b, err := v.Interface().(time.Time).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	err = w.WriteUvarint(uint64(len(b)))
+	if err != nil {
+		return err
+	}
+	return w.WriteBytes(b)
+}
+
+func decodeTime(r *Reader, v reflect.Value) error {
+	l, err := r.ReadLength()
+	if err != nil {
+		return err
+	}
+	b, err := r.ReadBytes(l)
+	if err != nil {
+		return err
+	}
+	var t time.Time
+	err = t.UnmarshalBinary(b)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
+
+func sliceCoder(t reflect.Type, e *coder) (func(*Writer, reflect.Value) error, func(*Reader, reflect.Value) error) {
+
+	enc := func(w *Writer, v reflect.Value) error {
+		err := w.WriteUvarint(uint64(v.Len()))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			err = e.enc(w, v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dec := func(r *Reader, v reflect.Value) error {
+		l, err := r.ReadLength()
+		if err != nil {
+			return err
+		}
+		if l == 0 {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		s := reflect.MakeSlice(t, 0, reflectx.InitialSize(l))
+		z := reflect.Zero(t.Elem())
+		for i := 0; i < l; i++ {
+			s = reflect.Append(s, z)
+			err = e.dec(r, s.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+
+	return enc, dec
+
+}
+
+func arrayCoder(e *coder) (func(*Writer, reflect.Value) error, func(*Reader, reflect.Value) error) {
+
+	enc := func(w *Writer, v reflect.Value) error {
+		for i := 0; i < v.Len(); i++ {
+			err := e.enc(w, v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dec := func(r *Reader, v reflect.Value) error {
+		for i := 0; i < v.Len(); i++ {
+			err := e.dec(r, v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return enc, dec
+
+}
+
+func mapCoder(t reflect.Type, k, e *coder) (func(*Writer, reflect.Value) error, func(*Reader, reflect.Value) error) {
+
+	enc := func(w *Writer, v reflect.Value) error {
+		err := w.WriteUvarint(uint64(v.Len()))
+		if err != nil {
+			return err
+		}
+		keys, vals := reflectx.Entries(v)
+		for i := range keys {
+			err = k.enc(w, keys[i])
+			if err != nil {
+				return err
+			}
+			err = e.enc(w, vals[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dec := func(r *Reader, v reflect.Value) error {
+		l, err := r.ReadLength()
+		if err != nil {
+			return err
+		}
+		if l == 0 {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		m := reflect.MakeMapWithSize(t, reflectx.InitialSize(l))
+		for i := 0; i < l; i++ {
+			key := reflect.New(t.Key()).Elem()
+			err = k.dec(r, key)
+			if err != nil {
+				return err
+			}
+			val := reflect.New(t.Elem()).Elem()
+			err = e.dec(r, val)
+			if err != nil {
+				return err
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+		return nil
+	}
+
+	return enc, dec
+
+}
+
+func ptrCoder(t reflect.Type, e *coder) (func(*Writer, reflect.Value) error, func(*Reader, reflect.Value) error) {
+
+	enc := func(w *Writer, v reflect.Value) error {
+		if v.IsNil() {
+			return w.WriteBool(false)
+		}
+		err := w.WriteBool(true)
+		if err != nil {
+			return err
+		}
+		return e.enc(w, v.Elem())
+	}
+
+	dec := func(r *Reader, v reflect.Value) error {
+		ok, err := r.ReadBool()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		p := reflect.New(t.Elem())
+		err = e.dec(r, p.Elem())
+		if err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+
+	return enc, dec
+
+}
+
+func structCoder(t reflect.Type, f []field) (func(*Writer, reflect.Value) error, func(*Reader, reflect.Value) error) {
+
+	idx := make(map[string]*field, len(f))
+	for i := range f {
+		idx[f[i].nme] = &f[i]
+	}
+
+	enc := func(w *Writer, v reflect.Value) error {
+
+		// Write the number of fields being written.
+
+		n := 0
+		for i := range f {
+			if !f[i].omt || !reflectx.IsEmpty(v.Field(f[i].idx)) {
+				n++
+			}
+		}
+
+		err := w.WriteUvarint(uint64(n))
+		if err != nil {
+			return err
+		}
+
+		// Write the name and value of each field.
+
+		for i := range f {
+			x := v.Field(f[i].idx)
+			if f[i].omt && reflectx.IsEmpty(x) {
+				continue
+			}
+			s := w.BeginField(f[i].nme)
+			err = f[i].cdr.enc(w, x)
+			if e := s.End(); err == nil {
+				err = e
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	}
+
+	dec := func(r *Reader, v reflect.Value) error {
+
+		// Replace any existing field values.
+
+		v.Set(reflect.Zero(t))
+
+		// Decode each known field from its section.
+
+		return r.ReadFields(func(n []byte, c *Reader) error {
+			if x := idx[string(n)]; x != nil {
+				return x.cdr.dec(c, v.Field(x.idx))
+			}
+			return nil
+		})
+
+	}
+
+	return enc, dec
+
+}
+
+// BeginField writes the name of a struct field, and
+// begins a scope for its value. Structs are written
+// by Encode as a uvarint count of fields, followed
+// by each field, so that Marshaler implementations
+// can write data which is compatible with Encode.
+func (w *Writer) BeginField(name string) Scope {
+	w.WriteUvarint(uint64(len(name)))
+	w.WriteString(name)
+	return w.BeginScope(PrefixUvarint)
+}
+
+// ReadFields reads the fields of a struct which
+// was written by Encode, or using BeginField. The
+// function is called with the name of each field
+// and a section Reader which is limited to the
+// value of the field. Any part of the value which
+// is not read is skipped, so unknown fields can be
+// ignored. The name and section Reader must not be
+// retained after the function returns.
+func (r *Reader) ReadFields(fn func(name []byte, f *Reader) error) error {
+
+	// Read the number of fields which were written.
+
+	n, err := r.ReadLength()
+	if err != nil {
+		return err
+	}
+
+	// Read the name and value of each field.
+
+	for i := 0; i < n; i++ {
+
+		l, err := r.ReadLength()
+		if err != nil {
+			return err
+		}
+
+		b, err := r.ReadBytes(l)
+		if err != nil {
+			return err
+		}
+
+		l, err = r.ReadLength()
+		if err != nil {
+			return err
+		}
+
+		// Read the field value within its own section.
+
+		c := r.limit(limits.Get().(*Reader), l)
+		err = fn(b, c)
+		if e := c.Close(); err == nil {
+			err = e
+		}
+		limits.Put(c)
+
+		if err != nil {
+			return err
+		}
+
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
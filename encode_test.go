@@ -0,0 +1,198 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type encodeInner struct {
+	Name  string
+	Score float64
+	Tags  []string `bump:"tags,omitempty"`
+}
+
+type encodeNode struct {
+	Value int
+	Next  *encodeNode
+}
+
+type encodeRecord struct {
+	ID       uint64 `bump:"id"`
+	Active   bool
+	Small    int8
+	Byte     uint8
+	Count    int32
+	Ratio    float32
+	Data     []byte
+	Items    []encodeInner
+	Lookup   map[string]int
+	Pointer  *encodeInner
+	Missing  *encodeInner
+	Array    [3]uint16
+	When     time.Time
+	List     *encodeNode
+	Optional string `bump:"optional,omitempty"`
+	Ignored  string `bump:"-"`
+	hidden   string
+}
+
+type encodeOlder struct {
+	ID    uint64 `bump:"id"`
+	Count int32
+}
+
+func encodeSample() *encodeRecord {
+	return &encodeRecord{
+		ID:     math.MaxUint64,
+		Active: true,
+		Small:  -5,
+		Byte:   200,
+		Count:  -123456,
+		Ratio:  0.5,
+		Data:   txt,
+		Items: []encodeInner{
+			{Name: "first", Score: 1.5, Tags: []string{"a", "b"}},
+			{Name: "second", Score: -2},
+		},
+		Lookup:  map[string]int{"one": 1, "two": 2, "three": 3},
+		Pointer: &encodeInner{Name: "pointer"},
+		Array:   [3]uint16{1, 2, 3},
+		When:    time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		List:    &encodeNode{1, &encodeNode{2, &encodeNode{3, nil}}},
+	}
+}
+
+func TestEncode(t *testing.T) {
+
+	Convey("Encode should round trip a struct through a byte slice", t, func() {
+		var b []byte
+		v := encodeSample()
+		So(Encode(NewWriterBytes(&b), v), ShouldBeNil)
+		var o encodeRecord
+		So(Decode(NewReaderBytes(b), &o), ShouldBeNil)
+		So(o, ShouldResemble, *v)
+	})
+
+	Convey("Encode should round trip a struct through an io.Writer", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		v := encodeSample()
+		So(Encode(w, v), ShouldBeNil)
+		So(Encode(w, v), ShouldBeNil)
+		w.Flush()
+		r := NewReader(b)
+		for i := 0; i < 2; i++ {
+			var o encodeRecord
+			So(Decode(r, &o), ShouldBeNil)
+			So(o, ShouldResemble, *v)
+		}
+	})
+
+	Convey("Encode should write the same data for values and pointers", t, func() {
+		var b, c []byte
+		v := encodeSample()
+		Encode(NewWriterBytes(&b), v)
+		Encode(NewWriterBytes(&c), *v)
+		So(c, ShouldResemble, b)
+	})
+
+	Convey("Encode should write map entries in key order", t, func() {
+		var b []byte
+		Encode(NewWriterBytes(&b), map[string]int{"c": -1, "a": 1, "b": 2})
+		So(b, ShouldResemble, []byte{3, 1, 'a', 2, 1, 'b', 4, 1, 'c', 1})
+		n1 := math.Float64frombits(0x7ff8000000000001)
+		n2 := math.Float64frombits(0x7ff8000000000002)
+		b = b[:0]
+		Encode(NewWriterBytes(&b), map[float64]bool{n2: true, 1: false, n1: false, -1: true})
+		So(b, ShouldResemble, []byte{
+			4,
+			0xbf, 0xf0, 0, 0, 0, 0, 0, 0, 1,
+			0x3f, 0xf0, 0, 0, 0, 0, 0, 0, 0,
+			0x7f, 0xf8, 0, 0, 0, 0, 0, 1, 0,
+			0x7f, 0xf8, 0, 0, 0, 0, 0, 2, 1,
+		})
+	})
+
+	Convey("Encode should omit empty and ignored fields", t, func() {
+		var b []byte
+		v := encodeSample()
+		v.Ignored = "ignored"
+		v.hidden = "hidden"
+		Encode(NewWriterBytes(&b), v)
+		So(bytes.Contains(b, []byte("optional")), ShouldBeFalse)
+		So(bytes.Contains(b, []byte("ignored")), ShouldBeFalse)
+		So(bytes.Contains(b, []byte("hidden")), ShouldBeFalse)
+		v.Optional = "set"
+		b = b[:0]
+		Encode(NewWriterBytes(&b), v)
+		So(bytes.Contains(b, []byte("optional")), ShouldBeTrue)
+	})
+
+	Convey("Decode should skip unknown fields", t, func() {
+		var b []byte
+		v := encodeSample()
+		w := NewWriterBytes(&b)
+		Encode(w, v)
+		w.WriteByte(1)
+		var o encodeOlder
+		r := NewReaderBytes(b)
+		So(Decode(r, &o), ShouldBeNil)
+		So(o.ID, ShouldEqual, v.ID)
+		So(o.Count, ShouldEqual, v.Count)
+		x, _ := r.ReadByte()
+		So(x, ShouldEqual, 1)
+	})
+
+	Convey("Decode should replace existing values", t, func() {
+		var b []byte
+		Encode(NewWriterBytes(&b), encodeInner{Name: "new"})
+		o := encodeInner{Name: "old", Score: 1, Tags: []string{"old"}}
+		So(Decode(NewReaderBytes(b), &o), ShouldBeNil)
+		So(o, ShouldResemble, encodeInner{Name: "new"})
+	})
+
+	Convey("Decode should return an error for overflowing numbers", t, func() {
+		var b []byte
+		Encode(NewWriterBytes(&b), int64(math.MaxInt64))
+		var o int16
+		So(Decode(NewReaderBytes(b), &o), ShouldEqual, ErrOverflow)
+	})
+
+	Convey("Decode should return an error for truncated data", t, func() {
+		var b []byte
+		Encode(NewWriterBytes(&b), encodeSample())
+		var o encodeRecord
+		So(Decode(NewReaderBytes(b[:len(b)/2]), &o), ShouldNotBeNil)
+	})
+
+	Convey("Encode and Decode should reject invalid values", t, func() {
+		var b []byte
+		var p *encodeInner
+		So(Encode(NewWriterBytes(&b), nil), ShouldEqual, ErrInvalidEncode)
+		So(Encode(NewWriterBytes(&b), p), ShouldEqual, ErrInvalidEncode)
+		So(Decode(NewReaderBytes(b), encodeInner{}), ShouldEqual, ErrInvalidDecode)
+		So(Decode(NewReaderBytes(b), p), ShouldEqual, ErrInvalidDecode)
+		err := Encode(NewWriterBytes(&b), struct{ C chan int }{})
+		So(err, ShouldHaveSameTypeAs, &UnsupportedTypeError{})
+		So(err.Error(), ShouldEqual, "bump: unsupported type chan int")
+	})
+
+}
@@ -0,0 +1,67 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"errors"
+)
+
+var (
+	// ErrClosed is returned when a Writer or Reader
+	// is used after it has been closed.
+	ErrClosed = errors.New("bump: use of closed writer or reader")
+	// ErrChecksumMismatch is returned when a checksum
+	// which was read does not match the checksum of
+	// the data which was read before it.
+	ErrChecksumMismatch = errors.New("bump: checksum mismatch")
+	// ErrNoChecksum is returned when a checksum is
+	// written or read, but no running checksum has
+	// been enabled with Checksum.
+	ErrNoChecksum = errors.New("bump: no checksum enabled")
+	// ErrFrameTooLarge is returned when a frame which
+	// is being read has a length which is too large.
+	ErrFrameTooLarge = errors.New("bump: frame too large")
+	// ErrNegativeCount is returned when a negative
+	// number of bytes is discarded or limited.
+	ErrNegativeCount = errors.New("bump: negative count")
+	// ErrScopeOrder is returned when a scope is ended
+	// before the scopes which are nested within it,
+	// or when a Writer is closed with a scope open.
+	ErrScopeOrder = errors.New("bump: scope ended out of order")
+	// ErrScopeTooLarge is returned when the length of
+	// a scope does not fit in its length prefix.
+	ErrScopeTooLarge = errors.New("bump: scope too large for length prefix")
+	// ErrInvalidEncode is returned when Encode is
+	// called with a nil value or nil pointer.
+	ErrInvalidEncode = errors.New("bump: encode requires a non-nil value")
+	// ErrInvalidDecode is returned when Decode is
+	// not called with a non-nil pointer.
+	ErrInvalidDecode = errors.New("bump: decode requires a non-nil pointer")
+	// ErrOverflow is returned when a decoded number
+	// does not fit in the type being decoded into.
+	ErrOverflow = errors.New("bump: decoded value overflows type")
+	// ErrInvalidTag is returned when a Protocol Buffers
+	// tag has an invalid field number or wire type.
+	ErrInvalidTag = errors.New("bump: invalid protobuf tag")
+	// ErrInvalidText is returned when hex, base64
+	// or base32 text can not be decoded.
+	ErrInvalidText = errors.New("bump: invalid hex, base64 or base32 text")
+	// ErrInvalidLevel is returned when a Codec does
+	// not support the specified compression level.
+	ErrInvalidLevel = errors.New("bump: invalid compression level")
+	// ErrInvalidSnappy is returned when data which
+	// is read with the Snappy codec is not valid.
+	ErrInvalidSnappy = errors.New("bump: invalid snappy data")
+)
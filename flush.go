@@ -0,0 +1,110 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"sync"
+	"time"
+)
+
+// FlushAfter instructs the Writer to flush its
+// buffered data whenever at least n bytes have
+// been buffered. Passing 0 disables this policy.
+func (w *Writer) FlushAfter(n int) {
+	w.max = n
+}
+
+// FlushOnNewline instructs the Writer to flush its
+// buffered data whenever a write contains a newline
+// character, so that each line-delimited message
+// is sent as soon as it has been written.
+func (w *Writer) FlushOnNewline(v bool) {
+	w.nwl = v
+}
+
+// FlushEvery instructs the Writer to flush its
+// buffered data in the background, once data has
+// been buffered for the specified duration. Any
+// error from a background flush is returned from
+// all later calls on the Writer. Close stops any
+// background flushing. Passing 0 disables this
+// policy.
+func (w *Writer) FlushEvery(d time.Duration) {
+
+	// Initialise the lock if needed.
+
+	if w.mtx == nil {
+		w.mtx = new(sync.Mutex)
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	// Stop the timer if disabled.
+
+	if d <= 0 && w.tmr != nil {
+		w.tmr.Stop()
+		w.arm = false
+	}
+
+	w.ivl = d
+
+}
+
+func (w *Writer) policy(nl bool) error {
+
+	// Flush if a policy threshold was reached.
+
+	if nl || (w.max > 0 && w.pos >= w.max) {
+		return w.flushAll()
+	}
+
+	// Start the timer if data is now buffered.
+
+	if w.ivl > 0 && w.pos > 0 && !w.arm {
+		w.arm = true
+		if w.tmr == nil {
+			w.tmr = time.AfterFunc(w.ivl, w.tick)
+		} else {
+			w.tmr.Reset(w.ivl)
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+func (w *Writer) tick() {
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	// Ignore the timer if it was stopped.
+
+	if !w.arm {
+		return
+	}
+
+	w.arm = false
+
+	// Flush the data in the background.
+
+	if w.err == nil && w.out == nil {
+		w.flushAll()
+	}
+
+}
@@ -0,0 +1,140 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+	err error
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) Len() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.buf.Len()
+}
+
+func (s *syncBuffer) Bytes() []byte {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]byte(nil), s.buf.Bytes()...)
+}
+
+func TestFlush(t *testing.T) {
+
+	Convey("Writer should flush once the size threshold is reached", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		w.FlushAfter(100)
+		w.WriteBytes(txt[:99])
+		So(b.Len(), ShouldEqual, 0)
+		w.WriteByte(txt[99])
+		So(b.Len(), ShouldEqual, 100)
+		w.WriteString("test")
+		So(b.Len(), ShouldEqual, 100)
+		w.FlushAfter(0)
+		w.WriteBytes(txt[:200])
+		So(b.Len(), ShouldEqual, 100)
+		So(w.Flush(), ShouldBeNil)
+	})
+
+	Convey("Writer should flush whenever a newline is written", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		w.FlushOnNewline(true)
+		w.WriteString("{\"id\":1}")
+		So(b.Len(), ShouldEqual, 0)
+		w.WriteByte('\n')
+		So(b.String(), ShouldEqual, "{\"id\":1}\n")
+		w.WriteBytes([]byte("{\"id\":2}\n{"))
+		So(b.String(), ShouldEqual, "{\"id\":1}\n{\"id\":2}\n{")
+		w.WriteString("\"id\":3}\n")
+		So(b.String(), ShouldEqual, "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n")
+	})
+
+	Convey("Writer should flush in the background once the interval has elapsed", t, func() {
+		b := &syncBuffer{}
+		w := NewWriter(b)
+		w.FlushEvery(10 * time.Millisecond)
+		w.WriteString("message")
+		So(b.Len(), ShouldEqual, 0)
+		for i := 0; i < 100 && b.Len() == 0; i++ {
+			time.Sleep(5 * time.Millisecond)
+		}
+		So(string(b.Bytes()), ShouldEqual, "message")
+		w.WriteString("another")
+		for i := 0; i < 100 && b.Len() == 7; i++ {
+			time.Sleep(5 * time.Millisecond)
+		}
+		So(string(b.Bytes()), ShouldEqual, "messageanother")
+		So(w.Close(), ShouldBeNil)
+	})
+
+	Convey("Writer should stop background flushing when closed", t, func() {
+		b := &syncBuffer{}
+		w := NewWriter(b)
+		w.FlushEvery(10 * time.Millisecond)
+		for i := 0; i < 10; i++ {
+			w.WriteString("message")
+		}
+		So(w.Close(), ShouldBeNil)
+		So(b.Len(), ShouldEqual, 70)
+		time.Sleep(20 * time.Millisecond)
+		So(b.Len(), ShouldEqual, 70)
+	})
+
+	Convey("Writer should return background flush errors from the next call", t, func() {
+		b := &syncBuffer{err: ErrChecksumMismatch}
+		w := NewWriter(b)
+		w.FlushEvery(time.Millisecond)
+		w.WriteString("message")
+		time.Sleep(20 * time.Millisecond)
+		So(w.WriteString("another"), ShouldEqual, ErrChecksumMismatch)
+	})
+
+	Convey("Writer should be safe to write to while flushing in the background", t, func() {
+		b := &syncBuffer{}
+		w := NewWriter(b)
+		w.FlushEvery(time.Microsecond)
+		for i := 0; i < 1000; i++ {
+			w.WriteBytes(txt[:i%len(txt)])
+		}
+		So(w.Close(), ShouldBeNil)
+		x := bytes.NewBuffer(nil)
+		for i := 0; i < 1000; i++ {
+			x.Write(txt[:i%len(txt)])
+		}
+		So(b.Bytes(), ShouldResemble, x.Bytes())
+	})
+
+}
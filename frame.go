@@ -0,0 +1,109 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"math"
+)
+
+// maxFrameSize is the largest frame length
+// which will be accepted when reading frames.
+const maxFrameSize = math.MaxInt32
+
+// WriteFrame writes a frame, consisting of a uvarint
+// length prefix followed by the frame data. If a
+// hash.Hash is specified, then a checksum of the
+// frame data is written after the frame data.
+func (w *Writer) WriteFrame(v []byte, h hash.Hash) error {
+
+	// Write the length of the frame data.
+
+	n := binary.PutUvarint(w.tmp[:], uint64(len(v)))
+	err := w.WriteBytes(w.tmp[:n])
+	if err != nil {
+		return err
+	}
+
+	// Write the frame data itself.
+
+	err = w.WriteBytes(v)
+	if err != nil {
+		return err
+	}
+
+	// Write the frame checksum if specified.
+
+	if h != nil {
+		h.Reset()
+		h.Write(v)
+		w.hsh = h.Sum(w.hsh[:0])
+		return w.WriteBytes(w.hsh)
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// ReadFrame reads a frame which was written using
+// WriteFrame. If a hash.Hash is specified, then the
+// frame checksum is read and verified, returning
+// ErrChecksumMismatch if the checksums differ.
+func (r *Reader) ReadFrame(h hash.Hash) ([]byte, error) {
+
+	// Read the length of the frame data.
+
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the frame length is sensible.
+
+	if l > maxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+
+	// Read the frame data itself.
+
+	b, err := r.ReadBytes(int(l))
+	if err != nil {
+		return nil, err
+	}
+
+	// Read and verify the frame checksum.
+
+	if h != nil {
+		c, err := r.ReadBytes(h.Size())
+		if err != nil {
+			return nil, err
+		}
+		h.Reset()
+		h.Write(b)
+		r.hsh = h.Sum(r.hsh[:0])
+		if !bytes.Equal(c, r.hsh) {
+			return nil, ErrChecksumMismatch
+		}
+	}
+
+	// Everything went ok.
+
+	return b, nil
+
+}
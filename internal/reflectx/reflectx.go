@@ -0,0 +1,194 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reflectx contains the reflection helpers which
+// are shared by the struct encoders of bump, msgpack and
+// cbor, which differ only in the name of their struct tag.
+package reflectx
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Field holds the details of a single
+// struct field which is encoded by name.
+type Field struct {
+	Index     int
+	Name      string
+	OmitEmpty bool
+}
+
+// Fields returns the encoded fields of a struct
+// type in declaration order, using the struct tag
+// with the specified name. Unexported fields, and
+// fields which are tagged "-", are skipped.
+func Fields(t reflect.Type, tag string) []Field {
+
+	var f []Field
+
+	for i := 0; i < t.NumField(); i++ {
+
+		s := t.Field(i)
+
+		// Skip unexported and ignored fields.
+
+		if !s.IsExported() {
+			continue
+		}
+
+		val := s.Tag.Get(tag)
+		if val == "-" {
+			continue
+		}
+
+		// Parse the field name and options.
+
+		nme, opt := val, ""
+		if j := strings.IndexByte(val, ','); j >= 0 {
+			nme, opt = val[:j], val[j+1:]
+		}
+		if nme == "" {
+			nme = s.Name
+		}
+
+		f = append(f, Field{
+			Index:     i,
+			Name:      nme,
+			OmitEmpty: opt == "omitempty",
+		})
+
+	}
+
+	// Everything went ok.
+
+	return f
+
+}
+
+// IsEmpty returns whether a field with the
+// omitempty option should be omitted.
+func IsEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// InitialSize limits how much space is allocated
+// up front for slices and maps, so that a corrupt
+// count can not exhaust memory. A negative count,
+// such as that of an indefinite-length item, starts
+// with no space.
+func InitialSize(n int) int {
+	switch {
+	case n < 0:
+		return 0
+	case n > 1024:
+		return 1024
+	}
+	return n
+}
+
+// KeyOrder returns a function which orders map
+// keys of the specified type, or nil if the keys
+// are not ordered. NaN floating point keys sort
+// after all other keys, ordered by their bits.
+// Keys which are NaN with the same bits can not
+// be told apart, so their entries are written
+// in an unspecified order.
+func KeyOrder(t reflect.Type) func(a, b reflect.Value) bool {
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(a, b reflect.Value) bool { return !a.Bool() && b.Bool() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		return func(a, b reflect.Value) bool { return floatLess(a.Float(), b.Float()) }
+	case reflect.String:
+		return func(a, b reflect.Value) bool { return a.String() < b.String() }
+	}
+	return nil
+}
+
+// Entries returns the keys and values of a map,
+// in key order if the keys are ordered. Values are
+// collected along with their keys, as entries with
+// NaN keys can not be looked up.
+func Entries(v reflect.Value) ([]reflect.Value, []reflect.Value) {
+
+	e := entries{
+		key: make([]reflect.Value, 0, v.Len()),
+		val: make([]reflect.Value, 0, v.Len()),
+	}
+
+	for i := v.MapRange(); i.Next(); {
+		e.key = append(e.key, i.Key())
+		e.val = append(e.val, i.Value())
+	}
+
+	// Sort the entries if the keys are ordered.
+
+	if e.lss = KeyOrder(v.Type().Key()); e.lss != nil {
+		sort.Sort(e)
+	}
+
+	// Everything went ok.
+
+	return e.key, e.val
+
+}
+
+// entries sorts the keys and values of a map
+// together, using the order of the keys.
+
+type entries struct {
+	key []reflect.Value
+	val []reflect.Value
+	lss func(a, b reflect.Value) bool
+}
+
+func (e entries) Len() int {
+	return len(e.key)
+}
+
+func (e entries) Less(i, j int) bool {
+	return e.lss(e.key[i], e.key[j])
+}
+
+func (e entries) Swap(i, j int) {
+	e.key[i], e.key[j] = e.key[j], e.key[i]
+	e.val[i], e.val[j] = e.val[j], e.val[i]
+}
+
+// floatLess orders floating point numbers in
+// numeric order, followed by NaN values in the
+// order of their bits.
+
+func floatLess(a, b float64) bool {
+	switch {
+	case a == a && b == b:
+		return a < b
+	case a != a && b != b:
+		return math.Float64bits(a) < math.Float64bits(b)
+	}
+	return b != b
+}
@@ -0,0 +1,87 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reflectx
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type tagged struct {
+	A int
+	B string `test:"b,omitempty"`
+	C bool   `test:",omitempty"`
+	D int    `test:"-"`
+	e int
+	F []int `other:"f"`
+}
+
+func TestReflectx(t *testing.T) {
+
+	Convey("Fields should use the specified struct tag", t, func() {
+		So(Fields(reflect.TypeOf(tagged{}), "test"), ShouldResemble, []Field{
+			{Index: 0, Name: "A"},
+			{Index: 1, Name: "b", OmitEmpty: true},
+			{Index: 2, Name: "C", OmitEmpty: true},
+			{Index: 5, Name: "F"},
+		})
+	})
+
+	Convey("IsEmpty should report empty values", t, func() {
+		var i interface{}
+		So(IsEmpty(reflect.ValueOf("")), ShouldBeTrue)
+		So(IsEmpty(reflect.ValueOf([]int{})), ShouldBeTrue)
+		So(IsEmpty(reflect.ValueOf((*int)(nil))), ShouldBeTrue)
+		So(IsEmpty(reflect.ValueOf(&i).Elem()), ShouldBeTrue)
+		So(IsEmpty(reflect.ValueOf(0)), ShouldBeTrue)
+		So(IsEmpty(reflect.ValueOf(tagged{})), ShouldBeTrue)
+		So(IsEmpty(reflect.ValueOf("a")), ShouldBeFalse)
+		So(IsEmpty(reflect.ValueOf(1)), ShouldBeFalse)
+	})
+
+	Convey("InitialSize should limit the space allocated up front", t, func() {
+		So(InitialSize(-1), ShouldEqual, 0)
+		So(InitialSize(10), ShouldEqual, 10)
+		So(InitialSize(1<<30), ShouldEqual, 1024)
+	})
+
+	Convey("KeyOrder should sort NaN floats after other floats by their bits", t, func() {
+		less := KeyOrder(reflect.TypeOf(0.0))
+		n1 := reflect.ValueOf(math.Float64frombits(0x7ff8000000000001))
+		n2 := reflect.ValueOf(math.Float64frombits(0xfff8000000000000))
+		inf := reflect.ValueOf(math.Inf(1))
+		So(less(inf, n1), ShouldBeTrue)
+		So(less(n1, inf), ShouldBeFalse)
+		So(less(n1, n2), ShouldBeTrue)
+		So(less(n2, n1), ShouldBeFalse)
+		So(less(n1, n1), ShouldBeFalse)
+		So(KeyOrder(reflect.TypeOf(struct{}{})), ShouldBeNil)
+	})
+
+	Convey("Entries should return the values of NaN keys", t, func() {
+		k, v := Entries(reflect.ValueOf(map[float64]int{math.NaN(): 1, 0: 2, -1: 3}))
+		So(k, ShouldHaveLength, 3)
+		So(k[0].Float(), ShouldEqual, -1)
+		So(k[1].Float(), ShouldEqual, 0)
+		So(math.IsNaN(k[2].Float()), ShouldBeTrue)
+		So(v[0].Int(), ShouldEqual, 3)
+		So(v[1].Int(), ShouldEqual, 2)
+		So(v[2].Int(), ShouldEqual, 1)
+	})
+
+}
@@ -0,0 +1,429 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/surrealdb/bump"
+)
+
+// hexDigits are used for writing unicode escapes.
+
+const hexDigits = "0123456789abcdef"
+
+// Encoder writes JSON to a bump Writer. Commas and
+// colons are written automatically, and the order of
+// the keys and values is checked as they are written,
+// so that the output is always valid JSON once every
+// object and array has been ended. Any number of values
+// can be written one after the other, and top-level
+// values are separated by newlines.
+type Encoder struct {
+	wtr *bump.Writer
+	stk []byte
+	fst bool
+	key bool
+	top bool
+	htm bool
+	utf bool
+	pre string
+	ind string
+	tmp [64]byte
+}
+
+// NewEncoder creates a new Encoder which
+// writes to the specified Writer.
+func NewEncoder(w *bump.Writer) *Encoder {
+	return &Encoder{wtr: w}
+}
+
+// Reset instructs the Encoder to write to the
+// specified Writer, from the start of a new value,
+// keeping any escaping and indentation settings.
+func (e *Encoder) Reset(w *bump.Writer) {
+	e.wtr = w
+	e.stk = e.stk[:0]
+	e.fst = false
+	e.key = false
+	e.top = false
+}
+
+// EscapeHTML specifies whether the characters <, >
+// and & are escaped within strings, along with U+2028
+// and U+2029, so that the output can be embedded in
+// HTML script tags. By default they are not escaped.
+func (e *Encoder) EscapeHTML(v bool) {
+	e.htm = v
+}
+
+// ReplaceInvalid specifies whether invalid UTF-8
+// within strings is replaced with U+FFFD. By default
+// strings are written as they are, and must be valid
+// UTF-8 for the output to be valid JSON.
+func (e *Encoder) ReplaceInvalid(v bool) {
+	e.utf = v
+}
+
+// Indent specifies that the output is pretty-printed,
+// with each element of an object or array on a new
+// line, which starts with the prefix, followed by one
+// copy of the indent for each level of nesting. Empty
+// strings turn pretty-printing off, which is the default.
+func (e *Encoder) Indent(prefix, indent string) {
+	e.pre = prefix
+	e.ind = indent
+}
+
+// BeginObject begins writing an object, whose
+// keys and values are written with Key and the
+// value methods, until EndObject is called.
+func (e *Encoder) BeginObject() error {
+	return e.begin('{')
+}
+
+// EndObject ends the object which was
+// most recently begun.
+func (e *Encoder) EndObject() error {
+	return e.end('{', '}')
+}
+
+// BeginArray begins writing an array, whose
+// elements are written with the value methods,
+// until EndArray is called.
+func (e *Encoder) BeginArray() error {
+	return e.begin('[')
+}
+
+// EndArray ends the array which was
+// most recently begun.
+func (e *Encoder) EndArray() error {
+	return e.end('[', ']')
+}
+
+// Key writes the key of an object member,
+// which must be followed by its value.
+func (e *Encoder) Key(k string) error {
+
+	if len(e.stk) == 0 || e.stk[len(e.stk)-1] != '{' || e.key {
+		return ErrInvalidWrite
+	}
+
+	if err := e.elem(); err != nil {
+		return err
+	}
+
+	if err := e.quote(k); err != nil {
+		return err
+	}
+
+	e.key = true
+
+	if e.ind != "" || e.pre != "" {
+		return e.wtr.WriteString(": ")
+	}
+
+	return e.wtr.WriteByte(':')
+
+}
+
+// String writes a string value, escaping
+// any characters which need to be escaped.
+func (e *Encoder) String(v string) error {
+	if err := e.value(); err != nil {
+		return err
+	}
+	return e.quote(v)
+}
+
+// Int writes a signed integer value.
+func (e *Encoder) Int(v int64) error {
+	if err := e.value(); err != nil {
+		return err
+	}
+	return e.wtr.WriteBytes(strconv.AppendInt(e.tmp[:0], v, 10))
+}
+
+// Uint writes an unsigned integer value.
+func (e *Encoder) Uint(v uint64) error {
+	if err := e.value(); err != nil {
+		return err
+	}
+	return e.wtr.WriteBytes(strconv.AppendUint(e.tmp[:0], v, 10))
+}
+
+// Float writes a floating point value, in the
+// shortest form which reads back as the same
+// value, using an exponent only for very small
+// and very large numbers. Returns ErrInvalidNumber
+// for NaN and infinite values.
+func (e *Encoder) Float(v float64) error {
+
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return ErrInvalidNumber
+	}
+
+	if err := e.value(); err != nil {
+		return err
+	}
+
+	// Use an exponent in the same cases
+	// as JavaScript, and remove the zero
+	// padding of negative exponents.
+
+	f := byte('f')
+	if a := math.Abs(v); a != 0 && (a < 1e-6 || a >= 1e21) {
+		f = 'e'
+	}
+
+	b := strconv.AppendFloat(e.tmp[:0], v, f, -1, 64)
+
+	if f == 'e' {
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+
+	// Everything went ok.
+
+	return e.wtr.WriteBytes(b)
+
+}
+
+// Bool writes a boolean value.
+func (e *Encoder) Bool(v bool) error {
+	if err := e.value(); err != nil {
+		return err
+	}
+	if v {
+		return e.wtr.WriteString("true")
+	}
+	return e.wtr.WriteString("false")
+}
+
+// Null writes a null value.
+func (e *Encoder) Null() error {
+	if err := e.value(); err != nil {
+		return err
+	}
+	return e.wtr.WriteString("null")
+}
+
+// begin writes the start of an object or array.
+
+func (e *Encoder) begin(c byte) error {
+	if err := e.value(); err != nil {
+		return err
+	}
+	e.stk = append(e.stk, c)
+	e.fst = true
+	return e.wtr.WriteByte(c)
+}
+
+// end writes the end of an object or array,
+// on a new line if it is not empty.
+
+func (e *Encoder) end(o, c byte) error {
+
+	if len(e.stk) == 0 || e.stk[len(e.stk)-1] != o || e.key {
+		return ErrInvalidWrite
+	}
+
+	e.stk = e.stk[:len(e.stk)-1]
+
+	if !e.fst {
+		if err := e.line(); err != nil {
+			return err
+		}
+	}
+
+	e.fst = false
+
+	return e.wtr.WriteByte(c)
+
+}
+
+// value checks that a value can be written,
+// and writes anything which comes before it.
+
+func (e *Encoder) value() error {
+	switch {
+	case len(e.stk) == 0:
+		if e.top {
+			if err := e.wtr.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+		e.top = true
+		return nil
+	case e.stk[len(e.stk)-1] == '{':
+		if !e.key {
+			return ErrInvalidWrite
+		}
+		e.key = false
+		return nil
+	}
+	return e.elem()
+}
+
+// elem writes anything which comes before
+// an element of an object or array.
+
+func (e *Encoder) elem() error {
+	if !e.fst {
+		if err := e.wtr.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	e.fst = false
+	return e.line()
+}
+
+// line starts a new indented line
+// when pretty-printing.
+
+func (e *Encoder) line() error {
+
+	if e.ind == "" && e.pre == "" {
+		return nil
+	}
+
+	if err := e.wtr.WriteByte('\n'); err != nil {
+		return err
+	}
+
+	if err := e.wtr.WriteString(e.pre); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(e.stk); i++ {
+		if err := e.wtr.WriteString(e.ind); err != nil {
+			return err
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// quote writes a string within quotes, writing
+// runs of characters which need no escaping in
+// a single call.
+
+func (e *Encoder) quote(s string) error {
+
+	if err := e.wtr.WriteByte('"'); err != nil {
+		return err
+	}
+
+	p := 0
+
+	for i := 0; i < len(s); {
+
+		c := s[i]
+
+		// Find the next character to escape.
+
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && (!e.htm || c != '<' && c != '>' && c != '&') {
+				i++
+				continue
+			}
+		} else {
+			if !e.utf && !e.htm {
+				i++
+				continue
+			}
+			r, n := utf8.DecodeRuneInString(s[i:])
+			if !(e.utf && r == utf8.RuneError && n == 1) && !(e.htm && (r == '\u2028' || r == '\u2029')) {
+				i += n
+				continue
+			}
+		}
+
+		// Write the characters before it.
+
+		if err := e.wtr.WriteString(s[p:i]); err != nil {
+			return err
+		}
+
+		// Write the escaped character.
+
+		n, err := e.escape(s[i:])
+		if err != nil {
+			return err
+		}
+
+		i += n
+		p = i
+
+	}
+
+	if err := e.wtr.WriteString(s[p:]); err != nil {
+		return err
+	}
+
+	// Everything went ok.
+
+	return e.wtr.WriteByte('"')
+
+}
+
+// escape writes the escape sequence for the first
+// character of the string, and returns its length.
+
+func (e *Encoder) escape(s string) (int, error) {
+
+	switch s[0] {
+	case '"', '\\':
+		e.tmp[0], e.tmp[1] = '\\', s[0]
+		return 1, e.wtr.WriteBytes(e.tmp[:2])
+	case '\b':
+		return 1, e.wtr.WriteString(`\b`)
+	case '\f':
+		return 1, e.wtr.WriteString(`\f`)
+	case '\n':
+		return 1, e.wtr.WriteString(`\n`)
+	case '\r':
+		return 1, e.wtr.WriteString(`\r`)
+	case '\t':
+		return 1, e.wtr.WriteString(`\t`)
+	}
+
+	if s[0] < utf8.RuneSelf {
+		return 1, e.unicode(rune(s[0]))
+	}
+
+	r, n := utf8.DecodeRuneInString(s)
+
+	return n, e.unicode(r)
+
+}
+
+// unicode writes a unicode escape sequence.
+
+func (e *Encoder) unicode(r rune) error {
+	e.tmp[0], e.tmp[1] = '\\', 'u'
+	e.tmp[2] = hexDigits[r>>12&0xf]
+	e.tmp[3] = hexDigits[r>>8&0xf]
+	e.tmp[4] = hexDigits[r>>4&0xf]
+	e.tmp[5] = hexDigits[r&0xf]
+	return e.wtr.WriteBytes(e.tmp[:6])
+}
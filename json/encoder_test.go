@@ -0,0 +1,173 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"bytes"
+	std "encoding/json"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/surrealdb/bump"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// encode writes values using the specified
+// function, and returns the output.
+
+func encode(fn func(e *Encoder)) string {
+	var b []byte
+	fn(NewEncoder(bump.NewWriterBytes(&b)))
+	return string(b)
+}
+
+// document writes an object containing
+// values of every kind.
+
+func document(e *Encoder) {
+	e.BeginObject()
+	e.Key("a")
+	e.BeginArray()
+	e.Int(-1)
+	e.Uint(2)
+	e.Float(2.5)
+	e.Bool(true)
+	e.Bool(false)
+	e.Null()
+	e.EndArray()
+	e.Key("b")
+	e.BeginObject()
+	e.EndObject()
+	e.Key("c")
+	e.BeginArray()
+	e.EndArray()
+	e.Key("d")
+	e.BeginObject()
+	e.Key("e")
+	e.String("f")
+	e.EndObject()
+	e.EndObject()
+}
+
+func TestEncoder(t *testing.T) {
+
+	Convey("Encoder should place commas and colons", t, func() {
+		So(encode(document), ShouldEqual, `{"a":[-1,2,2.5,true,false,null],"b":{},"c":[],"d":{"e":"f"}}`)
+	})
+
+	Convey("Encoder should pretty-print with indentation", t, func() {
+		s := encode(func(e *Encoder) {
+			e.Indent("", "  ")
+			document(e)
+		})
+		var b bytes.Buffer
+		std.Indent(&b, []byte(encode(document)), "", "  ")
+		So(s, ShouldEqual, b.String())
+		s = encode(func(e *Encoder) {
+			e.Indent("> ", "\t")
+			e.BeginArray()
+			e.Int(1)
+			e.EndArray()
+		})
+		So(s, ShouldEqual, "[\n> \t1\n> ]")
+	})
+
+	Convey("Encoder should separate top-level values with newlines", t, func() {
+		s := encode(func(e *Encoder) {
+			e.BeginObject()
+			e.EndObject()
+			e.Int(1)
+			e.String("a")
+		})
+		So(s, ShouldEqual, "{}\n1\n\"a\"")
+	})
+
+	Convey("Encoder should escape strings", t, func() {
+		v := "a\"b\\c/\b\f\n\r\t\x00\x1f\x7f<>&é€😀\u2028\u2029"
+		s := encode(func(e *Encoder) { e.String(v) })
+		So(s, ShouldEqual, `"a\"b\\c/\b\f\n\r\t\u0000\u001f`+"\x7f<>&é€😀\u2028\u2029\"")
+		var o string
+		So(std.Unmarshal([]byte(s), &o), ShouldBeNil)
+		So(o, ShouldEqual, v)
+	})
+
+	Convey("Encoder should escape strings for HTML", t, func() {
+		s := encode(func(e *Encoder) {
+			e.EscapeHTML(true)
+			e.String("<a href=\"x\">&é\u2028\u2029</a>")
+		})
+		So(s, ShouldEqual, `"\u003ca href=\"x\"\u003e\u0026é\u2028\u2029\u003c/a\u003e"`)
+	})
+
+	Convey("Encoder should replace invalid UTF-8", t, func() {
+		v := "a\xffb\xe2\x82c\xed\xa0\x80é"
+		s := encode(func(e *Encoder) { e.String(v) })
+		So(s, ShouldEqual, `"`+v+`"`)
+		s = encode(func(e *Encoder) {
+			e.ReplaceInvalid(true)
+			e.String(v)
+		})
+		So(s, ShouldEqual, `"a\ufffdb\ufffd\ufffdc\ufffd\ufffd\ufffdé"`)
+		So(std.Valid([]byte(s)), ShouldBeTrue)
+	})
+
+	Convey("Encoder should write numbers like JavaScript", t, func() {
+		for _, v := range []float64{0, math.Copysign(0, -1), 1, -1.5, 0.1, 1e-6, 1e-7, 123456789, 1e20, 1e21, 1.5e300, -2.5e-300, math.MaxFloat64, math.SmallestNonzeroFloat64} {
+			s := encode(func(e *Encoder) { e.Float(v) })
+			b, _ := std.Marshal(v)
+			So(s, ShouldEqual, string(b))
+		}
+		s := encode(func(e *Encoder) {
+			e.Int(math.MinInt64)
+			e.Uint(math.MaxUint64)
+		})
+		So(s, ShouldEqual, "-9223372036854775808\n18446744073709551615")
+		e := NewEncoder(bump.NewWriterBytes(new([]byte)))
+		So(e.Float(math.NaN()), ShouldEqual, ErrInvalidNumber)
+		So(e.Float(math.Inf(-1)), ShouldEqual, ErrInvalidNumber)
+	})
+
+	Convey("Encoder should reject writes which are not valid JSON", t, func() {
+		e := NewEncoder(bump.NewWriterBytes(new([]byte)))
+		So(e.Key("a"), ShouldEqual, ErrInvalidWrite)
+		So(e.EndObject(), ShouldEqual, ErrInvalidWrite)
+		So(e.BeginObject(), ShouldBeNil)
+		So(e.Int(1), ShouldEqual, ErrInvalidWrite)
+		So(e.EndArray(), ShouldEqual, ErrInvalidWrite)
+		So(e.Key("a"), ShouldBeNil)
+		So(e.Key("b"), ShouldEqual, ErrInvalidWrite)
+		So(e.EndObject(), ShouldEqual, ErrInvalidWrite)
+		So(e.BeginArray(), ShouldBeNil)
+		So(e.Key("c"), ShouldEqual, ErrInvalidWrite)
+		So(e.EndObject(), ShouldEqual, ErrInvalidWrite)
+		So(e.EndArray(), ShouldBeNil)
+		So(e.EndObject(), ShouldBeNil)
+	})
+
+	Convey("Encoder output should be read back by the Tokenizer", t, func() {
+		var b []byte
+		e := NewEncoder(bump.NewWriterBytes(&b))
+		e.Indent("", "\t")
+		document(e)
+		e.String("xé\n")
+		o, err := tokens(NewTokenizer(bump.NewReaderBytes(b)))
+		So(err, ShouldEqual, io.EOF)
+		So(len(o), ShouldEqual, 23)
+		So(string(o[22].Value), ShouldEqual, "xé\n")
+	})
+
+}
@@ -0,0 +1,148 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package json implements a streaming JSON tokenizer
+// on top of bump Readers, and a streaming JSON encoder
+// on top of bump Writers, without any buffering of their
+// own. A Tokenizer reads one token at a time, checking
+// the syntax of the input as it goes, and can read a
+// stream of whitespace-separated values, such as an
+// NDJSON file. Strings without escapes are returned
+// without being copied. An Encoder writes one key or
+// value at a time, placing commas and escaping strings
+// automatically.
+package json
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// defaultMaxDepth limits how deeply objects and
+// arrays can be nested, unless the Tokenizer is
+// configured otherwise.
+const defaultMaxDepth = 10000
+
+// Kind represents the kind of a JSON token.
+type Kind int
+
+const (
+	InvalidKind Kind = iota
+	ObjectStart
+	ObjectEnd
+	ArrayStart
+	ArrayEnd
+	Key
+	String
+	Number
+	True
+	False
+	Null
+)
+
+var kindNames = [...]string{
+	InvalidKind: "invalid",
+	ObjectStart: "object start",
+	ObjectEnd:   "object end",
+	ArrayStart:  "array start",
+	ArrayEnd:    "array end",
+	Key:         "key",
+	String:      "string",
+	Number:      "number",
+	True:        "true",
+	False:       "false",
+	Null:        "null",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return kindNames[InvalidKind]
+}
+
+// Token represents a single JSON token. The Value
+// holds the unescaped contents of keys and strings,
+// without quotes, and the text of numbers, and is
+// only valid until the next token is read.
+type Token struct {
+	Kind   Kind
+	Value  []byte
+	Offset int64
+}
+
+// Int parses the value of a number token
+// as a signed integer.
+func (t Token) Int() (int64, error) {
+	if t.Kind != Number {
+		return 0, &TypeError{Want: Number, Got: t.Kind}
+	}
+	return strconv.ParseInt(string(t.Value), 10, 64)
+}
+
+// Uint parses the value of a number token
+// as an unsigned integer.
+func (t Token) Uint() (uint64, error) {
+	if t.Kind != Number {
+		return 0, &TypeError{Want: Number, Got: t.Kind}
+	}
+	return strconv.ParseUint(string(t.Value), 10, 64)
+}
+
+// Float parses the value of a number token
+// as a floating point number.
+func (t Token) Float() (float64, error) {
+	if t.Kind != Number {
+		return 0, &TypeError{Want: Number, Got: t.Kind}
+	}
+	return strconv.ParseFloat(string(t.Value), 64)
+}
+
+var (
+	// ErrMaxDepth is returned when objects and
+	// arrays are nested too deeply.
+	ErrMaxDepth = errors.New("json: maximum nesting depth exceeded")
+	// ErrInvalidWrite is returned when a key or value
+	// is written where it would not be valid JSON, or
+	// an object or array is ended before it is open.
+	ErrInvalidWrite = errors.New("json: invalid write at this position")
+	// ErrInvalidNumber is returned when writing a
+	// NaN or infinite number, which JSON can not
+	// represent.
+	ErrInvalidNumber = errors.New("json: cannot write NaN or infinity")
+)
+
+// SyntaxError is returned when the input is not
+// valid JSON. The Offset is the position of the
+// byte at which the error was found.
+type SyntaxError struct {
+	Msg    string
+	Offset int64
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("json: %s at offset %d", e.Msg, e.Offset)
+}
+
+// TypeError is returned when a token of one
+// kind is used as another kind.
+type TypeError struct {
+	Want Kind
+	Got  Kind
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("json: cannot use %s as %s", e.Got, e.Want)
+}
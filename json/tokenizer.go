@@ -0,0 +1,617 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/surrealdb/bump"
+)
+
+// What the Tokenizer expects to read next.
+
+const (
+	expValue = iota
+	expKey
+	expColon
+	expComma
+)
+
+// Tokenizer reads JSON tokens from a bump Reader.
+// Each call to Next reads a single token, and the
+// input is checked to be valid JSON as it is read.
+// Any number of values can be read one after the
+// other, separated by optional whitespace.
+type Tokenizer struct {
+	rdr *bump.Reader
+	off int64
+	stk []byte
+	exp int
+	opn bool
+	buf []byte
+	max int
+	tmp [utf8.UTFMax]byte
+}
+
+// NewTokenizer creates a new Tokenizer
+// which reads from the specified Reader.
+func NewTokenizer(r *bump.Reader) *Tokenizer {
+	return &Tokenizer{rdr: r, max: defaultMaxDepth}
+}
+
+// Reset instructs the Tokenizer to read from
+// the specified Reader, from the start of a
+// new value, at an offset of zero.
+func (t *Tokenizer) Reset(r *bump.Reader) {
+	t.rdr = r
+	t.off = 0
+	t.stk = t.stk[:0]
+	t.exp = expValue
+	t.opn = false
+}
+
+// MaxDepth specifies how deeply objects and arrays
+// can be nested, after which ErrMaxDepth is returned.
+// By default they can be nested 10000 levels deep.
+func (t *Tokenizer) MaxDepth(n int) {
+	t.max = n
+}
+
+// Offset returns the number of bytes which
+// have been read by the Tokenizer.
+func (t *Tokenizer) Offset() int64 {
+	return t.off
+}
+
+// Depth returns the number of objects and
+// arrays which are currently open.
+func (t *Tokenizer) Depth() int {
+	return len(t.stk)
+}
+
+// Next reads the next token. Object keys are read
+// as Key tokens, and the colon and commas between
+// values are checked but not returned. Returns io.EOF
+// when the input ends between top-level values, and
+// a SyntaxError if the input is not valid JSON.
+func (t *Tokenizer) Next() (Token, error) {
+
+	for {
+
+		c, err := t.space()
+		if err != nil {
+			if err == io.EOF && len(t.stk) == 0 && t.exp == expValue {
+				return Token{}, io.EOF
+			}
+			return Token{}, t.eof(err)
+		}
+
+		off := t.off
+
+		switch t.exp {
+		case expComma:
+			switch {
+			case c == ',':
+				t.skip()
+				t.opn = false
+				t.exp = expValue
+				if t.stk[len(t.stk)-1] == '{' {
+					t.exp = expKey
+				}
+				continue
+			// Closing brackets are two after opening ones.
+			case c == t.stk[len(t.stk)-1]+2:
+				return t.end(off)
+			}
+			if t.stk[len(t.stk)-1] == '{' {
+				return Token{}, t.fail(fmt.Sprintf("invalid character %q after object value", c))
+			}
+			return Token{}, t.fail(fmt.Sprintf("invalid character %q after array value", c))
+		case expColon:
+			if c != ':' {
+				return Token{}, t.fail(fmt.Sprintf("invalid character %q after object key", c))
+			}
+			t.skip()
+			t.exp = expValue
+			continue
+		case expKey:
+			if c == '}' && t.opn {
+				return t.end(off)
+			}
+			if c != '"' {
+				return Token{}, t.fail(fmt.Sprintf("invalid character %q looking for object key", c))
+			}
+			t.skip()
+			v, err := t.string()
+			if err != nil {
+				return Token{}, err
+			}
+			t.exp = expColon
+			return Token{Kind: Key, Value: v, Offset: off}, nil
+		}
+
+		if c == ']' && t.opn {
+			return t.end(off)
+		}
+
+		return t.value(c, off)
+
+	}
+
+}
+
+// Skip skips the next value, including all of the
+// tokens within it if it is an object or array. If
+// the next token is an object key, then the key and
+// its value are skipped, and if it ends an object or
+// array, then only that token is read.
+func (t *Tokenizer) Skip() error {
+	d := len(t.stk)
+	for {
+		k, err := t.Next()
+		if err != nil {
+			return err
+		}
+		if k.Kind != Key && len(t.stk) <= d {
+			return nil
+		}
+	}
+}
+
+// fail returns a SyntaxError at the current offset.
+
+func (t *Tokenizer) fail(msg string) error {
+	return &SyntaxError{Msg: msg, Offset: t.off}
+}
+
+// eof converts the end of the input
+// within a value into a SyntaxError.
+
+func (t *Tokenizer) eof(err error) error {
+	if err == io.EOF {
+		return t.fail("unexpected end of input")
+	}
+	return err
+}
+
+// skip advances past a byte which has been peeked.
+
+func (t *Tokenizer) skip() {
+	t.rdr.ReadByte()
+	t.off++
+}
+
+// read reads a byte within a value.
+
+func (t *Tokenizer) read() (byte, error) {
+	c, err := t.rdr.ReadByte()
+	if err != nil {
+		return 0, t.eof(err)
+	}
+	t.off++
+	return c, nil
+}
+
+// peek returns the next byte without advancing,
+// or zero at the end of the input.
+
+func (t *Tokenizer) peek() (byte, error) {
+	c, err := t.rdr.PeekByte()
+	if err == io.EOF {
+		return 0, nil
+	}
+	return c, err
+}
+
+// space skips any whitespace, and returns
+// the next byte without advancing past it.
+
+func (t *Tokenizer) space() (byte, error) {
+	for {
+		c, err := t.rdr.PeekByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			t.skip()
+		default:
+			return c, nil
+		}
+	}
+}
+
+// done updates the state after a value.
+
+func (t *Tokenizer) done() {
+	t.opn = false
+	t.exp = expValue
+	if len(t.stk) > 0 {
+		t.exp = expComma
+	}
+}
+
+// end reads the end of an object or array.
+
+func (t *Tokenizer) end(off int64) (Token, error) {
+	t.skip()
+	k := ObjectEnd
+	if t.stk[len(t.stk)-1] == '[' {
+		k = ArrayEnd
+	}
+	t.stk = t.stk[:len(t.stk)-1]
+	t.done()
+	return Token{Kind: k, Offset: off}, nil
+}
+
+// value reads a value which starts
+// with the specified byte.
+
+func (t *Tokenizer) value(c byte, off int64) (Token, error) {
+
+	switch c {
+	case '{', '[':
+		if len(t.stk) >= t.max {
+			return Token{}, ErrMaxDepth
+		}
+		t.skip()
+		t.stk = append(t.stk, c)
+		t.opn = true
+		if c == '{' {
+			t.exp = expKey
+			return Token{Kind: ObjectStart, Offset: off}, nil
+		}
+		t.exp = expValue
+		return Token{Kind: ArrayStart, Offset: off}, nil
+	case '"':
+		t.skip()
+		v, err := t.string()
+		if err != nil {
+			return Token{}, err
+		}
+		t.done()
+		return Token{Kind: String, Value: v, Offset: off}, nil
+	case 't':
+		return t.literal("true", True, off)
+	case 'f':
+		return t.literal("false", False, off)
+	case 'n':
+		return t.literal("null", Null, off)
+	}
+
+	if c == '-' || c >= '0' && c <= '9' {
+		v, err := t.number()
+		if err != nil {
+			return Token{}, err
+		}
+		t.done()
+		return Token{Kind: Number, Value: v, Offset: off}, nil
+	}
+
+	return Token{}, t.fail(fmt.Sprintf("invalid character %q looking for value", c))
+
+}
+
+// delimited checks that a number or literal
+// is followed by whitespace, a delimiter, or
+// the end of the input.
+
+func (t *Tokenizer) delimited(what string) error {
+	c, err := t.peek()
+	if err != nil {
+		return err
+	}
+	switch c {
+	case 0, ' ', '\t', '\n', '\r', ',', ']', '}':
+		return nil
+	}
+	return t.fail(fmt.Sprintf("invalid character %q after %s", c, what))
+}
+
+// literal reads the specified literal.
+
+func (t *Tokenizer) literal(w string, k Kind, off int64) (Token, error) {
+
+	for i := 0; i < len(w); i++ {
+		c, err := t.rdr.PeekByte()
+		if err != nil {
+			return Token{}, t.eof(err)
+		}
+		if c != w[i] {
+			return Token{}, t.fail(fmt.Sprintf("invalid character %q in literal %s", c, w))
+		}
+		t.skip()
+	}
+
+	if err := t.delimited(w); err != nil {
+		return Token{}, err
+	}
+
+	t.done()
+
+	return Token{Kind: k, Offset: off}, nil
+
+}
+
+// number reads the text of a number into
+// the scratch space, checking its syntax.
+
+func (t *Tokenizer) number() ([]byte, error) {
+
+	t.buf = t.buf[:0]
+
+	c, err := t.peek()
+	if err != nil {
+		return nil, err
+	}
+
+	if c == '-' {
+		if c, err = t.take(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the integer part, where only a
+	// single zero can start with a zero.
+
+	if c == '0' {
+		if c, err = t.take(); err != nil {
+			return nil, err
+		}
+	} else if c, err = t.digits(c); err != nil {
+		return nil, err
+	}
+
+	// Read the fraction and exponent.
+
+	if c == '.' {
+		if c, err = t.take(); err != nil {
+			return nil, err
+		}
+		if c, err = t.digits(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if c == 'e' || c == 'E' {
+		if c, err = t.take(); err != nil {
+			return nil, err
+		}
+		if c == '+' || c == '-' {
+			if c, err = t.take(); err != nil {
+				return nil, err
+			}
+		}
+		if _, err = t.digits(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := t.delimited("number"); err != nil {
+		return nil, err
+	}
+
+	// Everything went ok.
+
+	return t.buf, nil
+
+}
+
+// take moves a byte of a number into the scratch
+// space, and returns the byte which follows it.
+
+func (t *Tokenizer) take() (byte, error) {
+	c, _ := t.read()
+	t.buf = append(t.buf, c)
+	return t.peek()
+}
+
+// digits reads one or more digits of a number,
+// starting with the specified byte.
+
+func (t *Tokenizer) digits(c byte) (byte, error) {
+	if c < '0' || c > '9' {
+		if c == 0 {
+			return 0, t.eof(io.EOF)
+		}
+		return 0, t.fail(fmt.Sprintf("invalid character %q in number", c))
+	}
+	var err error
+	for c >= '0' && c <= '9' {
+		if c, err = t.take(); err != nil {
+			return 0, err
+		}
+	}
+	return c, nil
+}
+
+// string reads the rest of a string, after the
+// opening quote. Strings without escapes which
+// are buffered in full are returned without being
+// copied, and otherwise they are unescaped into
+// the scratch space.
+
+func (t *Tokenizer) string() ([]byte, error) {
+
+	t.buf = t.buf[:0]
+
+	cpy := false
+
+	for {
+
+		p, err := t.rdr.PeekBytes()
+		if err != nil {
+			return nil, t.eof(err)
+		}
+
+		// Find the end of the plain characters.
+
+		i := 0
+		for i < len(p) && p[i] != '"' && p[i] != '\\' && p[i] >= 0x20 {
+			i++
+		}
+
+		// Return the buffered data if it is complete.
+
+		if i < len(p) && p[i] == '"' && !cpy {
+			t.rdr.Discard(i + 1)
+			t.off += int64(i + 1)
+			return p[:i], nil
+		}
+
+		// Otherwise copy it into the scratch space.
+
+		t.buf = append(t.buf, p[:i]...)
+		t.rdr.Discard(i)
+		t.off += int64(i)
+		cpy = true
+
+		if i == len(p) {
+			continue
+		}
+
+		switch p[i] {
+		case '"':
+			t.skip()
+			return t.buf, nil
+		case '\\':
+			t.skip()
+			if err := t.escape(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, t.fail("invalid control character in string")
+		}
+
+	}
+
+}
+
+// escape reads an escape sequence, after
+// the backslash, into the scratch space.
+
+func (t *Tokenizer) escape() error {
+	c, err := t.read()
+	if err != nil {
+		return err
+	}
+	return t.unescape(c)
+}
+
+// unescape reads the rest of an escape sequence
+// which starts with the specified character.
+
+func (t *Tokenizer) unescape(c byte) error {
+
+	switch c {
+	case '"', '\\', '/':
+		t.buf = append(t.buf, c)
+		return nil
+	case 'b':
+		t.buf = append(t.buf, '\b')
+		return nil
+	case 'f':
+		t.buf = append(t.buf, '\f')
+		return nil
+	case 'n':
+		t.buf = append(t.buf, '\n')
+		return nil
+	case 'r':
+		t.buf = append(t.buf, '\r')
+		return nil
+	case 't':
+		t.buf = append(t.buf, '\t')
+		return nil
+	case 'u':
+	default:
+		t.off--
+		err := t.fail(fmt.Sprintf("invalid escape character %q", c))
+		t.off++
+		return err
+	}
+
+	r, err := t.hex()
+	if err != nil {
+		return err
+	}
+
+	// Combine a surrogate pair if one follows,
+	// otherwise write a replacement character.
+
+	if utf16.IsSurrogate(r) && r < 0xdc00 {
+		if c, _ := t.rdr.PeekByte(); c == '\\' {
+			t.skip()
+			c, err := t.read()
+			if err != nil {
+				return err
+			}
+			if c != 'u' {
+				t.rune(utf8.RuneError)
+				return t.unescape(c)
+			}
+			s, err := t.hex()
+			if err != nil {
+				return err
+			}
+			if d := utf16.DecodeRune(r, s); d != utf8.RuneError {
+				t.rune(d)
+				return nil
+			}
+			t.rune(utf8.RuneError)
+			r = s
+		}
+	}
+
+	t.rune(r)
+
+	return nil
+
+}
+
+// hex reads the four hexadecimal
+// digits of a unicode escape.
+
+func (t *Tokenizer) hex() (rune, error) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		c, err := t.rdr.PeekByte()
+		if err != nil {
+			return 0, t.eof(err)
+		}
+		switch {
+		case c >= '0' && c <= '9':
+			r = r<<4 | rune(c-'0')
+		case c >= 'a' && c <= 'f':
+			r = r<<4 | rune(c-'a'+10)
+		case c >= 'A' && c <= 'F':
+			r = r<<4 | rune(c-'A'+10)
+		default:
+			return 0, t.fail(fmt.Sprintf("invalid character %q in unicode escape", c))
+		}
+		t.skip()
+	}
+	return r, nil
+}
+
+// rune writes a character to the scratch space,
+// replacing any unpaired surrogate.
+
+func (t *Tokenizer) rune(r rune) {
+	n := utf8.EncodeRune(t.tmp[:], r)
+	t.buf = append(t.buf, t.tmp[:n]...)
+}
@@ -0,0 +1,227 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/surrealdb/bump"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// readers returns Tokenizers which read the specified
+// input from a byte slice and from an io.Reader.
+
+func readers(s string) []*Tokenizer {
+	return []*Tokenizer{
+		NewTokenizer(bump.NewReaderBytes([]byte(s))),
+		NewTokenizer(bump.NewReader(strings.NewReader(s))),
+		NewTokenizer(bump.NewReader(iotest.OneByteReader(strings.NewReader(s)))),
+	}
+}
+
+// tokens reads all of the tokens from a Tokenizer,
+// copying their values, until there is an error.
+
+func tokens(t *Tokenizer) ([]Token, error) {
+	var o []Token
+	for {
+		k, err := t.Next()
+		if err != nil {
+			return o, err
+		}
+		if k.Value != nil {
+			k.Value = append([]byte(nil), k.Value...)
+		}
+		o = append(o, k)
+	}
+}
+
+// syntax returns the offset of the
+// syntax error when reading the input.
+
+func syntax(s string) int64 {
+	_, err := tokens(NewTokenizer(bump.NewReaderBytes([]byte(s))))
+	if e, ok := err.(*SyntaxError); ok {
+		return e.Offset
+	}
+	return -1
+}
+
+func TestTokenizer(t *testing.T) {
+
+	Convey("Tokenizer should read all kinds of tokens", t, func() {
+		s := ` {"a": [1, -2.5e+3, true, false, null], "b": {}, "c": [], "d": "x"} `
+		for _, r := range readers(s) {
+			o, err := tokens(r)
+			So(err, ShouldEqual, io.EOF)
+			So(o, ShouldResemble, []Token{
+				{Kind: ObjectStart, Offset: 1},
+				{Kind: Key, Value: []byte("a"), Offset: 2},
+				{Kind: ArrayStart, Offset: 7},
+				{Kind: Number, Value: []byte("1"), Offset: 8},
+				{Kind: Number, Value: []byte("-2.5e+3"), Offset: 11},
+				{Kind: True, Offset: 20},
+				{Kind: False, Offset: 26},
+				{Kind: Null, Offset: 33},
+				{Kind: ArrayEnd, Offset: 37},
+				{Kind: Key, Value: []byte("b"), Offset: 40},
+				{Kind: ObjectStart, Offset: 45},
+				{Kind: ObjectEnd, Offset: 46},
+				{Kind: Key, Value: []byte("c"), Offset: 49},
+				{Kind: ArrayStart, Offset: 54},
+				{Kind: ArrayEnd, Offset: 55},
+				{Kind: Key, Value: []byte("d"), Offset: 58},
+				{Kind: String, Value: []byte("x"), Offset: 63},
+				{Kind: ObjectEnd, Offset: 66},
+			})
+			So(r.Offset(), ShouldEqual, len(s))
+		}
+	})
+
+	Convey("Tokenizer should parse the values of numbers", t, func() {
+		r := NewTokenizer(bump.NewReaderBytes([]byte(`[-12, 18446744073709551615, 0.25]`)))
+		r.Next()
+		k, _ := r.Next()
+		i, err := k.Int()
+		So(err, ShouldBeNil)
+		So(i, ShouldEqual, -12)
+		k, _ = r.Next()
+		u, err := k.Uint()
+		So(err, ShouldBeNil)
+		So(u, ShouldEqual, uint64(18446744073709551615))
+		k, _ = r.Next()
+		f, err := k.Float()
+		So(err, ShouldBeNil)
+		So(f, ShouldEqual, 0.25)
+		k, _ = r.Next()
+		_, err = k.Int()
+		So(err, ShouldResemble, &TypeError{Want: Number, Got: ArrayEnd})
+	})
+
+	Convey("Tokenizer should not copy strings without escapes", t, func() {
+		b := []byte(`["abc", "d\"e"]`)
+		r := NewTokenizer(bump.NewReaderBytes(b))
+		r.Next()
+		k, _ := r.Next()
+		So(string(k.Value), ShouldEqual, "abc")
+		So(&k.Value[0], ShouldEqual, &b[2])
+		k, _ = r.Next()
+		So(string(k.Value), ShouldEqual, `d"e`)
+		So(&k.Value[0], ShouldNotEqual, &b[9])
+	})
+
+	Convey("Tokenizer should unescape strings", t, func() {
+		s := `"\"\\\/\b\f\n\r\tAé€😀\ud800x\udc00\ud800\n"`
+		for _, r := range readers(s) {
+			o, err := tokens(r)
+			So(err, ShouldEqual, io.EOF)
+			So(string(o[0].Value), ShouldEqual, "\"\\/\b\f\n\r\tAé€😀�x��\n")
+		}
+	})
+
+	Convey("Tokenizer should read strings longer than the buffer", t, func() {
+		l := strings.Repeat("abcdefgh", 1000)
+		for _, s := range []string{`"` + l + `"`, `"` + l + `\n` + l + `"`} {
+			for _, r := range readers(s) {
+				o, err := tokens(r)
+				So(err, ShouldEqual, io.EOF)
+				So(string(o[0].Value), ShouldEqual, strings.Replace(s[1:len(s)-1], `\n`, "\n", 1))
+			}
+		}
+	})
+
+	Convey("Tokenizer should read a stream of values", t, func() {
+		for _, r := range readers("{\"a\":1}\n[2]\n\"b\"\n3\ntrue\n") {
+			o, err := tokens(r)
+			So(err, ShouldEqual, io.EOF)
+			So(len(o), ShouldEqual, 10)
+			So(o[9].Kind, ShouldEqual, True)
+			So(o[9].Offset, ShouldEqual, 18)
+		}
+	})
+
+	Convey("Tokenizer should report the offset of syntax errors", t, func() {
+		So(syntax(`[1,]`), ShouldEqual, 3)
+		So(syntax(`[1 2]`), ShouldEqual, 3)
+		So(syntax(`{"a" 1}`), ShouldEqual, 5)
+		So(syntax(`{"a":1,}`), ShouldEqual, 7)
+		So(syntax(`{1:2}`), ShouldEqual, 1)
+		So(syntax(`[1}`), ShouldEqual, 2)
+		So(syntax(`{"a":1]`), ShouldEqual, 6)
+		So(syntax(`]`), ShouldEqual, 0)
+		So(syntax(`[01]`), ShouldEqual, 2)
+		So(syntax(`[-]`), ShouldEqual, 2)
+		So(syntax(`[1.]`), ShouldEqual, 3)
+		So(syntax(`[1e]`), ShouldEqual, 3)
+		So(syntax(`[1x]`), ShouldEqual, 2)
+		So(syntax(`[tru]`), ShouldEqual, 4)
+		So(syntax(`[nullx]`), ShouldEqual, 5)
+		So(syntax(`["a\x"]`), ShouldEqual, 4)
+		So(syntax(`["\u12g4"]`), ShouldEqual, 6)
+		So(syntax("[\"a\tb\"]"), ShouldEqual, 3)
+		So(syntax(`["abc`), ShouldEqual, 5)
+		So(syntax(`[1, 2`), ShouldEqual, 5)
+		So(syntax(`{"a"`), ShouldEqual, 4)
+		So(syntax(`-`), ShouldEqual, 1)
+		_, err := tokens(NewTokenizer(bump.NewReaderBytes([]byte(`[1,]`))))
+		So(err.Error(), ShouldEqual, `json: invalid character ']' looking for value at offset 3`)
+	})
+
+	Convey("Tokenizer should enforce the maximum depth", t, func() {
+		r := NewTokenizer(bump.NewReaderBytes([]byte(`[[{"a":[1]}]]`)))
+		r.MaxDepth(3)
+		_, err := tokens(r)
+		So(err, ShouldEqual, ErrMaxDepth)
+		So(r.Depth(), ShouldEqual, 3)
+		r.Reset(bump.NewReaderBytes([]byte(`[[{"a":[1]}]]`)))
+		r.MaxDepth(4)
+		o, err := tokens(r)
+		So(err, ShouldEqual, io.EOF)
+		So(len(o), ShouldEqual, 10)
+		r.Reset(bump.NewReaderBytes(bytes.Repeat([]byte("["), 20000)))
+		r.MaxDepth(10000)
+		_, err = tokens(r)
+		So(err, ShouldEqual, ErrMaxDepth)
+	})
+
+	Convey("Tokenizer should skip values", t, func() {
+		for _, r := range readers(`{"a": {"b": [1, {"c": 2}]}, "d": 3, "e": [4, 5]}`) {
+			r.Next()
+			So(r.Skip(), ShouldBeNil)
+			k, _ := r.Next()
+			So(string(k.Value), ShouldEqual, "d")
+			So(r.Skip(), ShouldBeNil)
+			k, _ = r.Next()
+			So(string(k.Value), ShouldEqual, "e")
+			r.Next()
+			So(r.Skip(), ShouldBeNil)
+			k, _ = r.Next()
+			So(string(k.Value), ShouldEqual, "5")
+			k, _ = r.Next()
+			So(k.Kind, ShouldEqual, ArrayEnd)
+			So(r.Skip(), ShouldBeNil)
+			So(r.Depth(), ShouldEqual, 0)
+			_, err := r.Next()
+			So(err, ShouldEqual, io.EOF)
+		}
+	})
+
+}
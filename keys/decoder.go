@@ -0,0 +1,190 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/surrealdb/bump"
+)
+
+// Decoder reads the components of keys from
+// a bump Reader, in ascending order unless
+// descending order is specified.
+type Decoder struct {
+	rdr *bump.Reader
+	dsc bool
+	buf []byte
+}
+
+// NewDecoder creates a new Decoder which
+// reads from the specified Reader.
+func NewDecoder(r *bump.Reader) *Decoder {
+	return &Decoder{rdr: r}
+}
+
+// Reset instructs the Decoder to read from
+// the specified Reader, in ascending order.
+func (d *Decoder) Reset(r *bump.Reader) {
+	d.rdr = r
+	d.dsc = false
+}
+
+// Descending specifies whether the components
+// which are read next were written in descending
+// order.
+func (d *Decoder) Descending(v bool) {
+	d.dsc = v
+}
+
+// ReadUint reads an unsigned integer.
+func (d *Decoder) ReadUint() (uint64, error) {
+	v, err := d.rdr.ReadUint64()
+	if err != nil {
+		return 0, err
+	}
+	if d.dsc {
+		v = ^v
+	}
+	return v, nil
+}
+
+// ReadInt reads a signed integer.
+func (d *Decoder) ReadInt() (int64, error) {
+	v, err := d.ReadUint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(v ^ signBit), nil
+}
+
+// ReadFloat reads a floating point number.
+func (d *Decoder) ReadFloat() (float64, error) {
+	v, err := d.ReadUint()
+	if err != nil {
+		return 0, err
+	}
+	if v&signBit != 0 {
+		v &^= signBit
+	} else {
+		v = ^v
+	}
+	return math.Float64frombits(v), nil
+}
+
+// ReadBool reads a boolean. Returns ErrInvalidKey
+// if the byte is neither true nor false.
+func (d *Decoder) ReadBool() (bool, error) {
+	b, err := d.rdr.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	if d.dsc {
+		b = ^b
+	}
+	if b > 1 {
+		return false, ErrInvalidKey
+	}
+	return b == 1, nil
+}
+
+// ReadString reads a string. Returns ErrInvalidKey
+// if the string contains an invalid escape sequence.
+func (d *Decoder) ReadString() (string, error) {
+	if err := d.unescape(); err != nil {
+		return "", err
+	}
+	return string(d.buf), nil
+}
+
+// ReadBytes reads a byte slice into newly allocated
+// memory. Returns ErrInvalidKey if the byte slice
+// contains an invalid escape sequence.
+func (d *Decoder) ReadBytes() ([]byte, error) {
+	if err := d.unescape(); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, d.buf...), nil
+}
+
+// unescape reads the contents of a string up to
+// its terminator into the scratch space, searching
+// the buffered data for each escape byte.
+
+func (d *Decoder) unescape() error {
+
+	d.buf = d.buf[:0]
+
+	// The encoded bytes are inverted when
+	// in descending order, and are copied
+	// as they are before being restored.
+
+	var x byte
+	if d.dsc {
+		x = 0xff
+	}
+
+	for {
+
+		p, err := d.rdr.PeekBytes()
+		if err != nil {
+			return err
+		}
+
+		i := bytes.IndexByte(p, escape^x)
+		if i < 0 {
+			d.buf = append(d.buf, p...)
+			if _, err := d.rdr.Discard(len(p)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		d.buf = append(d.buf, p[:i]...)
+		if _, err := d.rdr.Discard(i + 1); err != nil {
+			return err
+		}
+
+		// Check the byte after the escape.
+
+		c, err := d.rdr.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		if c^x == terminator {
+			break
+		}
+
+		if c^x != escaped {
+			return ErrInvalidKey
+		}
+
+		d.buf = append(d.buf, escape^x)
+
+	}
+
+	if d.dsc {
+		for i := range d.buf {
+			d.buf[i] = ^d.buf[i]
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
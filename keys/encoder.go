@@ -0,0 +1,140 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"math"
+
+	"github.com/surrealdb/bump"
+)
+
+// Encoder writes the components of keys to
+// a bump Writer, in ascending order unless
+// descending order is specified.
+type Encoder struct {
+	wtr *bump.Writer
+	dsc bool
+	buf []byte
+}
+
+// NewEncoder creates a new Encoder which
+// writes to the specified Writer.
+func NewEncoder(w *bump.Writer) *Encoder {
+	return &Encoder{wtr: w}
+}
+
+// Reset instructs the Encoder to write to the
+// specified Writer, in ascending order.
+func (e *Encoder) Reset(w *bump.Writer) {
+	e.wtr = w
+	e.dsc = false
+}
+
+// Descending specifies whether the components
+// which are written next sort in descending
+// order. Each component must be read in the
+// same order as it was written.
+func (e *Encoder) Descending(v bool) {
+	e.dsc = v
+}
+
+// WriteUint writes an unsigned integer as
+// eight bytes in big-endian byte order.
+func (e *Encoder) WriteUint(v uint64) error {
+	if e.dsc {
+		v = ^v
+	}
+	return e.wtr.WriteUint64(v)
+}
+
+// WriteInt writes a signed integer as eight
+// bytes in big-endian byte order, with the sign
+// bit flipped so that negative numbers sort
+// before positive numbers.
+func (e *Encoder) WriteInt(v int64) error {
+	return e.WriteUint(uint64(v) ^ signBit)
+}
+
+// WriteFloat writes a floating point number as
+// eight bytes, which sort in numeric order. The
+// sign bit of positive numbers is flipped, and all
+// of the bits of negative numbers are inverted.
+// Negative zero sorts before positive zero, and
+// NaN values sort after infinity, or before
+// negative infinity if their sign bit is set.
+func (e *Encoder) WriteFloat(v float64) error {
+	b := math.Float64bits(v)
+	if b&signBit != 0 {
+		b = ^b
+	} else {
+		b |= signBit
+	}
+	return e.WriteUint(b)
+}
+
+// WriteBool writes a boolean as a single
+// byte, where false sorts before true.
+func (e *Encoder) WriteBool(v bool) error {
+	var b byte
+	if v {
+		b = 1
+	}
+	if e.dsc {
+		b = ^b
+	}
+	return e.wtr.WriteByte(b)
+}
+
+// WriteString writes a string, escaping any
+// zero bytes, followed by a terminator, so that
+// a string sorts before any longer string which
+// starts with it.
+func (e *Encoder) WriteString(v string) error {
+	e.buf = e.buf[:0]
+	for i := 0; i < len(v); i++ {
+		e.buf = append(e.buf, v[i])
+		if v[i] == escape {
+			e.buf = append(e.buf, escaped)
+		}
+	}
+	return e.terminate()
+}
+
+// WriteBytes writes a byte slice in the
+// same way as a string.
+func (e *Encoder) WriteBytes(v []byte) error {
+	e.buf = e.buf[:0]
+	for i := 0; i < len(v); i++ {
+		e.buf = append(e.buf, v[i])
+		if v[i] == escape {
+			e.buf = append(e.buf, escaped)
+		}
+	}
+	return e.terminate()
+}
+
+// terminate writes the escaped contents of a
+// string, with its terminator, in the current
+// order.
+
+func (e *Encoder) terminate() error {
+	e.buf = append(e.buf, escape, terminator)
+	if e.dsc {
+		for i := range e.buf {
+			e.buf[i] = ^e.buf[i]
+		}
+	}
+	return e.wtr.WriteBytes(e.buf)
+}
@@ -0,0 +1,49 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keys implements an order-preserving encoding
+// for the components of keys, on top of bump Readers and
+// Writers, so that comparing two encoded keys byte by
+// byte gives the same result as comparing their values
+// component by component. Integers are written as fixed
+// size big-endian numbers, with the sign bit flipped for
+// signed integers, and floats are transformed so that
+// their bits sort in numeric order. Strings and bytes are
+// terminated, with any zero bytes escaped, so that a key
+// sorts before any longer key which it is a prefix of.
+// Any component can be written in descending order,
+// which inverts all of its bytes.
+package keys
+
+import "errors"
+
+// Strings and bytes are terminated with zero followed
+// by one, and zero bytes within them are escaped as
+// zero followed by 0xff.
+
+const (
+	escape     = 0x00
+	escaped    = 0xff
+	terminator = 0x01
+)
+
+// signBit is flipped so that signed integers
+// and floats sort in numeric order.
+const signBit = 1 << 63
+
+// ErrInvalidKey is returned when a component of a
+// key is not encoded correctly, such as a boolean
+// which is neither true nor false, or an escape
+// sequence which is not valid.
+var ErrInvalidKey = errors.New("keys: invalid key encoding")
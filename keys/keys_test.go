@@ -0,0 +1,261 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"testing/quick"
+
+	"github.com/surrealdb/bump"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// key encodes a key using the specified function.
+
+func key(fn func(e *Encoder)) []byte {
+	var b []byte
+	fn(NewEncoder(bump.NewWriterBytes(&b)))
+	return b
+}
+
+// sign returns -1 if one value is less than
+// another, 1 if it is greater, and otherwise 0.
+
+func sign(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// ordered checks that comparing the encodings of
+// two values, in both ascending and descending
+// order, agrees with comparing the values.
+
+func ordered(c int, fn func(e *Encoder, first bool)) bool {
+	a := key(func(e *Encoder) { fn(e, true) })
+	b := key(func(e *Encoder) { fn(e, false) })
+	if bytes.Compare(a, b) != c {
+		return false
+	}
+	a = key(func(e *Encoder) { e.Descending(true); fn(e, true) })
+	b = key(func(e *Encoder) { e.Descending(true); fn(e, false) })
+	return bytes.Compare(a, b) == -c
+}
+
+// floats are special values which are
+// compared along with random values.
+
+var floats = []float64{
+	math.Inf(-1), -math.MaxFloat64, -1e10, -1, -math.SmallestNonzeroFloat64,
+	0, math.SmallestNonzeroFloat64, 1, 1e10, math.MaxFloat64, math.Inf(1),
+}
+
+func TestKeys(t *testing.T) {
+
+	Convey("Keys should encode components as documented", t, func() {
+		So(key(func(e *Encoder) { e.WriteUint(1) }), ShouldResemble, []byte{0, 0, 0, 0, 0, 0, 0, 1})
+		So(key(func(e *Encoder) { e.WriteInt(-1) }), ShouldResemble, []byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+		So(key(func(e *Encoder) { e.WriteFloat(1) }), ShouldResemble, []byte{0xbf, 0xf0, 0, 0, 0, 0, 0, 0})
+		So(key(func(e *Encoder) { e.WriteFloat(-1) }), ShouldResemble, []byte{0x40, 0x0f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+		So(key(func(e *Encoder) { e.WriteBool(true) }), ShouldResemble, []byte{1})
+		So(key(func(e *Encoder) { e.WriteString("a\x00b") }), ShouldResemble, []byte{'a', 0, 0xff, 'b', 0, 1})
+		So(key(func(e *Encoder) {
+			e.Descending(true)
+			e.WriteBytes([]byte{0})
+			e.WriteBool(false)
+		}), ShouldResemble, []byte{0xff, 0, 0xff, 0xfe, 0xff})
+	})
+
+	Convey("Keys should compare in the same order as integers", t, func() {
+		So(quick.Check(func(a, b uint64) bool {
+			return ordered(sign(a < b, a > b), func(e *Encoder, f bool) {
+				if f {
+					e.WriteUint(a)
+				} else {
+					e.WriteUint(b)
+				}
+			})
+		}, nil), ShouldBeNil)
+		So(quick.Check(func(a, b int64) bool {
+			return ordered(sign(a < b, a > b), func(e *Encoder, f bool) {
+				if f {
+					e.WriteInt(a)
+				} else {
+					e.WriteInt(b)
+				}
+			})
+		}, nil), ShouldBeNil)
+		So(ordered(-1, func(e *Encoder, f bool) {
+			if f {
+				e.WriteInt(math.MinInt64)
+			} else {
+				e.WriteInt(math.MaxInt64)
+			}
+		}), ShouldBeTrue)
+	})
+
+	Convey("Keys should compare in the same order as floats", t, func() {
+		check := func(a, b float64) bool {
+			return ordered(sign(a < b, a > b), func(e *Encoder, f bool) {
+				if f {
+					e.WriteFloat(a)
+				} else {
+					e.WriteFloat(b)
+				}
+			})
+		}
+		So(quick.Check(check, nil), ShouldBeNil)
+		for _, a := range floats {
+			for _, b := range floats {
+				So(check(a, b), ShouldBeTrue)
+			}
+		}
+		So(bytes.Compare(
+			key(func(e *Encoder) { e.WriteFloat(math.Copysign(0, -1)) }),
+			key(func(e *Encoder) { e.WriteFloat(0) }),
+		), ShouldEqual, -1)
+	})
+
+	Convey("Keys should compare in the same order as strings and bytes", t, func() {
+		check := func(a, b string) bool {
+			return ordered(strings.Compare(a, b), func(e *Encoder, f bool) {
+				if f {
+					e.WriteString(a)
+				} else {
+					e.WriteString(b)
+				}
+			})
+		}
+		So(quick.Check(check, nil), ShouldBeNil)
+		for _, a := range []string{"", "\x00", "\x00\x00", "\x00\x01", "\x01", "a", "a\x00", "a\x00\xff", "a\x01", "ab", "\xff", "\xff\x00"} {
+			for _, b := range []string{"", "\x00", "\x01", "a", "a\x00", "ab", "\xff"} {
+				So(check(a, b), ShouldBeTrue)
+			}
+		}
+		So(quick.Check(func(a, b []byte) bool {
+			return ordered(bytes.Compare(a, b), func(e *Encoder, f bool) {
+				if f {
+					e.WriteBytes(a)
+				} else {
+					e.WriteBytes(b)
+				}
+			})
+		}, nil), ShouldBeNil)
+	})
+
+	Convey("Keys should compare component by component", t, func() {
+		So(quick.Check(func(a, b string, c, d int64, x, y bool) bool {
+			s := strings.Compare(a, b)
+			if s == 0 {
+				s = sign(c < d, c > d)
+			}
+			if s == 0 {
+				s = sign(!x && y, x && !y)
+			}
+			return ordered(s, func(e *Encoder, f bool) {
+				if f {
+					e.WriteString(a)
+					e.WriteInt(c)
+					e.WriteBool(x)
+				} else {
+					e.WriteString(b)
+					e.WriteInt(d)
+					e.WriteBool(y)
+				}
+			})
+		}, nil), ShouldBeNil)
+	})
+
+	Convey("Keys should round-trip in both orders", t, func() {
+		So(quick.Check(func(a uint64, b int64, c float64, d bool, s string, v []byte, dsc bool) bool {
+			k := key(func(e *Encoder) {
+				e.Descending(dsc)
+				e.WriteUint(a)
+				e.WriteInt(b)
+				e.WriteFloat(c)
+				e.WriteBool(d)
+				e.WriteString(s)
+				e.WriteBytes(v)
+				e.Descending(!dsc)
+				e.WriteString(s)
+			})
+			r := NewDecoder(bump.NewReader(iotest.OneByteReader(bytes.NewReader(k))))
+			r.Descending(dsc)
+			ra, _ := r.ReadUint()
+			rb, _ := r.ReadInt()
+			rc, _ := r.ReadFloat()
+			rd, _ := r.ReadBool()
+			rs, _ := r.ReadString()
+			rv, _ := r.ReadBytes()
+			r.Descending(!dsc)
+			rt, err := r.ReadString()
+			if err != nil {
+				return false
+			}
+			if _, err := r.ReadBool(); err != io.EOF {
+				return false
+			}
+			return ra == a && rb == b && rc == c && rd == d && rs == s && bytes.Equal(rv, v) && rt == s
+		}, nil), ShouldBeNil)
+	})
+
+	Convey("Keys should read strings longer than the buffer", t, func() {
+		s := strings.Repeat("ab\x00", 1000)
+		for _, dsc := range []bool{false, true} {
+			k := key(func(e *Encoder) {
+				e.Descending(dsc)
+				e.WriteString(s)
+				e.WriteString("")
+			})
+			r := NewDecoder(bump.NewReader(bytes.NewReader(k)))
+			r.Descending(dsc)
+			v, err := r.ReadString()
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, s)
+			v, err = r.ReadString()
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, "")
+		}
+	})
+
+	Convey("Keys should return errors from the Reader while reading strings", t, func() {
+		_, w := io.Pipe()
+		w.Close()
+		r := bump.NewReaderBytes(key(func(e *Encoder) { e.WriteString("a\x00b") }))
+		r.Tee(w)
+		_, err := NewDecoder(r).ReadString()
+		So(err, ShouldEqual, io.ErrClosedPipe)
+	})
+
+	Convey("Keys should return ErrInvalidKey for invalid components", t, func() {
+		_, err := NewDecoder(bump.NewReaderBytes([]byte{2})).ReadBool()
+		So(err, ShouldEqual, ErrInvalidKey)
+		_, err = NewDecoder(bump.NewReaderBytes([]byte{'a', 0, 2})).ReadString()
+		So(err, ShouldEqual, ErrInvalidKey)
+		_, err = NewDecoder(bump.NewReaderBytes([]byte{'a'})).ReadBytes()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+}
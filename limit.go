@@ -0,0 +1,292 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"io"
+)
+
+// Limit returns a child Reader which reads at
+// most n bytes from this Reader, sharing its
+// buffer, and returns io.EOF at the boundary.
+// When the child is closed, or when this Reader
+// is next used, this Reader is advanced past any
+// data in the section which was not read. Data
+// read through the child is mirrored to any
+// checksum or tee on this Reader. If n is
+// negative, then the child returns an error.
+func (r *Reader) Limit(n int) *Reader {
+	return r.limit(&Reader{}, n)
+}
+
+// Discard skips the next n bytes, and returns
+// the number of bytes which were discarded. If
+// fewer than n bytes were discarded, then an
+// error is also returned. Discarded data is
+// mirrored to any checksum or tee.
+func (r *Reader) Discard(n int) (int, error) {
+	if n < 0 {
+		return 0, ErrNegativeCount
+	}
+	if r.sub != nil {
+		r.skip()
+	}
+	return r.discard(n)
+}
+
+// limit initialises the specified Reader as a
+// child section of this Reader, so that child
+// Readers can be reused internally.
+
+func (r *Reader) limit(c *Reader, n int) *Reader {
+	if r.sub != nil {
+		r.skip()
+	}
+	*c = Reader{par: r, rem: n}
+	if n < 0 {
+		c.rem, c.err = 0, ErrNegativeCount
+	}
+	r.sub = c
+	return c
+}
+
+func (r *Reader) discard(n int) (int, error) {
+
+	// Return an error if the Reader has failed.
+
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	// Discard data from the parent Reader.
+
+	if r.par != nil {
+		return r.discardFromParent(n)
+	}
+
+	// Or discard data from the byte slice.
+
+	if r.out != nil {
+		if r.pgs != nil {
+			return r.discardFromSlices(n)
+		}
+		return r.discardFromBytes(n)
+	}
+
+	// Or discard data from the io.Reader.
+
+	return r.discardFromReader(n)
+
+}
+
+func (r *Reader) discardFromParent(n int) (int, error) {
+
+	// Stop discarding at the section boundary.
+
+	var err error
+
+	if n > r.rem {
+		n, err = r.rem, io.EOF
+	}
+
+	// Discard the data from the parent Reader.
+
+	d, e := r.par.discard(n)
+	if e != nil {
+		err = e
+	}
+
+	r.rem -= d
+
+	return d, err
+
+}
+
+func (r *Reader) discardFromBytes(n int) (int, error) {
+
+	// Stop discarding at the end of the data.
+
+	var err error
+
+	if n > len(r.out)-r.pos {
+		n, err = len(r.out)-r.pos, io.EOF
+	}
+
+	// Mirror the data which is being discarded.
+
+	if r.sum != nil || r.tee != nil {
+		if e := r.mirror(r.out[r.pos : r.pos+n]); e != nil {
+			return 0, e
+		}
+	}
+
+	// Advance the buffer position.
+
+	r.pos += n
+
+	return n, err
+
+}
+
+func (r *Reader) discardFromReader(n int) (int, error) {
+
+	// Initialise the underlying buffer if needed.
+
+	if r.buf == nil {
+		r.buf = r.arr[0:]
+	}
+
+	// Loop through until we have discarded enough.
+
+	for d := 0; ; {
+
+		if d == n {
+			return d, nil
+		}
+
+		// Fill the buffer with data if there is not enough.
+
+		if r.pos >= r.sze {
+			err := r.fill()
+			if err != nil {
+				return d, err
+			}
+		}
+
+		// Mirror the data which is being discarded.
+
+		c := r.sze - r.pos
+		if c > n-d {
+			c = n - d
+		}
+
+		if r.sum != nil || r.tee != nil {
+			if err := r.mirror(r.buf[r.pos : r.pos+c]); err != nil {
+				return d, err
+			}
+		}
+
+		// Advance the buffer position.
+
+		r.pos += c
+		d += c
+
+	}
+
+}
+
+func (r *Reader) peekByteFromParent() (byte, error) {
+	if r.rem < 1 {
+		return byte(0), io.EOF
+	}
+	return r.par.peekByte()
+}
+
+func (r *Reader) peekBytesFromParent() ([]byte, error) {
+	if r.rem < 1 {
+		return nil, io.EOF
+	}
+	b, err := r.par.peekBytes()
+	if len(b) > r.rem {
+		b = b[:r.rem]
+	}
+	return b, err
+}
+
+func (r *Reader) readByteFromParent() (byte, error) {
+	if r.rem < 1 {
+		return byte(0), io.EOF
+	}
+	b, err := r.par.takeByte()
+	if err == nil {
+		r.rem -= 1
+	}
+	return b, err
+}
+
+func (r *Reader) readBytesFromParent(l int) ([]byte, error) {
+	if r.rem < l {
+		return nil, io.EOF
+	}
+	b, err := r.par.takeBytes(l)
+	if err == nil {
+		r.rem -= l
+	}
+	return b, err
+}
+
+func (r *Reader) readStringFromParent(l int) (string, error) {
+	if r.rem < l {
+		return "", io.EOF
+	}
+	s, err := r.par.takeString(l)
+	if err == nil {
+		r.rem -= l
+	}
+	return s, err
+}
+
+func (r *Reader) closeLimit() error {
+
+	// Close any nested section first.
+
+	if r.sub != nil {
+		r.skip()
+	}
+
+	// Advance the parent past the remainder.
+
+	r.par.sub = nil
+	r.err = ErrClosed
+
+	_, err := r.par.discard(r.rem)
+
+	r.rem = 0
+
+	return err
+
+}
+
+// skip closes the open child section, which
+// advances this Reader past its remainder.
+
+func (r *Reader) skip() {
+	r.sub.Close()
+}
+
+// detach prevents any open child sections
+// from being used, without advancing past
+// their remainder.
+
+func (r *Reader) detach() {
+	for s := r.sub; s != nil; s = s.sub {
+		s.err = ErrClosed
+	}
+	r.sub = nil
+}
+
+// release closes this Reader if it is a child
+// section, and detaches it from its parent, so
+// that it can be reused for other data.
+
+func (r *Reader) release() {
+	if r.par != nil {
+		if r.err != ErrClosed {
+			r.closeLimit()
+		}
+		r.par = nil
+	}
+	r.detach()
+}
@@ -0,0 +1,225 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLimit(t *testing.T) {
+
+	Convey("Limit should return io.EOF at the section boundary", t, func() {
+		r := NewReader(bytes.NewReader(big))
+		c := r.Limit(3000)
+		o := chunkReadBytes(c, 3000)
+		So(o, ShouldResemble, big[:3000])
+		_, err := c.ReadByte()
+		So(err, ShouldEqual, io.EOF)
+		_, err = c.PeekByte()
+		So(err, ShouldEqual, io.EOF)
+		_, err = c.ReadBytes(1)
+		So(err, ShouldEqual, io.EOF)
+		So(c.Close(), ShouldBeNil)
+		b, _ := r.ReadByte()
+		So(b, ShouldEqual, big[3000])
+	})
+
+	Convey("Limit should advance the parent past the remainder on Close", t, func() {
+		r := NewReader(bytes.NewReader(big))
+		c := r.Limit(5000)
+		c.ReadBytes(10)
+		So(c.Close(), ShouldBeNil)
+		So(c.Close(), ShouldEqual, ErrClosed)
+		_, err := c.ReadByte()
+		So(err, ShouldEqual, ErrClosed)
+		o, _ := r.ReadBytes(100)
+		So(o, ShouldResemble, big[5000:5100])
+	})
+
+	Convey("Limit should advance the parent past the remainder when abandoned", t, func() {
+		r := NewReaderBytes(big)
+		c := r.Limit(5000)
+		c.ReadString(10)
+		o, _ := r.ReadBytes(100)
+		So(o, ShouldResemble, big[5000:5100])
+		_, err := c.ReadByte()
+		So(err, ShouldEqual, ErrClosed)
+	})
+
+	Convey("Limit should not copy data when reading from a byte slice", t, func() {
+		r := NewReaderBytes(big)
+		c := r.Limit(100)
+		o, _ := c.ReadBytes(100)
+		So(&o[0], ShouldEqual, &big[0])
+	})
+
+	Convey("Limit should support nested sections", t, func() {
+		r := NewReaderBytes(big)
+		c := r.Limit(1000)
+		g := c.Limit(200)
+		o, _ := g.ReadBytes(50)
+		So(o, ShouldResemble, big[:50])
+		_, err := g.ReadBytes(151)
+		So(err, ShouldEqual, io.EOF)
+		o, _ = c.ReadBytes(100)
+		So(o, ShouldResemble, big[200:300])
+		So(c.Close(), ShouldBeNil)
+		o, _ = r.ReadBytes(100)
+		So(o, ShouldResemble, big[1000:1100])
+	})
+
+	Convey("Limit should mirror data read through a section to the parent", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.Checksum(NewCRC32C())
+		w.WriteBytes(big)
+		w.WriteChecksum()
+		r := NewReaderBytes(b)
+		r.Checksum(NewCRC32C())
+		c := r.Limit(len(big))
+		c.ReadBytes(100)
+		So(c.Close(), ShouldBeNil)
+		So(r.ReadChecksum(), ShouldBeNil)
+	})
+
+	Convey("Limit should return io.EOF when the parent has too little data", t, func() {
+		r := NewReader(bytes.NewReader(txt))
+		c := r.Limit(len(txt) + 10)
+		_, err := c.ReadBytes(len(txt) + 1)
+		So(err, ShouldEqual, io.EOF)
+		So(c.Close(), ShouldEqual, io.EOF)
+	})
+
+	Convey("Limit should return an error for a negative count", t, func() {
+		r := NewReaderBytes(txt)
+		c := r.Limit(-1)
+		_, err := c.ReadByte()
+		So(err, ShouldEqual, ErrNegativeCount)
+		_, err = c.Discard(1)
+		So(err, ShouldEqual, ErrNegativeCount)
+		So(c.Close(), ShouldBeNil)
+		o, _ := r.ReadBytes(10)
+		So(o, ShouldResemble, txt[:10])
+	})
+
+	Convey("Limit should detach sections when the parent is reset", t, func() {
+		r := NewReaderBytes(big)
+		c := r.Limit(100)
+		r.ResetBytes(txt)
+		_, err := c.ReadByte()
+		So(err, ShouldEqual, ErrClosed)
+		o, _ := r.ReadBytes(10)
+		So(o, ShouldResemble, txt[:10])
+	})
+
+}
+
+func TestDiscard(t *testing.T) {
+
+	Convey("Discard should skip data from an io.Reader", t, func() {
+		r := NewReader(bytes.NewReader(big))
+		n, err := r.Discard(5000)
+		So(n, ShouldEqual, 5000)
+		So(err, ShouldBeNil)
+		o, _ := r.ReadBytes(10)
+		So(o, ShouldResemble, big[5000:5010])
+		n, err = r.Discard(len(big))
+		So(n, ShouldEqual, len(big)-5010)
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Discard should skip data from a byte slice", t, func() {
+		r := NewReaderBytes(txt)
+		n, err := r.Discard(10)
+		So(n, ShouldEqual, 10)
+		So(err, ShouldBeNil)
+		o, _ := r.ReadBytes(10)
+		So(o, ShouldResemble, txt[10:20])
+		n, err = r.Discard(len(txt))
+		So(n, ShouldEqual, len(txt)-20)
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Discard should mirror discarded data", t, func() {
+		c := bytes.NewBuffer(nil)
+		r := NewReader(bytes.NewReader(big))
+		r.Tee(c)
+		r.Discard(len(big))
+		So(c.Bytes(), ShouldResemble, big)
+	})
+
+	Convey("Discard should return an error for a negative count", t, func() {
+		c := bytes.NewBuffer(nil)
+		r := NewReader(bytes.NewReader(big))
+		r.Tee(c)
+		n, err := r.Discard(-2)
+		So(n, ShouldEqual, 0)
+		So(err, ShouldEqual, ErrNegativeCount)
+		s := NewReaderBytes(txt)
+		n, err = s.Discard(-2)
+		So(n, ShouldEqual, 0)
+		So(err, ShouldEqual, ErrNegativeCount)
+		o, _ := s.ReadBytes(10)
+		So(o, ShouldResemble, txt[:10])
+		So(c.Len(), ShouldEqual, 0)
+	})
+
+}
+
+func TestPeekBytes(t *testing.T) {
+
+	Convey("PeekBytes should return the buffered data from an io.Reader", t, func() {
+		r := NewReader(bytes.NewReader(big))
+		p, err := r.PeekBytes()
+		So(err, ShouldBeNil)
+		So(len(p), ShouldBeGreaterThan, 0)
+		So(p, ShouldResemble, big[:len(p)])
+		r.Discard(len(p))
+		q, _ := r.PeekBytes()
+		So(q, ShouldResemble, big[len(p):len(p)+len(q)])
+		r.Discard(len(big))
+		_, err = r.PeekBytes()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("PeekBytes should not copy data when reading from a byte slice", t, func() {
+		r := NewReaderBytes(txt)
+		r.Discard(10)
+		p, err := r.PeekBytes()
+		So(err, ShouldBeNil)
+		So(&p[0], ShouldEqual, &txt[10])
+		So(len(p), ShouldEqual, len(txt)-10)
+		o, _ := r.ReadBytes(10)
+		So(o, ShouldResemble, txt[10:20])
+	})
+
+	Convey("PeekBytes should stop at the section boundary", t, func() {
+		r := NewReader(bytes.NewReader(big))
+		c := r.Limit(10)
+		p, err := c.PeekBytes()
+		So(err, ShouldBeNil)
+		So(p, ShouldResemble, big[:10])
+		c.Discard(10)
+		_, err = c.PeekBytes()
+		So(err, ShouldEqual, io.EOF)
+		p, _ = r.PeekBytes()
+		So(p[0], ShouldEqual, big[10])
+	})
+
+}
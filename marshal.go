@@ -0,0 +1,172 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Marshaler is the interface implemented by types
+// which can write themselves to a Writer. Encode
+// uses the MarshalBump method of any value which
+// implements Marshaler, instead of reflection.
+type Marshaler interface {
+	MarshalBump(w *Writer) error
+}
+
+// Unmarshaler is the interface implemented by types
+// which can read themselves from a Reader. Decode
+// uses the UnmarshalBump method of any value which
+// implements Unmarshaler, instead of reflection.
+type Unmarshaler interface {
+	UnmarshalBump(r *Reader) error
+}
+
+var (
+	marshalerType   = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+)
+
+// marshalState holds a Writer and the buffer
+// which it writes to, so that both can be
+// reused when marshaling values.
+
+type marshalState struct {
+	w Writer
+	b []byte
+}
+
+var marshalStates = sync.Pool{
+	New: func() interface{} {
+		return new(marshalState)
+	},
+}
+
+// maxPooledSize is the largest buffer which is
+// returned to the pool after marshaling, so that
+// one large value does not keep its memory alive.
+const maxPooledSize = 64 * 1024
+
+var unmarshalReaders = sync.Pool{
+	New: func() interface{} {
+		return new(Reader)
+	},
+}
+
+// Marshal encodes the specified value using Encode,
+// and returns the encoded data. The value is encoded
+// into a pooled buffer, so that only the returned
+// byte slice is allocated.
+func Marshal(v interface{}) ([]byte, error) {
+
+	// Encode the value into a pooled buffer.
+
+	m := marshalStates.Get().(*marshalState)
+	defer putMarshalState(m)
+
+	m.b = m.b[:0]
+	m.w.ResetBytes(&m.b)
+
+	err := Encode(&m.w, v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the data out of the pooled buffer.
+
+	b := make([]byte, len(m.b))
+	copy(b, m.b)
+
+	return b, nil
+
+}
+
+// putMarshalState returns the state to the pool,
+// unless its buffer has grown too large.
+
+func putMarshalState(m *marshalState) {
+	if cap(m.b) > maxPooledSize {
+		return
+	}
+	marshalStates.Put(m)
+}
+
+// Unmarshal decodes a value which was encoded using
+// Marshal or Encode from the specified byte slice,
+// and stores it in the value which the specified
+// pointer points to, using Decode.
+func Unmarshal(b []byte, v interface{}) error {
+
+	r := unmarshalReaders.Get().(*Reader)
+	defer unmarshalReaders.Put(r)
+
+	r.ResetBytes(b)
+	defer r.ResetBytes(nil)
+
+	return Decode(r, v)
+
+}
+
+// marshalerCoder returns functions which encode and
+// decode values of the specified type using their
+// MarshalBump and UnmarshalBump methods. Either of
+// the functions is nil if the type does not have
+// the corresponding method. Pointer types are not
+// checked, so that nil pointers are still written
+// with a presence byte.
+
+func marshalerCoder(t reflect.Type) (func(*Writer, reflect.Value) error, func(*Reader, reflect.Value) error) {
+
+	var enc func(*Writer, reflect.Value) error
+	var dec func(*Reader, reflect.Value) error
+
+	if t.Kind() == reflect.Ptr {
+		return nil, nil
+	}
+
+	// Use the MarshalBump method of the value, or of
+	// a pointer to a copy if it is not addressable.
+
+	switch {
+	case t.Implements(marshalerType):
+		enc = func(w *Writer, v reflect.Value) error {
+			return v.Interface().(Marshaler).MarshalBump(w)
+		}
+	case reflect.PtrTo(t).Implements(marshalerType):
+		enc = func(w *Writer, v reflect.Value) error {
+			if !v.CanAddr() {
+				p := reflect.New(t)
+				p.Elem().Set(v)
+				v = p.Elem()
+			}
+			return v.Addr().Interface().(Marshaler).MarshalBump(w)
+		}
+	}
+
+	// Values are always decoded into addressable
+	// values, so a pointer can always be taken.
+
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		dec = func(r *Reader, v reflect.Value) error {
+			return v.Addr().Interface().(Unmarshaler).UnmarshalBump(r)
+		}
+	}
+
+	// Everything went ok.
+
+	return enc, dec
+
+}
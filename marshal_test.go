@@ -0,0 +1,171 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// marshalPoint writes itself as two fixed-size
+// integers, with methods on the pointer type.
+
+type marshalPoint struct {
+	X, Y int32
+	Fn   func()
+}
+
+func (p *marshalPoint) MarshalBump(w *Writer) error {
+	w.WriteInt32(p.X)
+	return w.WriteInt32(p.Y)
+}
+
+func (p *marshalPoint) UnmarshalBump(r *Reader) error {
+	x, err := r.ReadInt32()
+	if err != nil {
+		return err
+	}
+	y, err := r.ReadInt32()
+	if err != nil {
+		return err
+	}
+	*p = marshalPoint{X: x, Y: y}
+	return nil
+}
+
+// marshalCode writes itself as a single byte,
+// with a method on the value type.
+
+type marshalCode uint64
+
+func (c marshalCode) MarshalBump(w *Writer) error {
+	return w.WriteUint8(uint8(c))
+}
+
+func (c *marshalCode) UnmarshalBump(r *Reader) error {
+	b, err := r.ReadUint8()
+	*c = marshalCode(b)
+	return err
+}
+
+// marshalFail returns an error when written.
+
+type marshalFail struct{}
+
+func (marshalFail) MarshalBump(w *Writer) error {
+	return errors.New("failed")
+}
+
+type marshalShape struct {
+	Name   string
+	Origin marshalPoint
+	Points []marshalPoint
+	Lookup map[string]marshalPoint
+	Code   marshalCode
+	Corner *marshalPoint
+}
+
+func TestMarshal(t *testing.T) {
+
+	// Allocations from pooled state are only
+	// checked without the race detector.
+
+	pooled := Convey
+	if raceEnabled {
+		pooled = SkipConvey
+	}
+
+	Convey("Marshal should use the MarshalBump method", t, func() {
+		b, err := Marshal(&marshalPoint{X: 1, Y: 2})
+		So(err, ShouldBeNil)
+		So(b, ShouldResemble, []byte{0, 0, 0, 1, 0, 0, 0, 2})
+		var o marshalPoint
+		So(Unmarshal(b, &o), ShouldBeNil)
+		So(o, ShouldResemble, marshalPoint{X: 1, Y: 2})
+	})
+
+	Convey("Marshal should use the MarshalBump method of values", t, func() {
+		b, err := Marshal(marshalPoint{X: 1, Y: 2})
+		So(err, ShouldBeNil)
+		So(b, ShouldResemble, []byte{0, 0, 0, 1, 0, 0, 0, 2})
+		b, err = Marshal(marshalCode(300))
+		So(err, ShouldBeNil)
+		So(b, ShouldResemble, []byte{44})
+	})
+
+	Convey("Marshal should use the MarshalBump method of nested values", t, func() {
+		v := marshalShape{
+			Name:   "shape",
+			Origin: marshalPoint{X: -1, Y: -2},
+			Points: []marshalPoint{{X: 1}, {Y: 2}},
+			Lookup: map[string]marshalPoint{"a": {X: 3, Y: 4}},
+			Code:   7,
+			Corner: &marshalPoint{X: 5, Y: 6},
+		}
+		b, err := Marshal(v)
+		So(err, ShouldBeNil)
+		c, err := Marshal(&v)
+		So(err, ShouldBeNil)
+		So(c, ShouldResemble, b)
+		var o marshalShape
+		So(Unmarshal(b, &o), ShouldBeNil)
+		So(o, ShouldResemble, v)
+	})
+
+	Convey("Marshal should return a new byte slice each time", t, func() {
+		b, _ := Marshal("first")
+		c, _ := Marshal("second")
+		So(b, ShouldResemble, []byte("\x05first"))
+		So(c, ShouldResemble, []byte("\x06second"))
+	})
+
+	pooled("Marshal should only allocate the returned byte slice", t, func() {
+		v := &marshalPoint{X: 1, Y: 2}
+		Marshal(v)
+		n := testing.AllocsPerRun(10, func() {
+			Marshal(v)
+		})
+		So(n, ShouldEqual, 1)
+	})
+
+	Convey("Marshal should not pool oversized buffers", t, func() {
+		m := &marshalState{b: make([]byte, 0, maxPooledSize+1)}
+		putMarshalState(m)
+		So(marshalStates.Get(), ShouldNotEqual, m)
+	})
+
+	pooled("Unmarshal should not allocate for Unmarshaler types", t, func() {
+		b := []byte{0, 0, 0, 1, 0, 0, 0, 2}
+		var o marshalPoint
+		Unmarshal(b, &o)
+		n := testing.AllocsPerRun(10, func() {
+			Unmarshal(b, &o)
+		})
+		So(n, ShouldEqual, 0)
+	})
+
+	Convey("Marshal and Unmarshal should return errors", t, func() {
+		_, err := Marshal(marshalFail{})
+		So(err, ShouldNotBeNil)
+		_, err = Marshal(nil)
+		So(err, ShouldEqual, ErrInvalidEncode)
+		var o marshalPoint
+		So(Unmarshal([]byte{0, 0, 0, 1}, &o), ShouldNotBeNil)
+		So(Unmarshal([]byte{0, 0, 0, 1}, o), ShouldEqual, ErrInvalidDecode)
+	})
+
+}
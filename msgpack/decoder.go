@@ -0,0 +1,467 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgpack
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/surrealdb/bump"
+)
+
+// Decoder reads MessagePack items and values from
+// a bump Reader. Each item is checked before it is
+// read, so an item which has the wrong type can
+// still be read using another method, or skipped.
+type Decoder struct {
+	rdr *bump.Reader
+}
+
+// NewDecoder creates a new Decoder which
+// reads from the specified Reader.
+func NewDecoder(r *bump.Reader) *Decoder {
+	return &Decoder{rdr: r}
+}
+
+// Reset instructs the Decoder to read
+// from the specified Reader.
+func (d *Decoder) Reset(r *bump.Reader) {
+	d.rdr = r
+}
+
+// PeekType returns the type of the next item,
+// without advancing the position of the Reader.
+// Timestamps are reported as ExtType.
+func (d *Decoder) PeekType() (Type, error) {
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return InvalidType, err
+	}
+	if c == codeNever {
+		return InvalidType, ErrInvalidCode
+	}
+	return typeOf(c), nil
+}
+
+// code returns the format code of the next item,
+// if the item has the specified type, and advances
+// past the format code.
+
+func (d *Decoder) code(t Type) (byte, error) {
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return 0, err
+	}
+	if typeOf(c) != t {
+		return 0, d.fail(t, c)
+	}
+	return d.rdr.ReadByte()
+}
+
+// fail returns the error for an item with
+// the specified code which can not be read.
+
+func (d *Decoder) fail(t Type, c byte) error {
+	if c == codeNever {
+		return ErrInvalidCode
+	}
+	return &TypeError{Want: t, Got: typeOf(c)}
+}
+
+// uint reads an unsigned big-endian
+// integer of n bytes.
+
+func (d *Decoder) uint(n int) (uint64, error) {
+	switch n {
+	case 1:
+		v, err := d.rdr.ReadUint8()
+		return uint64(v), err
+	case 2:
+		v, err := d.rdr.ReadUint16()
+		return uint64(v), err
+	case 4:
+		v, err := d.rdr.ReadUint32()
+		return uint64(v), err
+	}
+	return d.rdr.ReadUint64()
+}
+
+// length reads the length which follows a format
+// code, where the codes for the 8-bit, 16-bit and
+// 32-bit formats are consecutive.
+
+func (d *Decoder) length(c, c8 byte) (int, error) {
+	v, err := d.uint(1 << (c - c8))
+	return int(v), err
+}
+
+// ReadNil reads a nil item.
+func (d *Decoder) ReadNil() error {
+	_, err := d.code(NilType)
+	return err
+}
+
+// ReadBool reads a boolean item.
+func (d *Decoder) ReadBool() (bool, error) {
+	c, err := d.code(BoolType)
+	return c == codeTrue, err
+}
+
+// ReadInt reads an integer item as a signed integer,
+// returning bump.ErrOverflow if it is too large.
+func (d *Decoder) ReadInt() (int64, error) {
+
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return 0, err
+	}
+
+	// Read unsigned integers if they fit.
+
+	if typeOf(c) == UintType {
+		v, err := d.ReadUint()
+		if err == nil && v > math.MaxInt64 {
+			return 0, bump.ErrOverflow
+		}
+		return int64(v), err
+	}
+
+	// Otherwise read the signed integer.
+
+	c, err = d.code(IntType)
+	if err != nil {
+		return 0, err
+	}
+
+	if c >= codeNegFixInt {
+		return int64(int8(c)), nil
+	}
+
+	v, err := d.uint(1 << (c - codeInt8))
+	if err != nil {
+		return 0, err
+	}
+
+	switch c {
+	case codeInt8:
+		return int64(int8(v)), nil
+	case codeInt16:
+		return int64(int16(v)), nil
+	case codeInt32:
+		return int64(int32(v)), nil
+	}
+
+	return int64(v), nil
+
+}
+
+// ReadUint reads an integer item as an unsigned
+// integer, returning bump.ErrOverflow if it is
+// negative.
+func (d *Decoder) ReadUint() (uint64, error) {
+
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return 0, err
+	}
+
+	// Read signed integers if they are positive.
+
+	if typeOf(c) == IntType {
+		v, err := d.ReadInt()
+		if err == nil && v < 0 {
+			return 0, bump.ErrOverflow
+		}
+		return uint64(v), err
+	}
+
+	// Otherwise read the unsigned integer.
+
+	c, err = d.code(UintType)
+	if err != nil {
+		return 0, err
+	}
+
+	if c <= 0x7f {
+		return uint64(c), nil
+	}
+
+	return d.uint(1 << (c - codeUint8))
+
+}
+
+// ReadFloat32 reads a 32-bit floating point item.
+func (d *Decoder) ReadFloat32() (float32, error) {
+	if _, err := d.code(Float32Type); err != nil {
+		return 0, err
+	}
+	return d.rdr.ReadFloat32()
+}
+
+// ReadFloat64 reads a floating point item, which
+// can be either a 32-bit or 64-bit item.
+func (d *Decoder) ReadFloat64() (float64, error) {
+	c, err := d.rdr.PeekByte()
+	if err != nil {
+		return 0, err
+	}
+	if c == codeFloat32 {
+		v, err := d.ReadFloat32()
+		return float64(v), err
+	}
+	if _, err := d.code(Float64Type); err != nil {
+		return 0, err
+	}
+	return d.rdr.ReadFloat64()
+}
+
+// readStr reads the length of a string item.
+
+func (d *Decoder) readStr() (int, error) {
+	c, err := d.code(StrType)
+	if err != nil {
+		return 0, err
+	}
+	if c <= 0xbf {
+		return int(c - codeFixStrMin), nil
+	}
+	return d.length(c, codeStr8)
+}
+
+// ReadString reads a string item.
+func (d *Decoder) ReadString() (string, error) {
+	n, err := d.readStr()
+	if err != nil {
+		return "", err
+	}
+	return d.rdr.ReadString(n)
+}
+
+// ReadStringBytes reads a string item as a byte
+// slice. When reading from a byte slice, the data
+// refers to the byte slice instead of being copied.
+func (d *Decoder) ReadStringBytes() ([]byte, error) {
+	n, err := d.readStr()
+	if err != nil {
+		return nil, err
+	}
+	return d.rdr.ReadBytes(n)
+}
+
+// ReadBytes reads a binary item. When reading from
+// a byte slice, the data refers to the byte slice
+// instead of being copied.
+func (d *Decoder) ReadBytes() ([]byte, error) {
+	c, err := d.code(BinType)
+	if err != nil {
+		return nil, err
+	}
+	n, err := d.length(c, codeBin8)
+	if err != nil {
+		return nil, err
+	}
+	return d.rdr.ReadBytes(n)
+}
+
+// ReadArrayHeader reads the header of an array item,
+// and returns the number of items which follow it.
+func (d *Decoder) ReadArrayHeader() (int, error) {
+	c, err := d.code(ArrayType)
+	if err != nil {
+		return 0, err
+	}
+	if c <= 0x9f {
+		return int(c - codeFixArrayMin), nil
+	}
+	return d.length(c+1, codeArray16)
+}
+
+// ReadMapHeader reads the header of a map item, and
+// returns the number of pairs of items which follow it.
+func (d *Decoder) ReadMapHeader() (int, error) {
+	c, err := d.code(MapType)
+	if err != nil {
+		return 0, err
+	}
+	if c <= 0x8f {
+		return int(c - codeFixMapMin), nil
+	}
+	return d.length(c+1, codeMap16)
+}
+
+// ReadExtHeader reads the header of an extension
+// item, and returns its type, and the number of
+// bytes of data which follow it.
+func (d *Decoder) ReadExtHeader() (int8, int, error) {
+
+	c, err := d.code(ExtType)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Find the length of the data.
+
+	var n int
+
+	switch c {
+	case codeFixExt1, codeFixExt2, codeFixExt4, codeFixExt8, codeFixExt16:
+		n = 1 << (c - codeFixExt1)
+	default:
+		n, err = d.length(c, codeExt8)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// Read the extension type.
+
+	t, err := d.rdr.ReadInt8()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return t, n, nil
+
+}
+
+// ReadExt reads an extension item. When reading from
+// a byte slice, the data refers to the byte slice
+// instead of being copied.
+func (d *Decoder) ReadExt() (int8, []byte, error) {
+	t, n, err := d.ReadExtHeader()
+	if err != nil {
+		return 0, nil, err
+	}
+	b, err := d.rdr.ReadBytes(n)
+	if err != nil {
+		return 0, nil, err
+	}
+	return t, b, nil
+}
+
+// ReadTime reads a timestamp extension item, in any
+// of the 32-bit, 64-bit and 96-bit formats. The time
+// is returned in the local time zone.
+func (d *Decoder) ReadTime() (time.Time, error) {
+
+	t, b, err := d.ReadExt()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if t != TimeExt {
+		return time.Time{}, ErrInvalidTime
+	}
+
+	return decodeTime(b)
+
+}
+
+// decodeTime decodes the data of a
+// timestamp extension item.
+
+func decodeTime(b []byte) (time.Time, error) {
+
+	switch len(b) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0), nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		if v>>34 > 999999999 {
+			return time.Time{}, ErrInvalidTime
+		}
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), nil
+	case 12:
+		n := binary.BigEndian.Uint32(b)
+		if n > 999999999 {
+			return time.Time{}, ErrInvalidTime
+		}
+		return time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(n)), nil
+	}
+
+	return time.Time{}, ErrInvalidTime
+
+}
+
+// Skip skips the next item, including all of
+// the items within it if it is an array or map.
+// Nested items are skipped without recursion, so
+// there is no limit on how deeply they are nested.
+func (d *Decoder) Skip() error {
+
+	for n := 1; n > 0; n-- {
+
+		c, err := d.rdr.PeekByte()
+		if err != nil {
+			return err
+		}
+
+		// Find the length of the item data, or
+		// the number of items within the item.
+
+		var l int
+
+		switch t := typeOf(c); t {
+		case NilType, BoolType, IntType, UintType:
+			d.rdr.ReadByte()
+			switch {
+			case c >= codeUint8 && c <= codeUint64:
+				l = 1 << (c - codeUint8)
+			case c >= codeInt8 && c <= codeInt64:
+				l = 1 << (c - codeInt8)
+			}
+		case Float32Type:
+			d.rdr.ReadByte()
+			l = 4
+		case Float64Type:
+			d.rdr.ReadByte()
+			l = 8
+		case StrType:
+			l, err = d.readStr()
+		case BinType:
+			d.rdr.ReadByte()
+			l, err = d.length(c, codeBin8)
+		case ExtType:
+			_, l, err = d.ReadExtHeader()
+		case ArrayType:
+			l, err = d.ReadArrayHeader()
+			n, l = n+l, 0
+		case MapType:
+			l, err = d.ReadMapHeader()
+			n, l = n+2*l, 0
+		default:
+			return d.fail(t, c)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Skip past the item data.
+
+		if l > 0 {
+			if _, err := d.rdr.Discard(l); err != nil {
+				return err
+			}
+		}
+
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
@@ -0,0 +1,241 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgpack
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/surrealdb/bump"
+)
+
+// Encoder writes MessagePack items and values
+// to a bump Writer. Each item is written in the
+// smallest format which can represent it.
+type Encoder struct {
+	wtr *bump.Writer
+	tmp [9]byte
+	tsp [12]byte
+}
+
+// NewEncoder creates a new Encoder which
+// writes to the specified Writer.
+func NewEncoder(w *bump.Writer) *Encoder {
+	return &Encoder{wtr: w}
+}
+
+// Reset instructs the Encoder to write
+// to the specified Writer.
+func (e *Encoder) Reset(w *bump.Writer) {
+	e.wtr = w
+}
+
+// head writes a format code followed by
+// an unsigned integer of n bytes.
+
+func (e *Encoder) head(c byte, n int, v uint64) error {
+	e.tmp[0] = c
+	switch n {
+	case 1:
+		e.tmp[1] = byte(v)
+	case 2:
+		binary.BigEndian.PutUint16(e.tmp[1:], uint16(v))
+	case 4:
+		binary.BigEndian.PutUint32(e.tmp[1:], uint32(v))
+	case 8:
+		binary.BigEndian.PutUint64(e.tmp[1:], v)
+	}
+	return e.wtr.WriteBytes(e.tmp[:1+n])
+}
+
+// size writes the format code for a length,
+// using the smallest of the fixed format, and
+// the 8-bit, 16-bit, or 32-bit formats. A code
+// of zero means that a format is not available.
+
+func (e *Encoder) size(n int, fix, max byte, c8, c16, c32 byte) error {
+	switch {
+	case n < 0 || uint64(n) > math.MaxUint32:
+		return ErrLength
+	case fix != 0 && n <= int(max):
+		return e.wtr.WriteByte(fix | byte(n))
+	case c8 != 0 && n <= math.MaxUint8:
+		return e.head(c8, 1, uint64(n))
+	case n <= math.MaxUint16:
+		return e.head(c16, 2, uint64(n))
+	}
+	return e.head(c32, 4, uint64(n))
+}
+
+// WriteNil writes a nil item.
+func (e *Encoder) WriteNil() error {
+	return e.wtr.WriteByte(codeNil)
+}
+
+// WriteBool writes a boolean item.
+func (e *Encoder) WriteBool(v bool) error {
+	if v {
+		return e.wtr.WriteByte(codeTrue)
+	}
+	return e.wtr.WriteByte(codeFalse)
+}
+
+// WriteInt writes a signed integer item. Positive
+// integers are written using the unsigned formats.
+func (e *Encoder) WriteInt(v int64) error {
+	switch {
+	case v >= 0:
+		return e.WriteUint(uint64(v))
+	case v >= -32:
+		return e.wtr.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		return e.head(codeInt8, 1, uint64(v))
+	case v >= math.MinInt16:
+		return e.head(codeInt16, 2, uint64(v))
+	case v >= math.MinInt32:
+		return e.head(codeInt32, 4, uint64(v))
+	}
+	return e.head(codeInt64, 8, uint64(v))
+}
+
+// WriteUint writes an unsigned integer item.
+func (e *Encoder) WriteUint(v uint64) error {
+	switch {
+	case v <= 0x7f:
+		return e.wtr.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		return e.head(codeUint8, 1, v)
+	case v <= math.MaxUint16:
+		return e.head(codeUint16, 2, v)
+	case v <= math.MaxUint32:
+		return e.head(codeUint32, 4, v)
+	}
+	return e.head(codeUint64, 8, v)
+}
+
+// WriteFloat32 writes a 32-bit floating point item.
+func (e *Encoder) WriteFloat32(v float32) error {
+	return e.head(codeFloat32, 4, uint64(math.Float32bits(v)))
+}
+
+// WriteFloat64 writes a 64-bit floating point item.
+func (e *Encoder) WriteFloat64(v float64) error {
+	return e.head(codeFloat64, 8, math.Float64bits(v))
+}
+
+// WriteString writes a string item.
+func (e *Encoder) WriteString(v string) error {
+	if err := e.size(len(v), codeFixStrMin, 31, codeStr8, codeStr16, codeStr32); err != nil {
+		return err
+	}
+	return e.wtr.WriteString(v)
+}
+
+// WriteStringBytes writes a string item
+// from the specified byte slice.
+func (e *Encoder) WriteStringBytes(v []byte) error {
+	if err := e.size(len(v), codeFixStrMin, 31, codeStr8, codeStr16, codeStr32); err != nil {
+		return err
+	}
+	return e.wtr.WriteBytes(v)
+}
+
+// WriteBytes writes a binary item.
+func (e *Encoder) WriteBytes(v []byte) error {
+	if err := e.size(len(v), 0, 0, codeBin8, codeBin16, codeBin32); err != nil {
+		return err
+	}
+	return e.wtr.WriteBytes(v)
+}
+
+// WriteArrayHeader writes the header of an array
+// item, which must be followed by n items.
+func (e *Encoder) WriteArrayHeader(n int) error {
+	return e.size(n, codeFixArrayMin, 15, 0, codeArray16, codeArray32)
+}
+
+// WriteMapHeader writes the header of a map item,
+// which must be followed by n pairs of items.
+func (e *Encoder) WriteMapHeader(n int) error {
+	return e.size(n, codeFixMapMin, 15, 0, codeMap16, codeMap32)
+}
+
+// WriteExtHeader writes the header of an extension
+// item, which must be followed by n bytes of data.
+func (e *Encoder) WriteExtHeader(t int8, n int) error {
+
+	// Use the fixed formats when possible.
+
+	var c byte
+
+	switch n {
+	case 1:
+		c = codeFixExt1
+	case 2:
+		c = codeFixExt2
+	case 4:
+		c = codeFixExt4
+	case 8:
+		c = codeFixExt8
+	case 16:
+		c = codeFixExt16
+	}
+
+	if c != 0 {
+		e.tmp[0], e.tmp[1] = c, byte(t)
+		return e.wtr.WriteBytes(e.tmp[:2])
+	}
+
+	// Otherwise write the length and type.
+
+	if err := e.size(n, 0, 0, codeExt8, codeExt16, codeExt32); err != nil {
+		return err
+	}
+
+	return e.wtr.WriteByte(byte(t))
+
+}
+
+// WriteExt writes an extension item.
+func (e *Encoder) WriteExt(t int8, v []byte) error {
+	if err := e.WriteExtHeader(t, len(v)); err != nil {
+		return err
+	}
+	return e.wtr.WriteBytes(v)
+}
+
+// WriteTime writes a time using the timestamp
+// extension, in the smallest of the 32-bit, 64-bit
+// and 96-bit formats which can represent it.
+func (e *Encoder) WriteTime(v time.Time) error {
+
+	s, n := v.Unix(), uint64(v.Nanosecond())
+
+	switch {
+	case uint64(s)>>32 == 0 && n == 0:
+		binary.BigEndian.PutUint32(e.tsp[:], uint32(s))
+		return e.WriteExt(TimeExt, e.tsp[:4])
+	case uint64(s)>>34 == 0:
+		binary.BigEndian.PutUint64(e.tsp[:], n<<34|uint64(s))
+		return e.WriteExt(TimeExt, e.tsp[:8])
+	}
+
+	binary.BigEndian.PutUint32(e.tsp[:], uint32(n))
+	binary.BigEndian.PutUint64(e.tsp[4:], uint64(s))
+
+	return e.WriteExt(TimeExt, e.tsp[:12])
+
+}
@@ -0,0 +1,192 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package msgpack implements the MessagePack format on
+// top of bump Readers and Writers, without any buffering
+// of its own. An Encoder and Decoder provide a token API,
+// for writing and reading one item at a time, and a value
+// API, for writing and reading whole Go values using
+// reflection. The timestamp extension is used for values
+// of type time.Time.
+package msgpack
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Format codes as defined in the MessagePack specification.
+
+const (
+	codeFixMapMin   = 0x80
+	codeFixArrayMin = 0x90
+	codeFixStrMin   = 0xa0
+	codeNil         = 0xc0
+	codeNever       = 0xc1
+	codeFalse       = 0xc2
+	codeTrue        = 0xc3
+	codeBin8        = 0xc4
+	codeBin16       = 0xc5
+	codeBin32       = 0xc6
+	codeExt8        = 0xc7
+	codeExt16       = 0xc8
+	codeExt32       = 0xc9
+	codeFloat32     = 0xca
+	codeFloat64     = 0xcb
+	codeUint8       = 0xcc
+	codeUint16      = 0xcd
+	codeUint32      = 0xce
+	codeUint64      = 0xcf
+	codeInt8        = 0xd0
+	codeInt16       = 0xd1
+	codeInt32       = 0xd2
+	codeInt64       = 0xd3
+	codeFixExt1     = 0xd4
+	codeFixExt2     = 0xd5
+	codeFixExt4     = 0xd6
+	codeFixExt8     = 0xd7
+	codeFixExt16    = 0xd8
+	codeStr8        = 0xd9
+	codeStr16       = 0xda
+	codeStr32       = 0xdb
+	codeArray16     = 0xdc
+	codeArray32     = 0xdd
+	codeMap16       = 0xde
+	codeMap32       = 0xdf
+	codeNegFixInt   = 0xe0
+)
+
+// TimeExt is the extension type of the
+// timestamp extension.
+const TimeExt = -1
+
+// maxDepth limits how deeply values can be
+// nested when decoding or skipping values.
+const maxDepth = 10000
+
+// Type represents the type of a MessagePack item.
+type Type int
+
+const (
+	InvalidType Type = iota
+	NilType
+	BoolType
+	IntType
+	UintType
+	Float32Type
+	Float64Type
+	StrType
+	BinType
+	ArrayType
+	MapType
+	ExtType
+)
+
+var typeNames = [...]string{
+	InvalidType: "invalid",
+	NilType:     "nil",
+	BoolType:    "bool",
+	IntType:     "int",
+	UintType:    "uint",
+	Float32Type: "float32",
+	Float64Type: "float64",
+	StrType:     "str",
+	BinType:     "bin",
+	ArrayType:   "array",
+	MapType:     "map",
+	ExtType:     "ext",
+}
+
+func (t Type) String() string {
+	if t >= 0 && int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+	return typeNames[InvalidType]
+}
+
+// typeOf returns the type of the item
+// which starts with the specified code.
+
+func typeOf(c byte) Type {
+	switch {
+	case c <= 0x7f:
+		return UintType
+	case c <= 0x8f:
+		return MapType
+	case c <= 0x9f:
+		return ArrayType
+	case c <= 0xbf:
+		return StrType
+	case c >= codeNegFixInt:
+		return IntType
+	}
+	switch c {
+	case codeNil:
+		return NilType
+	case codeFalse, codeTrue:
+		return BoolType
+	case codeBin8, codeBin16, codeBin32:
+		return BinType
+	case codeExt8, codeExt16, codeExt32, codeFixExt1, codeFixExt2, codeFixExt4, codeFixExt8, codeFixExt16:
+		return ExtType
+	case codeFloat32:
+		return Float32Type
+	case codeFloat64:
+		return Float64Type
+	case codeUint8, codeUint16, codeUint32, codeUint64:
+		return UintType
+	case codeInt8, codeInt16, codeInt32, codeInt64:
+		return IntType
+	case codeStr8, codeStr16, codeStr32:
+		return StrType
+	case codeArray16, codeArray32:
+		return ArrayType
+	case codeMap16, codeMap32:
+		return MapType
+	}
+	return InvalidType
+}
+
+var (
+	// ErrInvalidCode is returned when an item
+	// starts with a code which is never used.
+	ErrInvalidCode = errors.New("msgpack: invalid format code")
+	// ErrInvalidTime is returned when a timestamp
+	// extension has an invalid length or value.
+	ErrInvalidTime = errors.New("msgpack: invalid timestamp")
+	// ErrMaxDepth is returned when a value which is
+	// being decoded or skipped is nested too deeply.
+	ErrMaxDepth = errors.New("msgpack: maximum nesting depth exceeded")
+	// ErrLength is returned when a string, byte slice,
+	// array or map is too long to be written.
+	ErrLength = errors.New("msgpack: length too large")
+)
+
+// TypeError is returned when an item of one type
+// is read as, or decoded into, another type.
+type TypeError struct {
+	Want Type
+	Got  Type
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("msgpack: cannot read %s as %s", e.Got, e.Want)
+}
+
+// Ext represents an extension value
+// of an application-specific type.
+type Ext struct {
+	Type int8
+	Data []byte
+}
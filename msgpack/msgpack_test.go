@@ -0,0 +1,323 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgpack
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/surrealdb/bump"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// tokenWrite encodes items using the
+// specified function into a byte slice.
+
+func tokenWrite(fn func(e *Encoder) error) []byte {
+	var b []byte
+	if err := fn(NewEncoder(bump.NewWriterBytes(&b))); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// tokenRead returns a Decoder which reads
+// from the specified byte slice.
+
+func tokenRead(b []byte) *Decoder {
+	return NewDecoder(bump.NewReaderBytes(b))
+}
+
+func TestTokens(t *testing.T) {
+
+	Convey("Encoder should write integers in the smallest format", t, func() {
+		cases := []struct {
+			v int64
+			b []byte
+		}{
+			{0, []byte{0x00}},
+			{127, []byte{0x7f}},
+			{128, []byte{0xcc, 0x80}},
+			{256, []byte{0xcd, 0x01, 0x00}},
+			{65536, []byte{0xce, 0x00, 0x01, 0x00, 0x00}},
+			{1 << 32, []byte{0xcf, 0, 0, 0, 1, 0, 0, 0, 0}},
+			{-1, []byte{0xff}},
+			{-32, []byte{0xe0}},
+			{-33, []byte{0xd0, 0xdf}},
+			{-129, []byte{0xd1, 0xff, 0x7f}},
+			{-32769, []byte{0xd2, 0xff, 0xff, 0x7f, 0xff}},
+			{math.MinInt64, []byte{0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		}
+		for _, c := range cases {
+			b := tokenWrite(func(e *Encoder) error { return e.WriteInt(c.v) })
+			So(b, ShouldResemble, c.b)
+			v, err := tokenRead(b).ReadInt()
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, c.v)
+		}
+		b := tokenWrite(func(e *Encoder) error { return e.WriteUint(math.MaxUint64) })
+		So(b, ShouldResemble, []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	})
+
+	Convey("Decoder should read integers in any format", t, func() {
+		v, err := tokenRead([]byte{0xd3, 0, 0, 0, 0, 0, 0, 0, 5}).ReadUint()
+		So(err, ShouldBeNil)
+		So(v, ShouldEqual, 5)
+		i, err := tokenRead([]byte{0xcc, 0x05}).ReadInt()
+		So(err, ShouldBeNil)
+		So(i, ShouldEqual, 5)
+		_, err = tokenRead([]byte{0xff}).ReadUint()
+		So(err, ShouldEqual, bump.ErrOverflow)
+		_, err = tokenRead([]byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}).ReadInt()
+		So(err, ShouldEqual, bump.ErrOverflow)
+	})
+
+	Convey("Encoder should write nil, booleans and floats", t, func() {
+		b := tokenWrite(func(e *Encoder) error {
+			e.WriteNil()
+			e.WriteBool(true)
+			e.WriteBool(false)
+			e.WriteFloat32(1.5)
+			return e.WriteFloat64(1.5)
+		})
+		So(b, ShouldResemble, []byte{
+			0xc0, 0xc3, 0xc2,
+			0xca, 0x3f, 0xc0, 0x00, 0x00,
+			0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0,
+		})
+		d := tokenRead(b)
+		So(d.ReadNil(), ShouldBeNil)
+		v, _ := d.ReadBool()
+		So(v, ShouldBeTrue)
+		v, _ = d.ReadBool()
+		So(v, ShouldBeFalse)
+		f, _ := d.ReadFloat64()
+		So(f, ShouldEqual, 1.5)
+		f, _ = d.ReadFloat64()
+		So(f, ShouldEqual, 1.5)
+	})
+
+	Convey("Encoder should write strings and binary in the smallest format", t, func() {
+		cases := []struct {
+			n int
+			h []byte
+		}{
+			{0, []byte{0xa0}},
+			{31, []byte{0xbf}},
+			{32, []byte{0xd9, 32}},
+			{256, []byte{0xda, 0x01, 0x00}},
+			{65536, []byte{0xdb, 0x00, 0x01, 0x00, 0x00}},
+		}
+		for _, c := range cases {
+			s := strings.Repeat("s", c.n)
+			b := tokenWrite(func(e *Encoder) error { return e.WriteString(s) })
+			So(b[:len(c.h)], ShouldResemble, c.h)
+			So(len(b), ShouldEqual, len(c.h)+c.n)
+			v, err := tokenRead(b).ReadString()
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, s)
+		}
+		b := tokenWrite(func(e *Encoder) error { return e.WriteBytes([]byte{1, 2}) })
+		So(b, ShouldResemble, []byte{0xc4, 2, 1, 2})
+		b = tokenWrite(func(e *Encoder) error { return e.WriteBytes(make([]byte, 256)) })
+		So(b[:3], ShouldResemble, []byte{0xc5, 0x01, 0x00})
+		v, err := tokenRead(b).ReadBytes()
+		So(err, ShouldBeNil)
+		So(v, ShouldResemble, make([]byte, 256))
+	})
+
+	Convey("Decoder should read strings and binary without copying", t, func() {
+		b := tokenWrite(func(e *Encoder) error {
+			e.WriteStringBytes([]byte("abc"))
+			return e.WriteBytes([]byte("def"))
+		})
+		d := tokenRead(b)
+		s, _ := d.ReadStringBytes()
+		So(string(s), ShouldEqual, "abc")
+		v, _ := d.ReadBytes()
+		So(string(v), ShouldEqual, "def")
+		b[1], b[6] = 'A', 'D'
+		So(string(s), ShouldEqual, "Abc")
+		So(string(v), ShouldEqual, "Def")
+	})
+
+	Convey("Encoder should write array and map headers", t, func() {
+		b := tokenWrite(func(e *Encoder) error {
+			e.WriteArrayHeader(0)
+			e.WriteArrayHeader(15)
+			e.WriteArrayHeader(16)
+			e.WriteArrayHeader(65536)
+			e.WriteMapHeader(1)
+			e.WriteMapHeader(16)
+			return e.WriteMapHeader(65536)
+		})
+		So(b, ShouldResemble, []byte{
+			0x90, 0x9f, 0xdc, 0x00, 0x10, 0xdd, 0x00, 0x01, 0x00, 0x00,
+			0x81, 0xde, 0x00, 0x10, 0xdf, 0x00, 0x01, 0x00, 0x00,
+		})
+		d := tokenRead(b)
+		for _, n := range []int{0, 15, 16, 65536} {
+			v, err := d.ReadArrayHeader()
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, n)
+		}
+		for _, n := range []int{1, 16, 65536} {
+			v, err := d.ReadMapHeader()
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, n)
+		}
+	})
+
+	Convey("Encoder should write extensions in the smallest format", t, func() {
+		cases := []struct {
+			n int
+			h []byte
+		}{
+			{1, []byte{0xd4, 5}},
+			{2, []byte{0xd5, 5}},
+			{4, []byte{0xd6, 5}},
+			{8, []byte{0xd7, 5}},
+			{16, []byte{0xd8, 5}},
+			{3, []byte{0xc7, 3, 5}},
+			{256, []byte{0xc8, 0x01, 0x00, 5}},
+		}
+		for _, c := range cases {
+			x := bytes.Repeat([]byte{'x'}, c.n)
+			b := tokenWrite(func(e *Encoder) error { return e.WriteExt(5, x) })
+			So(b[:len(c.h)], ShouldResemble, c.h)
+			k, v, err := tokenRead(b).ReadExt()
+			So(err, ShouldBeNil)
+			So(k, ShouldEqual, 5)
+			So(v, ShouldResemble, x)
+		}
+	})
+
+	Convey("Encoder should write timestamps in the smallest format", t, func() {
+		cases := []struct {
+			t time.Time
+			b []byte
+		}{
+			{time.Unix(1, 0), []byte{0xd6, 0xff, 0, 0, 0, 1}},
+			{time.Unix(1, 1), []byte{0xd7, 0xff, 0, 0, 0, 0x04, 0, 0, 0, 1}},
+			{time.Unix(1<<34, 0), []byte{0xc7, 12, 0xff, 0, 0, 0, 0, 0, 0, 0, 0x04, 0, 0, 0, 0}},
+			{time.Unix(-1, 5), []byte{0xc7, 12, 0xff, 0, 0, 0, 5, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		}
+		for _, c := range cases {
+			b := tokenWrite(func(e *Encoder) error { return e.WriteTime(c.t) })
+			So(b, ShouldResemble, c.b)
+			v, err := tokenRead(b).ReadTime()
+			So(err, ShouldBeNil)
+			So(v.Equal(c.t), ShouldBeTrue)
+		}
+		_, err := tokenRead([]byte{0xd4, 0xff, 0}).ReadTime()
+		So(err, ShouldEqual, ErrInvalidTime)
+		_, err = tokenRead([]byte{0xd6, 0x01, 0, 0, 0, 1}).ReadTime()
+		So(err, ShouldEqual, ErrInvalidTime)
+	})
+
+	Convey("Decoder should report the type of the next item", t, func() {
+		b := tokenWrite(func(e *Encoder) error {
+			e.WriteNil()
+			e.WriteBool(true)
+			e.WriteInt(-1)
+			e.WriteUint(1)
+			e.WriteFloat32(1)
+			e.WriteFloat64(1)
+			e.WriteString("")
+			e.WriteBytes(nil)
+			e.WriteArrayHeader(0)
+			e.WriteMapHeader(0)
+			return e.WriteTime(time.Unix(0, 0))
+		})
+		d := tokenRead(b)
+		for _, want := range []Type{NilType, BoolType, IntType, UintType, Float32Type, Float64Type, StrType, BinType, ArrayType, MapType, ExtType} {
+			typ, err := d.PeekType()
+			So(err, ShouldBeNil)
+			So(typ, ShouldEqual, want)
+			So(d.Skip(), ShouldBeNil)
+		}
+		_, err := d.PeekType()
+		So(err, ShouldEqual, io.EOF)
+		_, err = tokenRead([]byte{0xc1}).PeekType()
+		So(err, ShouldEqual, ErrInvalidCode)
+	})
+
+	Convey("Decoder should not consume items of the wrong type", t, func() {
+		d := tokenRead([]byte{0xa1, 'a'})
+		_, err := d.ReadInt()
+		So(err, ShouldResemble, &TypeError{Want: IntType, Got: StrType})
+		So(err.Error(), ShouldEqual, "msgpack: cannot read str as int")
+		s, err := d.ReadString()
+		So(err, ShouldBeNil)
+		So(s, ShouldEqual, "a")
+	})
+
+	Convey("Decoder should skip nested items", t, func() {
+		b := tokenWrite(func(e *Encoder) error {
+			e.WriteMapHeader(2)
+			e.WriteString("a")
+			e.WriteArrayHeader(3)
+			e.WriteInt(-1000)
+			e.WriteFloat64(1)
+			e.WriteExt(1, []byte{1, 2, 3})
+			e.WriteString("b")
+			e.WriteMapHeader(1)
+			e.WriteBytes([]byte("x"))
+			e.WriteUint(math.MaxUint64)
+			return e.WriteString("after")
+		})
+		d := tokenRead(b)
+		So(d.Skip(), ShouldBeNil)
+		s, err := d.ReadString()
+		So(err, ShouldBeNil)
+		So(s, ShouldEqual, "after")
+	})
+
+	Convey("Encoder and Decoder should stream through an io.Writer", t, func() {
+		buf := bytes.NewBuffer(nil)
+		w := bump.NewWriter(buf)
+		e := NewEncoder(w)
+		for i := 0; i < 1000; i++ {
+			e.WriteArrayHeader(2)
+			e.WriteInt(int64(i))
+			e.WriteString(strings.Repeat("v", i))
+		}
+		So(w.Flush(), ShouldBeNil)
+		d := NewDecoder(bump.NewReader(buf))
+		for i := 0; i < 1000; i++ {
+			n, err := d.ReadArrayHeader()
+			So(n, ShouldEqual, 2)
+			So(err, ShouldBeNil)
+			v, _ := d.ReadInt()
+			So(v, ShouldEqual, i)
+			s, _ := d.ReadString()
+			So(len(s), ShouldEqual, i)
+		}
+	})
+
+	Convey("Decoder should return an error for truncated items", t, func() {
+		_, err := tokenRead([]byte{0xcd, 0x01}).ReadUint()
+		So(err, ShouldNotBeNil)
+		_, err = tokenRead([]byte{0xa5, 'a'}).ReadString()
+		So(err, ShouldNotBeNil)
+		So(tokenRead([]byte{0x92, 0x01}).Skip(), ShouldNotBeNil)
+	})
+
+}
@@ -0,0 +1,663 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgpack
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/surrealdb/bump"
+	"github.com/surrealdb/bump/internal/reflectx"
+)
+
+// field holds the details of a single
+// struct field which is encoded by name.
+
+type field struct {
+	idx int
+	nme string
+	omt bool
+}
+
+// fields holds the encoded fields of a
+// struct type, and an index by name.
+
+type fields struct {
+	lst []field
+	idx map[string]int
+}
+
+var (
+	structs  sync.Map
+	timeType = reflect.TypeOf(time.Time{})
+	extType  = reflect.TypeOf(Ext{})
+)
+
+// Encode writes the specified value as a single
+// item. Booleans, numbers, strings, byte slices,
+// and time.Time and Ext values are written as the
+// corresponding items. Other slices and arrays are
+// written as arrays, and maps are written as maps,
+// in key order when the keys are booleans, numbers
+// or strings. Pointers and interfaces are written
+// as the value which they refer to, or nil. Structs
+// are written as maps of field names to values.
+//
+// Fields can be renamed or omitted when empty using
+// a struct tag such as `msgpack:"name,omitempty"`,
+// and are ignored using `msgpack:"-"`.
+func (e *Encoder) Encode(v interface{}) error {
+
+	// Write common types without reflection.
+
+	switch x := v.(type) {
+	case nil:
+		return e.WriteNil()
+	case bool:
+		return e.WriteBool(x)
+	case int:
+		return e.WriteInt(int64(x))
+	case int64:
+		return e.WriteInt(x)
+	case uint64:
+		return e.WriteUint(x)
+	case float64:
+		return e.WriteFloat64(x)
+	case string:
+		return e.WriteString(x)
+	case []byte:
+		if x == nil {
+			return e.WriteNil()
+		}
+		return e.WriteBytes(x)
+	case time.Time:
+		return e.WriteTime(x)
+	}
+
+	// Otherwise write the value using reflection.
+
+	return e.encode(reflect.ValueOf(v), 0)
+
+}
+
+func (e *Encoder) encode(v reflect.Value, dep int) error {
+
+	if dep > maxDepth {
+		return ErrMaxDepth
+	}
+
+	switch v.Type() {
+	case timeType:
+		return e.WriteTime(v.Interface().(time.Time))
+	case extType:
+		x := v.Interface().(Ext)
+		return e.WriteExt(x.Type, x.Data)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return e.WriteBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.WriteInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.WriteUint(v.Uint())
+	case reflect.Float32:
+		return e.WriteFloat32(float32(v.Float()))
+	case reflect.Float64:
+		return e.WriteFloat64(v.Float())
+	case reflect.String:
+		return e.WriteString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			return e.WriteNil()
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.WriteBytes(v.Bytes())
+		}
+		return e.encodeArray(v, dep)
+	case reflect.Array:
+		return e.encodeArray(v, dep)
+	case reflect.Map:
+		if v.IsNil() {
+			return e.WriteNil()
+		}
+		return e.encodeMap(v, dep)
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return e.WriteNil()
+		}
+		return e.encode(v.Elem(), dep+1)
+	case reflect.Struct:
+		return e.encodeStruct(v, dep)
+	}
+
+	return &bump.UnsupportedTypeError{Type: v.Type()}
+
+}
+
+func (e *Encoder) encodeArray(v reflect.Value, dep int) error {
+	if err := e.WriteArrayHeader(v.Len()); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i), dep+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeMap(v reflect.Value, dep int) error {
+
+	if err := e.WriteMapHeader(v.Len()); err != nil {
+		return err
+	}
+
+	// Write the entries in key order if possible.
+
+	keys, vals := reflectx.Entries(v)
+
+	for i := range keys {
+		if err := e.encode(keys[i], dep+1); err != nil {
+			return err
+		}
+		if err := e.encode(vals[i], dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+func (e *Encoder) encodeStruct(v reflect.Value, dep int) error {
+
+	f := fieldsOf(v.Type())
+
+	// Count the fields which are not omitted.
+
+	n := 0
+	for _, x := range f.lst {
+		if !x.omt || !reflectx.IsEmpty(v.Field(x.idx)) {
+			n++
+		}
+	}
+
+	if err := e.WriteMapHeader(n); err != nil {
+		return err
+	}
+
+	// Write the name and value of each field.
+
+	for _, x := range f.lst {
+		if x.omt && reflectx.IsEmpty(v.Field(x.idx)) {
+			continue
+		}
+		if err := e.WriteString(x.nme); err != nil {
+			return err
+		}
+		if err := e.encode(v.Field(x.idx), dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// Decode reads a single item, and stores it in the
+// value which the specified pointer points to. Items
+// are converted to the type of the value where this
+// is possible, and nil items set the value to its
+// zero value. Any existing value is replaced. When
+// decoding into an empty interface, items are decoded
+// as nil, bool, int64, uint64, float32, float64,
+// string, []byte, []interface{}, time.Time and Ext
+// values, and maps are decoded as map[string]interface{}
+// if all of the keys are strings, and otherwise as
+// map[interface{}]interface{}. Unknown struct fields
+// are skipped.
+func (d *Decoder) Decode(v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return bump.ErrInvalidDecode
+	}
+
+	return d.decode(rv.Elem(), 0)
+
+}
+
+// DecodeValue reads a single item, and returns it
+// as one of the types used when decoding into an
+// empty interface.
+func (d *Decoder) DecodeValue() (interface{}, error) {
+	return d.value(0)
+}
+
+func (d *Decoder) decode(v reflect.Value, dep int) error {
+
+	if dep > maxDepth {
+		return ErrMaxDepth
+	}
+
+	t, err := d.PeekType()
+	if err != nil {
+		return err
+	}
+
+	// Set the zero value for nil items.
+
+	if t == NilType {
+		v.Set(reflect.Zero(v.Type()))
+		return d.ReadNil()
+	}
+
+	switch v.Type() {
+	case timeType:
+		x, err := d.ReadTime()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x))
+		return nil
+	case extType:
+		x, b, err := d.ReadExt()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(Ext{Type: x, Data: append([]byte(nil), b...)}))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		x, err := d.ReadBool()
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := d.ReadInt()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(x) {
+			return bump.ErrOverflow
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, err := d.ReadUint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(x) {
+			return bump.ErrOverflow
+		}
+		v.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := d.ReadFloat64()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	case reflect.String:
+		b, err := d.readBytes(t)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.readBytes(t)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append(make([]byte, 0, len(b)), b...))
+			return nil
+		}
+		return d.decodeSlice(v, dep)
+	case reflect.Array:
+		return d.decodeArray(v, dep)
+	case reflect.Map:
+		return d.decodeMap(v, dep)
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := d.decode(p.Elem(), dep+1); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return &bump.UnsupportedTypeError{Type: v.Type()}
+		}
+		x, err := d.value(dep)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&x).Elem())
+	case reflect.Struct:
+		return d.decodeStruct(v, dep)
+	default:
+		return &bump.UnsupportedTypeError{Type: v.Type()}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// readBytes reads a string or binary item as a
+// byte slice, which may refer to the underlying
+// byte slice being read from.
+
+func (d *Decoder) readBytes(t Type) ([]byte, error) {
+	if t == BinType {
+		return d.ReadBytes()
+	}
+	return d.ReadStringBytes()
+}
+
+func (d *Decoder) decodeSlice(v reflect.Value, dep int) error {
+
+	n, err := d.ReadArrayHeader()
+	if err != nil {
+		return err
+	}
+
+	s := reflect.MakeSlice(v.Type(), 0, reflectx.InitialSize(n))
+	z := reflect.Zero(v.Type().Elem())
+
+	for i := 0; i < n; i++ {
+		s = reflect.Append(s, z)
+		if err := d.decode(s.Index(i), dep+1); err != nil {
+			return err
+		}
+	}
+
+	v.Set(s)
+
+	return nil
+
+}
+
+func (d *Decoder) decodeArray(v reflect.Value, dep int) error {
+
+	n, err := d.ReadArrayHeader()
+	if err != nil {
+		return err
+	}
+
+	// Decode as many items as fit in the array,
+	// and skip any items which do not fit.
+
+	for i := 0; i < n; i++ {
+		if i >= v.Len() {
+			if err := d.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(v.Index(i), dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Zero any elements which were not decoded.
+
+	for i := n; i < v.Len(); i++ {
+		v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+	}
+
+	return nil
+
+}
+
+func (d *Decoder) decodeMap(v reflect.Value, dep int) error {
+
+	n, err := d.ReadMapHeader()
+	if err != nil {
+		return err
+	}
+
+	t := v.Type()
+	m := reflect.MakeMapWithSize(t, reflectx.InitialSize(n))
+
+	for i := 0; i < n; i++ {
+		k := reflect.New(t.Key()).Elem()
+		if err := d.decode(k, dep+1); err != nil {
+			return err
+		}
+		e := reflect.New(t.Elem()).Elem()
+		if err := d.decode(e, dep+1); err != nil {
+			return err
+		}
+		m.SetMapIndex(k, e)
+	}
+
+	v.Set(m)
+
+	return nil
+
+}
+
+func (d *Decoder) decodeStruct(v reflect.Value, dep int) error {
+
+	n, err := d.ReadMapHeader()
+	if err != nil {
+		return err
+	}
+
+	// Replace any existing field values.
+
+	f := fieldsOf(v.Type())
+
+	v.Set(reflect.Zero(v.Type()))
+
+	// Decode each known field, by name.
+
+	for i := 0; i < n; i++ {
+		k, err := d.ReadStringBytes()
+		if err != nil {
+			return err
+		}
+		x, ok := f.idx[string(k)]
+		if !ok {
+			if err := d.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(v.Field(x), dep+1); err != nil {
+			return err
+		}
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// value reads a single item as one of the
+// types used for empty interfaces.
+
+func (d *Decoder) value(dep int) (interface{}, error) {
+
+	if dep > maxDepth {
+		return nil, ErrMaxDepth
+	}
+
+	t, err := d.PeekType()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case NilType:
+		return nil, d.ReadNil()
+	case BoolType:
+		return d.ReadBool()
+	case IntType:
+		return d.ReadInt()
+	case UintType:
+		return d.ReadUint()
+	case Float32Type:
+		return d.ReadFloat32()
+	case Float64Type:
+		return d.ReadFloat64()
+	case StrType:
+		return d.ReadString()
+	case BinType:
+		b, err := d.ReadBytes()
+		if err != nil {
+			return nil, err
+		}
+		return append(make([]byte, 0, len(b)), b...), nil
+	case ExtType:
+		x, b, err := d.ReadExt()
+		if err != nil {
+			return nil, err
+		}
+		if x == TimeExt {
+			return decodeTime(b)
+		}
+		return Ext{Type: x, Data: append([]byte(nil), b...)}, nil
+	case ArrayType:
+		n, err := d.ReadArrayHeader()
+		if err != nil {
+			return nil, err
+		}
+		a := make([]interface{}, 0, reflectx.InitialSize(n))
+		for i := 0; i < n; i++ {
+			x, err := d.value(dep + 1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, x)
+		}
+		return a, nil
+	}
+
+	return d.valueMap(dep)
+
+}
+
+// valueMap reads a map item as a map with string
+// keys, switching to a map with interface keys if
+// any of the keys are not strings.
+
+func (d *Decoder) valueMap(dep int) (interface{}, error) {
+
+	n, err := d.ReadMapHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{}, reflectx.InitialSize(n))
+
+	var a map[interface{}]interface{}
+
+	for i := 0; i < n; i++ {
+
+		// Ensure that the key can be used in a map.
+
+		t, err := d.PeekType()
+		if err != nil {
+			return nil, err
+		}
+
+		if t == ArrayType || t == MapType {
+			return nil, &TypeError{Want: StrType, Got: t}
+		}
+
+		k, err := d.value(dep + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch x := k.(type) {
+		case []byte:
+			k = string(x)
+		case Ext:
+			return nil, &TypeError{Want: StrType, Got: ExtType}
+		}
+
+		e, err := d.value(dep + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		// Switch to interface keys if needed.
+
+		s, ok := k.(string)
+
+		if ok && a == nil {
+			m[s] = e
+			continue
+		}
+
+		if a == nil {
+			a = make(map[interface{}]interface{}, reflectx.InitialSize(n))
+			for x, y := range m {
+				a[x] = y
+			}
+		}
+
+		a[k] = e
+
+	}
+
+	if a != nil {
+		return a, nil
+	}
+
+	return m, nil
+
+}
+
+// fieldsOf returns the cached encoded
+// fields of a struct type.
+
+func fieldsOf(t reflect.Type) *fields {
+
+	if f, ok := structs.Load(t); ok {
+		return f.(*fields)
+	}
+
+	f := &fields{idx: make(map[string]int)}
+
+	for _, x := range reflectx.Fields(t, "msgpack") {
+		f.idx[x.Name] = x.Index
+		f.lst = append(f.lst, field{
+			idx: x.Index,
+			nme: x.Name,
+			omt: x.OmitEmpty,
+		})
+	}
+
+	// Everything went ok.
+
+	structs.Store(t, f)
+
+	return f
+
+}
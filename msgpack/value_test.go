@@ -0,0 +1,208 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgpack
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/surrealdb/bump"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type valueInner struct {
+	Name string
+	Tags []string `msgpack:"tags,omitempty"`
+}
+
+type valueRecord struct {
+	ID      uint64 `msgpack:"id"`
+	Active  bool
+	Count   int16
+	Ratio   float32
+	Score   float64
+	Data    []byte
+	Items   []valueInner
+	Lookup  map[string]int
+	Pointer *valueInner
+	Missing *valueInner
+	Array   [3]uint8
+	When    time.Time
+	Ext     Ext
+	Any     interface{}
+	Ignored string `msgpack:"-"`
+	hidden  string
+}
+
+func valueSample() *valueRecord {
+	return &valueRecord{
+		ID:      math.MaxUint64,
+		Active:  true,
+		Count:   -300,
+		Ratio:   0.5,
+		Score:   -1.25,
+		Data:    []byte("data"),
+		Items:   []valueInner{{Name: "first", Tags: []string{"a"}}, {Name: "second"}},
+		Lookup:  map[string]int{"one": 1, "two": 2, "three": 3},
+		Pointer: &valueInner{Name: "pointer"},
+		Array:   [3]uint8{1, 2, 3},
+		When:    time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Ext:     Ext{Type: 7, Data: []byte{1, 2, 3}},
+		Any:     "any",
+	}
+}
+
+func TestValues(t *testing.T) {
+
+	Convey("Encode should round trip a struct through a byte slice", t, func() {
+		var b []byte
+		v := valueSample()
+		So(NewEncoder(bump.NewWriterBytes(&b)).Encode(v), ShouldBeNil)
+		var o valueRecord
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&o), ShouldBeNil)
+		So(o.When.Equal(v.When), ShouldBeTrue)
+		o.When = v.When
+		So(o, ShouldResemble, *v)
+	})
+
+	Convey("Encode should round trip values through an io.Writer", t, func() {
+		buf := bytes.NewBuffer(nil)
+		w := bump.NewWriter(buf)
+		e := NewEncoder(w)
+		for i := 0; i < 100; i++ {
+			So(e.Encode(valueSample()), ShouldBeNil)
+		}
+		So(w.Flush(), ShouldBeNil)
+		d := NewDecoder(bump.NewReader(buf))
+		for i := 0; i < 100; i++ {
+			var o valueRecord
+			So(d.Decode(&o), ShouldBeNil)
+			So(o.Lookup, ShouldResemble, valueSample().Lookup)
+		}
+	})
+
+	Convey("Encode should write structs as maps of field names", t, func() {
+		var b []byte
+		v := valueInner{Name: "x"}
+		NewEncoder(bump.NewWriterBytes(&b)).Encode(v)
+		So(b, ShouldResemble, []byte{0x81, 0xa4, 'N', 'a', 'm', 'e', 0xa1, 'x'})
+		v.Tags = []string{}
+		b = b[:0]
+		NewEncoder(bump.NewWriterBytes(&b)).Encode(v)
+		So(b, ShouldResemble, []byte{0x81, 0xa4, 'N', 'a', 'm', 'e', 0xa1, 'x'})
+	})
+
+	Convey("Encode should write maps in key order", t, func() {
+		var b []byte
+		NewEncoder(bump.NewWriterBytes(&b)).Encode(map[int]bool{3: true, 1: false, 2: true})
+		So(b, ShouldResemble, []byte{0x83, 1, 0xc2, 2, 0xc3, 3, 0xc3})
+	})
+
+	Convey("DecodeValue should decode generic values", t, func() {
+		var b []byte
+		e := NewEncoder(bump.NewWriterBytes(&b))
+		e.Encode(map[string]interface{}{
+			"nil":   nil,
+			"bool":  true,
+			"int":   -5,
+			"uint":  5,
+			"f32":   float32(1.5),
+			"f64":   2.5,
+			"str":   "s",
+			"bin":   []byte("b"),
+			"array": []interface{}{1, "two"},
+			"map":   map[int]string{1: "one"},
+			"ext":   Ext{Type: 1, Data: []byte{1}},
+			"time":  time.Unix(1, 2),
+		})
+		v, err := NewDecoder(bump.NewReaderBytes(b)).DecodeValue()
+		So(err, ShouldBeNil)
+		m := v.(map[string]interface{})
+		So(m["nil"], ShouldBeNil)
+		So(m["bool"], ShouldEqual, true)
+		So(m["int"], ShouldEqual, int64(-5))
+		So(m["uint"], ShouldEqual, uint64(5))
+		So(m["f32"], ShouldEqual, float32(1.5))
+		So(m["f64"], ShouldEqual, 2.5)
+		So(m["str"], ShouldEqual, "s")
+		So(m["bin"], ShouldResemble, []byte("b"))
+		So(m["array"], ShouldResemble, []interface{}{uint64(1), "two"})
+		So(m["map"], ShouldResemble, map[interface{}]interface{}{uint64(1): "one"})
+		So(m["ext"], ShouldResemble, Ext{Type: 1, Data: []byte{1}})
+		So(m["time"].(time.Time).Equal(time.Unix(1, 2)), ShouldBeTrue)
+	})
+
+	Convey("Decode should convert items to the type of the value", t, func() {
+		var b []byte
+		e := NewEncoder(bump.NewWriterBytes(&b))
+		e.Encode("str")
+		e.Encode([]byte("bin"))
+		e.Encode(nil)
+		e.Encode([]int{1, 2, 3, 4})
+		d := NewDecoder(bump.NewReaderBytes(b))
+		var x []byte
+		So(d.Decode(&x), ShouldBeNil)
+		So(x, ShouldResemble, []byte("str"))
+		var s string
+		So(d.Decode(&s), ShouldBeNil)
+		So(s, ShouldEqual, "bin")
+		p := &valueInner{}
+		So(d.Decode(&p), ShouldBeNil)
+		So(p, ShouldBeNil)
+		var a [2]int
+		So(d.Decode(&a), ShouldBeNil)
+		So(a, ShouldResemble, [2]int{1, 2})
+	})
+
+	Convey("Decode should skip unknown struct fields", t, func() {
+		var b []byte
+		NewEncoder(bump.NewWriterBytes(&b)).Encode(map[string]interface{}{
+			"Name":    "x",
+			"Unknown": []interface{}{map[string]int{"a": 1}},
+		})
+		o := valueInner{Tags: []string{"old"}}
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&o), ShouldBeNil)
+		So(o, ShouldResemble, valueInner{Name: "x"})
+	})
+
+	Convey("Decode should return errors for invalid values", t, func() {
+		var b []byte
+		NewEncoder(bump.NewWriterBytes(&b)).Encode(1000)
+		var i int8
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&i), ShouldEqual, bump.ErrOverflow)
+		var s string
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&s), ShouldResemble, &TypeError{Want: StrType, Got: UintType})
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(s), ShouldEqual, bump.ErrInvalidDecode)
+		var c chan int
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&c), ShouldHaveSameTypeAs, &bump.UnsupportedTypeError{})
+	})
+
+	Convey("Decode should limit the nesting depth", t, func() {
+		b := bytes.Repeat([]byte{0x91}, maxDepth+2)
+		var v interface{}
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&v), ShouldEqual, ErrMaxDepth)
+		So(NewDecoder(bump.NewReaderBytes(b)).Skip(), ShouldNotEqual, ErrMaxDepth)
+	})
+
+	Convey("Decode should not allocate for large corrupt counts", t, func() {
+		b := []byte{0xdd, 0xff, 0xff, 0xff, 0xff}
+		var v []int
+		So(NewDecoder(bump.NewReaderBytes(b)).Decode(&v), ShouldNotBeNil)
+	})
+
+}
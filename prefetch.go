@@ -0,0 +1,167 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"io"
+	"sync"
+)
+
+const prefetchSize = 32 * readerSize
+
+// prefetch represents an io.Reader which reads
+// ahead from an underlying io.Reader into a
+// bounded number of buffers, using a
+// background goroutine.
+
+type prefetch struct {
+	rdr io.Reader
+	num int
+	cur []byte
+	off int
+	err error
+	ful chan prefetchChunk
+	emp chan []byte
+	stp chan struct{}
+	don chan struct{}
+	one sync.Once
+}
+
+type prefetchChunk struct {
+	b   []byte
+	err error
+}
+
+// NewReaderPrefetch creates a new Reader which
+// reads from an underlying io.Reader, while a
+// background goroutine reads ahead into up to
+// k buffers. Any error from the io.Reader is
+// returned only once all of the data which was
+// read before the error has been consumed. The
+// background goroutine is stopped by Reset,
+// ResetBytes, or Close, each of which waits for
+// any read which is in progress to return, so
+// the io.Reader is never read concurrently.
+func NewReaderPrefetch(r io.Reader, k int) *Reader {
+	p := newPrefetch(r, k)
+	return &Reader{rdr: p, src: r, pre: p}
+}
+
+func newPrefetch(r io.Reader, k int) *prefetch {
+
+	if k < 1 {
+		k = 1
+	}
+
+	p := &prefetch{
+		rdr: r,
+		num: k,
+		ful: make(chan prefetchChunk, k),
+		emp: make(chan []byte, k),
+		stp: make(chan struct{}),
+		don: make(chan struct{}),
+	}
+
+	for i := 0; i < k; i++ {
+		p.emp <- make([]byte, prefetchSize)
+	}
+
+	go p.run()
+
+	return p
+
+}
+
+func (p *prefetch) run() {
+
+	defer close(p.don)
+
+	for {
+
+		var b []byte
+
+		// Wait for an empty buffer to fill.
+
+		select {
+		case b = <-p.emp:
+		case <-p.stp:
+			return
+		}
+
+		// Read the next data into the buffer.
+
+		n, err := p.rdr.Read(b)
+
+		// Hand the buffer to the reader.
+
+		select {
+		case p.ful <- prefetchChunk{b[:n], err}:
+		case <-p.stp:
+			return
+		}
+
+		// Stop once the io.Reader has failed.
+
+		if err != nil {
+			return
+		}
+
+	}
+
+}
+
+func (p *prefetch) Read(b []byte) (int, error) {
+
+	// Move to the next buffer if needed.
+
+	for p.off >= len(p.cur) {
+
+		// Return the error where it occurred.
+
+		if p.err != nil {
+			return 0, p.err
+		}
+
+		// Return the consumed buffer for filling.
+
+		if p.cur != nil {
+			p.emp <- p.cur[:cap(p.cur)]
+		}
+
+		c := <-p.ful
+		p.cur, p.off, p.err = c.b, 0, c.err
+
+	}
+
+	// Copy the data from the current buffer.
+
+	n := copy(b, p.cur[p.off:])
+	p.off += n
+
+	// Everything went ok.
+
+	return n, nil
+
+}
+
+func (p *prefetch) stop() {
+	p.one.Do(func() {
+		close(p.stp)
+	})
+}
+
+func (p *prefetch) wait() {
+	<-p.don
+}
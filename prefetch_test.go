@@ -0,0 +1,160 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// countReader counts the number of
+// calls made to the underlying Read.
+
+type countReader struct {
+	r     io.Reader
+	calls int32
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if len(p) > 100 {
+		p = p[:100]
+	}
+	return c.r.Read(p)
+}
+
+// blockReader blocks every read
+// until it has been closed.
+
+type blockReader struct {
+	done chan struct{}
+}
+
+func (b *blockReader) Read(p []byte) (int, error) {
+	<-b.done
+	return 0, io.ErrClosedPipe
+}
+
+func (b *blockReader) Close() error {
+	close(b.done)
+	return nil
+}
+
+// serialReader counts the reads of an underlying
+// io.Reader which overlap with another read.
+
+type serialReader struct {
+	r       io.Reader
+	active  int32
+	overlap int32
+}
+
+func (s *serialReader) Read(p []byte) (int, error) {
+	if atomic.AddInt32(&s.active, 1) > 1 {
+		atomic.AddInt32(&s.overlap, 1)
+	}
+	defer atomic.AddInt32(&s.active, -1)
+	time.Sleep(time.Millisecond)
+	return s.r.Read(p)
+}
+
+func TestPrefetch(t *testing.T) {
+
+	Convey("Prefetching Reader should read all data in order", t, func() {
+		r := NewReaderPrefetch(bytes.NewReader(big), 2)
+		o := chunkReadBytes(r, len(big))
+		So(o, ShouldResemble, big)
+		So(r.Close(), ShouldBeNil)
+	})
+
+	Convey("Prefetching Reader should read all data in order (as a string)", t, func() {
+		r := NewReaderPrefetch(bytes.NewReader(big), 4)
+		o := chunkReadString(r, len(big))
+		So(o, ShouldEqual, string(big))
+	})
+
+	Convey("Prefetching Reader should read ahead a bounded number of buffers", t, func() {
+		c := &countReader{r: bytes.NewReader(big)}
+		r := NewReaderPrefetch(c, 3)
+		b, _ := r.ReadByte()
+		So(b, ShouldEqual, big[0])
+		time.Sleep(20 * time.Millisecond)
+		So(atomic.LoadInt32(&c.calls), ShouldBeLessThanOrEqualTo, 4)
+		So(atomic.LoadInt32(&c.calls), ShouldBeGreaterThanOrEqualTo, 3)
+		r.ResetBytes(nil)
+	})
+
+	Convey("Prefetching Reader should return errors at the position where they occurred", t, func() {
+		e := errors.New("connection reset")
+		r := NewReaderPrefetch(&errReader{bytes.NewReader(big), e}, 2)
+		o, err := r.ReadBytes(len(big))
+		So(err, ShouldBeNil)
+		So(o, ShouldResemble, big)
+		_, err = r.ReadByte()
+		So(err, ShouldEqual, e)
+		So(r.Err(), ShouldEqual, e)
+	})
+
+	Convey("Prefetching Reader should cancel the background goroutine on Close", t, func() {
+		b := &blockReader{done: make(chan struct{})}
+		r := NewReaderPrefetch(b, 2)
+		p := r.pre
+		So(r.Close(), ShouldBeNil)
+		select {
+		case <-p.don:
+		case <-time.After(time.Second):
+			So("background goroutine", ShouldEqual, "stopped")
+		}
+		_, err := r.ReadByte()
+		So(err, ShouldEqual, ErrClosed)
+	})
+
+	Convey("Prefetching Reader should restart prefetching on Reset", t, func() {
+		r := NewReaderPrefetch(bytes.NewReader(big), 2)
+		for i := 0; i < 5; i++ {
+			o, err := r.ReadBytes(100)
+			So(err, ShouldBeNil)
+			So(o, ShouldResemble, big[:100])
+			p := r.pre
+			So(r.Reset(bytes.NewReader(big)), ShouldBeNil)
+			select {
+			case <-p.don:
+			case <-time.After(time.Second):
+				So("background goroutine", ShouldEqual, "stopped")
+			}
+		}
+		o := chunkReadBytes(r, len(big))
+		So(o, ShouldResemble, big)
+	})
+
+	Convey("Prefetching Reader should not read concurrently when reset on the same source", t, func() {
+		s := &serialReader{r: bytes.NewReader(bytes.Repeat(big, 50))}
+		r := NewReaderPrefetch(s, 2)
+		for i := 0; i < 20; i++ {
+			_, err := r.ReadBytes(10)
+			So(err, ShouldBeNil)
+			So(r.Reset(s), ShouldBeNil)
+		}
+		So(r.Close(), ShouldBeNil)
+		So(atomic.LoadInt32(&s.overlap), ShouldEqual, 0)
+	})
+
+}
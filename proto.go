@@ -0,0 +1,248 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// WireType specifies how the value of a
+// Protocol Buffers field is encoded.
+type WireType int
+
+const (
+	// WireVarint is used for int32, int64, uint32,
+	// uint64, sint32, sint64, bool and enum fields.
+	WireVarint WireType = 0
+	// WireFixed64 is used for fixed64, sfixed64
+	// and double fields.
+	WireFixed64 WireType = 1
+	// WireBytes is used for string, bytes, embedded
+	// message and packed repeated fields.
+	WireBytes WireType = 2
+	// WireStartGroup starts a deprecated group.
+	WireStartGroup WireType = 3
+	// WireEndGroup ends a deprecated group.
+	WireEndGroup WireType = 4
+	// WireFixed32 is used for fixed32, sfixed32
+	// and float fields.
+	WireFixed32 WireType = 5
+)
+
+// maxFieldNumber is the largest valid
+// Protocol Buffers field number.
+const maxFieldNumber = 1<<29 - 1
+
+// WriteTag writes the tag of a Protocol Buffers
+// field, which must be followed by a value of the
+// specified wire type. Returns ErrInvalidTag if the
+// field number or wire type is not valid. Values of
+// sint32 and sint64 fields use the same zig-zag
+// encoding as WriteVarint, and values of int32,
+// int64, uint32, uint64, bool and enum fields are
+// written using WriteUvarint, with negative numbers
+// converted to uint64.
+func (w *Writer) WriteTag(field int, t WireType) error {
+	if field < 1 || field > maxFieldNumber || t < WireVarint || t > WireFixed32 {
+		return ErrInvalidTag
+	}
+	return w.WriteUvarint(uint64(field)<<3 | uint64(t))
+}
+
+// WriteFixed32 writes an unsigned 32-bit integer
+// in little-endian byte order, as used by fixed32,
+// sfixed32 and float fields.
+func (w *Writer) WriteFixed32(v uint32) error {
+	binary.LittleEndian.PutUint32(w.tmp[:], v)
+	return w.WriteBytes(w.tmp[:4])
+}
+
+// WriteFixed64 writes an unsigned 64-bit integer
+// in little-endian byte order, as used by fixed64,
+// sfixed64 and double fields.
+func (w *Writer) WriteFixed64(v uint64) error {
+	binary.LittleEndian.PutUint64(w.tmp[:], v)
+	return w.WriteBytes(w.tmp[:8])
+}
+
+// BeginMessage writes the tag of a length-delimited
+// field, and begins a scope for its value. This is
+// used for embedded messages, and for packed repeated
+// fields, where the values are written one after
+// another without tags. The field number must be
+// valid, as it is not checked.
+func (w *Writer) BeginMessage(field int) Scope {
+	w.WriteUvarint(uint64(field)<<3 | uint64(WireBytes))
+	return w.BeginScope(PrefixUvarint)
+}
+
+// ReadTag reads the tag of a Protocol Buffers field,
+// and returns the field number and wire type. Returns
+// ErrInvalidTag if the field number or wire type is
+// not valid.
+func (r *Reader) ReadTag() (int, WireType, error) {
+	v, err := r.ReadUvarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	f, t := v>>3, WireType(v&7)
+	if f < 1 || f > maxFieldNumber || t > WireFixed32 {
+		return 0, 0, ErrInvalidTag
+	}
+	return int(f), t, nil
+}
+
+// ReadFixed32 reads an unsigned 32-bit
+// integer in little-endian byte order.
+func (r *Reader) ReadFixed32() (uint32, error) {
+	b, err := r.readFixed(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// ReadFixed64 reads an unsigned 64-bit
+// integer in little-endian byte order.
+func (r *Reader) ReadFixed64() (uint64, error) {
+	b, err := r.readFixed(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// ReadMessage reads the value of a length-delimited
+// field, after its tag has been read. The function is
+// called with a section Reader which is limited to the
+// value, and which returns io.EOF at its end. Any part
+// of the value which is not read is skipped. The
+// section Reader must not be retained after the
+// function returns.
+func (r *Reader) ReadMessage(fn func(m *Reader) error) error {
+
+	l, err := r.ReadLength()
+	if err != nil {
+		return err
+	}
+
+	// Read the value within its own section.
+
+	c := r.limit(limits.Get().(*Reader), l)
+	err = fn(c)
+	if e := c.Close(); err == nil {
+		err = e
+	}
+	limits.Put(c)
+
+	return err
+
+}
+
+// ReadPacked reads the value of a packed repeated
+// field, after its tag has been read. The function
+// is called once for each element, with a section
+// Reader from which it must read one element,
+// otherwise io.ErrNoProgress is returned.
+func (r *Reader) ReadPacked(fn func(m *Reader) error) error {
+	return r.ReadMessage(func(m *Reader) error {
+		for m.rem > 0 {
+			n := m.rem
+			if err := fn(m); err != nil {
+				return err
+			}
+			if m.rem == n {
+				return io.ErrNoProgress
+			}
+		}
+		return nil
+	})
+}
+
+// SkipField skips the value of a field with the
+// specified field number and wire type, after its
+// tag has been read, without allocating. Groups
+// are skipped along with all of the fields within
+// them. Returns ErrInvalidTag for an unexpected end
+// group tag, or for an end group tag with a field
+// number which does not match its start group tag.
+func (r *Reader) SkipField(f int, t WireType) error {
+
+	switch t {
+	case WireVarint:
+		_, err := r.ReadUvarint()
+		return err
+	case WireFixed64:
+		_, err := r.Discard(8)
+		return err
+	case WireFixed32:
+		_, err := r.Discard(4)
+		return err
+	case WireBytes:
+		l, err := r.ReadLength()
+		if err != nil {
+			return err
+		}
+		_, err = r.Discard(l)
+		return err
+	case WireStartGroup:
+		return r.skipGroup(f)
+	}
+
+	return ErrInvalidTag
+
+}
+
+// skipGroup skips the fields within a group,
+// up to and including the matching end group
+// tag, without recursion. The field numbers of
+// the open groups are kept on a stack, so that
+// each end group tag can be checked.
+
+func (r *Reader) skipGroup(f int) error {
+
+	var arr [16]int
+
+	stk := append(arr[:0], f)
+
+	for len(stk) > 0 {
+
+		f, t, err := r.ReadTag()
+		if err != nil {
+			return err
+		}
+
+		switch t {
+		case WireStartGroup:
+			stk = append(stk, f)
+		case WireEndGroup:
+			if f != stk[len(stk)-1] {
+				return ErrInvalidTag
+			}
+			stk = stk[:len(stk)-1]
+		default:
+			if err := r.SkipField(f, t); err != nil {
+				return err
+			}
+		}
+
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
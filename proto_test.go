@@ -0,0 +1,225 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// protoWrite writes a message with a varint, a string,
+// an embedded message, a packed repeated field and
+// fixed size fields, as in the protobuf encoding guide.
+
+func protoWrite(w *Writer) {
+	w.WriteTag(1, WireVarint)
+	w.WriteUvarint(150)
+	w.WriteTag(2, WireBytes)
+	w.WriteUvarint(7)
+	w.WriteString("testing")
+	s := w.BeginMessage(3)
+	w.WriteTag(1, WireVarint)
+	w.WriteUvarint(150)
+	s.End()
+	s = w.BeginMessage(4)
+	for _, v := range []uint64{3, 270, 86942} {
+		w.WriteUvarint(v)
+	}
+	s.End()
+	w.WriteTag(5, WireFixed32)
+	w.WriteFixed32(math.Float32bits(1.5))
+	w.WriteTag(6, WireFixed64)
+	w.WriteFixed64(math.MaxUint64 - 1)
+	w.WriteTag(7, WireVarint)
+	w.WriteVarint(-2)
+}
+
+var protoBytes = []byte{
+	0x08, 0x96, 0x01,
+	0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g',
+	0x1a, 0x03, 0x08, 0x96, 0x01,
+	0x22, 0x06, 0x03, 0x8e, 0x02, 0x9e, 0xa7, 0x05,
+	0x2d, 0x00, 0x00, 0xc0, 0x3f,
+	0x31, 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x38, 0x03,
+}
+
+func TestProto(t *testing.T) {
+
+	Convey("Writer should write the protobuf wire format", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		protoWrite(w)
+		So(b, ShouldResemble, protoBytes)
+		buf := bytes.NewBuffer(nil)
+		w = NewWriter(buf)
+		protoWrite(w)
+		So(w.Flush(), ShouldBeNil)
+		So(buf.Bytes(), ShouldResemble, protoBytes)
+	})
+
+	Convey("Reader should read the protobuf wire format", t, func() {
+		for _, r := range []*Reader{NewReaderBytes(protoBytes), NewReader(bytes.NewReader(protoBytes))} {
+			f, k, err := r.ReadTag()
+			So(err, ShouldBeNil)
+			So(f, ShouldEqual, 1)
+			So(k, ShouldEqual, WireVarint)
+			v, _ := r.ReadUvarint()
+			So(v, ShouldEqual, 150)
+			f, k, _ = r.ReadTag()
+			So(f, ShouldEqual, 2)
+			So(k, ShouldEqual, WireBytes)
+			So(r.ReadMessage(func(m *Reader) error {
+				s, err := m.ReadString(7)
+				So(s, ShouldEqual, "testing")
+				return err
+			}), ShouldBeNil)
+			r.ReadTag()
+			So(r.ReadMessage(func(m *Reader) error {
+				f, _, _ := m.ReadTag()
+				So(f, ShouldEqual, 1)
+				v, _ := m.ReadUvarint()
+				So(v, ShouldEqual, 150)
+				_, _, err := m.ReadTag()
+				So(err, ShouldEqual, io.EOF)
+				return nil
+			}), ShouldBeNil)
+			r.ReadTag()
+			var p []uint64
+			So(r.ReadPacked(func(m *Reader) error {
+				v, err := m.ReadUvarint()
+				p = append(p, v)
+				return err
+			}), ShouldBeNil)
+			So(p, ShouldResemble, []uint64{3, 270, 86942})
+			f, k, _ = r.ReadTag()
+			So(f, ShouldEqual, 5)
+			So(k, ShouldEqual, WireFixed32)
+			x, _ := r.ReadFixed32()
+			So(math.Float32frombits(x), ShouldEqual, 1.5)
+			f, k, _ = r.ReadTag()
+			So(f, ShouldEqual, 6)
+			So(k, ShouldEqual, WireFixed64)
+			y, _ := r.ReadFixed64()
+			So(y, ShouldEqual, uint64(math.MaxUint64-1))
+			r.ReadTag()
+			z, _ := r.ReadVarint()
+			So(z, ShouldEqual, -2)
+			_, _, err = r.ReadTag()
+			So(err, ShouldEqual, io.EOF)
+		}
+	})
+
+	Convey("Reader should skip fields of any wire type", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		protoWrite(w)
+		w.WriteTag(8, WireStartGroup)
+		w.WriteTag(1, WireFixed32)
+		w.WriteFixed32(1)
+		w.WriteTag(2, WireStartGroup)
+		w.WriteTag(2, WireEndGroup)
+		w.WriteTag(8, WireEndGroup)
+		w.WriteTag(9, WireVarint)
+		w.WriteUvarint(9)
+		for _, r := range []*Reader{NewReaderBytes(b), NewReader(bytes.NewReader(b))} {
+			var f int
+			for f != 9 {
+				var k WireType
+				var err error
+				f, k, err = r.ReadTag()
+				So(err, ShouldBeNil)
+				if f != 9 {
+					So(r.SkipField(f, k), ShouldBeNil)
+				}
+			}
+			v, _ := r.ReadUvarint()
+			So(v, ShouldEqual, 9)
+		}
+	})
+
+	Convey("SkipField should check the field numbers of end group tags", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.WriteTag(3, WireStartGroup)
+		w.WriteTag(4, WireEndGroup)
+		r := NewReaderBytes(b)
+		f, k, _ := r.ReadTag()
+		So(r.SkipField(f, k), ShouldEqual, ErrInvalidTag)
+		b = b[:0]
+		w.ResetBytes(&b)
+		w.WriteTag(3, WireStartGroup)
+		for i := 1; i <= 20; i++ {
+			w.WriteTag(i, WireStartGroup)
+		}
+		for i := 20; i >= 1; i-- {
+			w.WriteTag(i, WireEndGroup)
+		}
+		w.WriteTag(3, WireEndGroup)
+		r = NewReaderBytes(b)
+		f, k, _ = r.ReadTag()
+		So(r.SkipField(f, k), ShouldBeNil)
+		_, err := r.ReadByte()
+		So(err, ShouldEqual, io.EOF)
+		b[len(b)-3] += 8
+		r = NewReaderBytes(b)
+		f, k, _ = r.ReadTag()
+		So(r.SkipField(f, k), ShouldEqual, ErrInvalidTag)
+	})
+
+	Convey("SkipField should not allocate", t, func() {
+		r := NewReaderBytes(nil)
+		n := testing.AllocsPerRun(100, func() {
+			r.ResetBytes(protoBytes)
+			for {
+				f, k, err := r.ReadTag()
+				if err != nil {
+					break
+				}
+				r.SkipField(f, k)
+			}
+		})
+		So(n, ShouldEqual, 0)
+	})
+
+	Convey("Tags should be checked for valid field numbers and wire types", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		So(w.WriteTag(0, WireVarint), ShouldEqual, ErrInvalidTag)
+		So(w.WriteTag(1<<29, WireVarint), ShouldEqual, ErrInvalidTag)
+		So(w.WriteTag(1, WireType(6)), ShouldEqual, ErrInvalidTag)
+		So(w.WriteTag(1<<29-1, WireFixed32), ShouldBeNil)
+		So(b, ShouldResemble, []byte{0xfd, 0xff, 0xff, 0xff, 0x0f})
+		f, k, err := NewReaderBytes(b).ReadTag()
+		So(err, ShouldBeNil)
+		So(f, ShouldEqual, 1<<29-1)
+		So(k, ShouldEqual, WireFixed32)
+		_, _, err = NewReaderBytes([]byte{0x07}).ReadTag()
+		So(err, ShouldEqual, ErrInvalidTag)
+		_, _, err = NewReaderBytes([]byte{0x02}).ReadTag()
+		So(err, ShouldEqual, ErrInvalidTag)
+		So(NewReaderBytes(nil).SkipField(1, WireEndGroup), ShouldEqual, ErrInvalidTag)
+	})
+
+	Convey("ReadPacked should require each call to read an element", t, func() {
+		r := NewReaderBytes([]byte{0x02, 0x01, 0x02})
+		So(r.ReadPacked(func(m *Reader) error { return nil }), ShouldEqual, io.ErrNoProgress)
+	})
+
+}
@@ -15,11 +15,17 @@
 package bump
 
 import (
+	"hash"
 	"io"
 )
 
 const readerSize = 1024
 
+// maxPrealloc is the most memory which is allocated
+// up front when reading data of a given length from
+// an io.Reader.
+const maxPrealloc = 64 * readerSize
+
 // Reader represents a buffer for reading
 // from an io.Reader, or a byte slice.
 type Reader struct {
@@ -28,6 +34,21 @@ type Reader struct {
 	buf []byte
 	out []byte
 	rdr io.Reader
+	src io.Reader
+	zip io.ReadCloser
+	cdc Codec
+	sum hash.Hash
+	tee io.Writer
+	hsh []byte
+	tmp [1]byte
+	fix [8]byte
+	err error
+	kep bool
+	pre *prefetch
+	pgs [][]byte
+	par *Reader
+	sub *Reader
+	rem int
 	arr [readerSize]byte
 }
 
@@ -44,57 +65,304 @@ func NewReaderBytes(b []byte) *Reader {
 }
 
 // Reset resets the Reader, and instructs it
-// to read from the specified io.Reader.
+// to read from the specified io.Reader. If the
+// Reader was created with NewCompressedReader
+// then the data will continue to be decompressed.
 func (r *Reader) Reset(i io.Reader) error {
+	r.release()
 	r.pos = 0
+	r.sze = 0
+	r.err = nil
 	r.rdr = i
 	r.out = nil
+	r.pgs = nil
+	if r.sum != nil {
+		r.sum.Reset()
+	}
+	if r.pre != nil {
+		r.pre.stop()
+		r.pre.wait()
+		r.pre = newPrefetch(i, r.pre.num)
+		r.rdr = r.pre
+		r.src = i
+	}
+	if r.cdc != nil {
+		return r.resetCodec(i)
+	}
 	return nil
 }
 
 // ResetBytes resets the Reader, and instructs
 // it to read from the specified byte slice.
 func (r *Reader) ResetBytes(b []byte) error {
+	r.release()
 	r.pos = 0
+	r.sze = 0
+	r.err = nil
 	r.out = b
+	r.pgs = nil
 	r.rdr = nil
+	r.src = nil
+	r.zip = nil
+	r.cdc = nil
+	if r.pre != nil {
+		r.pre.stop()
+		r.pre.wait()
+		r.pre = nil
+	}
+	if r.sum != nil {
+		r.sum.Reset()
+	}
 	return nil
 }
 
+// Close releases the decompressor, if the Reader
+// was created with a Codec, and then closes the
+// underlying io.Reader, if it is an io.Closer,
+// unless disabled using CloseUnderlying. Once
+// closed, all methods return ErrClosed until
+// the Reader is reset.
+func (r *Reader) Close() error {
+
+	var err error
+
+	// Ensure the Reader is not already closed.
+
+	if r.err == ErrClosed {
+		return ErrClosed
+	}
+
+	// Close a section of a parent Reader.
+
+	if r.par != nil {
+		return r.closeLimit()
+	}
+
+	// Detach any section which is still open.
+
+	r.detach()
+
+	// Release the decompressor.
+
+	if r.zip != nil {
+		err = r.zip.Close()
+	}
+
+	// Cancel any background reading.
+
+	if r.pre != nil {
+		r.pre.stop()
+	}
+
+	// Close the underlying reader if needed.
+
+	if !r.kep {
+		src := r.rdr
+		if r.zip != nil || r.pre != nil {
+			src = r.src
+		}
+		if c, ok := src.(io.Closer); ok {
+			if e := c.Close(); err == nil {
+				err = e
+			}
+		}
+	}
+
+	// Wait for the background reading to stop.
+
+	if r.pre != nil {
+		r.pre.wait()
+	}
+
+	// Prevent the Reader from being used.
+
+	r.err = ErrClosed
+
+	return err
+
+}
+
+// Err returns the error which caused the Reader
+// to fail, if any. Once the underlying io.Reader
+// has returned an error, including io.EOF, the
+// same error is returned from all later calls
+// on the Reader, until it is reset. After Close,
+// Err returns ErrClosed.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// CloseUnderlying specifies whether Close should
+// also close the underlying io.Reader, if it is
+// an io.Closer. This is enabled by default.
+func (r *Reader) CloseUnderlying(v bool) {
+	r.kep = !v
+}
+
+func (r *Reader) resetCodec(i io.Reader) error {
+
+	// Reuse the existing decompressor if possible.
+
+	if z, ok := r.zip.(readResetter); ok {
+		r.rdr = r.zip
+		r.src = i
+		r.err = z.Reset(i)
+		return r.err
+	}
+
+	// Otherwise create a new decompressor.
+
+	z, err := r.cdc.NewReader(i)
+	if err != nil {
+		r.err = err
+		return err
+	}
+
+	r.rdr = z
+	r.zip = z
+	r.src = i
+
+	// Everything went ok.
+
+	return nil
+
+}
+
 // PeekByte returns the next byte in the
 // stream without advancing the position
 // of the reader.
 func (r *Reader) PeekByte() (byte, error) {
-	if r.out != nil {
-		return r.peekByteFromBytes()
+	if r.sub != nil {
+		r.skip()
 	}
-	return r.peekByteFromReader()
+	return r.peekByte()
+}
+
+// PeekBytes returns the data which can be read
+// next without advancing the position of the
+// reader, or reading from the underlying source
+// unless no data is buffered. At least one byte
+// is returned unless there is an error. When
+// reading from a byte slice, the data refers to
+// the byte slice, and otherwise it is only valid
+// until the next call on the Reader.
+func (r *Reader) PeekBytes() ([]byte, error) {
+	if r.sub != nil {
+		r.skip()
+	}
+	return r.peekBytes()
 }
 
 // ReadByte reads a single byte from the
 // underlying io.Reader, or byte slice,
 // and advances the position.
 func (r *Reader) ReadByte() (byte, error) {
-	if r.out != nil {
-		return r.readByteFromBytes()
+	if r.sub != nil {
+		r.skip()
 	}
-	return r.readByteFromReader()
+	return r.takeByte()
 }
 
 // ReadBytes reads the specified number
 // of bytes from the underlying io.Reader,
 // or byte slice, and advances the position.
 func (r *Reader) ReadBytes(l int) ([]byte, error) {
-	if r.out != nil {
-		return r.readBytesFromBytes(l)
+	if r.sub != nil {
+		r.skip()
 	}
-	return r.readBytesFromReader(l)
+	return r.takeBytes(l)
 }
 
 // ReadString reads the specified length
 // string from the underlying io.Reader, or
 // byte slice, and advances the position.
 func (r *Reader) ReadString(l int) (string, error) {
+	if r.sub != nil {
+		r.skip()
+	}
+	return r.takeString(l)
+}
+
+func (r *Reader) peekByte() (byte, error) {
+	if r.err != nil {
+		return byte(0), r.err
+	}
+	if r.par != nil {
+		return r.peekByteFromParent()
+	}
+	if r.out != nil {
+		return r.peekByteFromBytes()
+	}
+	return r.peekByteFromReader()
+}
+
+func (r *Reader) peekBytes() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.par != nil {
+		return r.peekBytesFromParent()
+	}
+	if r.out != nil {
+		return r.peekBytesFromBytes()
+	}
+	return r.peekBytesFromReader()
+}
+
+func (r *Reader) takeByte() (byte, error) {
+	if r.err != nil {
+		return byte(0), r.err
+	}
+	if r.sum != nil || r.tee != nil {
+		return r.mirrorByte(r.readByte())
+	}
+	return r.readByte()
+}
+
+func (r *Reader) takeBytes(l int) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.sum != nil || r.tee != nil {
+		return r.mirrorBytes(r.readBytes(l))
+	}
+	return r.readBytes(l)
+}
+
+func (r *Reader) takeString(l int) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	if r.sum != nil || r.tee != nil {
+		return r.mirrorString(r.readString(l))
+	}
+	return r.readString(l)
+}
+
+func (r *Reader) readByte() (byte, error) {
+	if r.par != nil {
+		return r.readByteFromParent()
+	}
+	if r.out != nil {
+		return r.readByteFromBytes()
+	}
+	return r.readByteFromReader()
+}
+
+func (r *Reader) readBytes(l int) ([]byte, error) {
+	if r.par != nil {
+		return r.readBytesFromParent(l)
+	}
+	if r.out != nil {
+		return r.readBytesFromBytes(l)
+	}
+	return r.readBytesFromReader(l)
+}
+
+func (r *Reader) readString(l int) (string, error) {
+	if r.par != nil {
+		return r.readStringFromParent(l)
+	}
 	if r.out != nil {
 		return r.readStringFromBytes(l)
 	}
@@ -105,7 +373,7 @@ func (r *Reader) peekByteFromBytes() (byte, error) {
 
 	// Return an error if there is no more data.
 
-	if r.pos+1 > len(r.out) {
+	if r.pos+1 > len(r.out) && !r.next() {
 		return byte(0), io.EOF
 	}
 
@@ -119,11 +387,25 @@ func (r *Reader) peekByteFromBytes() (byte, error) {
 
 }
 
+func (r *Reader) peekBytesFromBytes() ([]byte, error) {
+
+	// Return an error if there is no more data.
+
+	if r.pos+1 > len(r.out) && !r.next() {
+		return nil, io.EOF
+	}
+
+	// Everything went ok.
+
+	return r.out[r.pos:], nil
+
+}
+
 func (r *Reader) readByteFromBytes() (byte, error) {
 
 	// Return an error if there is no more data.
 
-	if r.pos+1 > len(r.out) {
+	if r.pos+1 > len(r.out) && !r.next() {
 		return byte(0), io.EOF
 	}
 
@@ -146,6 +428,9 @@ func (r *Reader) readBytesFromBytes(l int) ([]byte, error) {
 	// Return an error if there is no more data.
 
 	if r.pos+l > len(r.out) {
+		if r.pgs != nil {
+			return r.readBytesFromSlices(l)
+		}
 		return nil, io.EOF
 	}
 
@@ -168,6 +453,10 @@ func (r *Reader) readStringFromBytes(l int) (string, error) {
 	// Return an error if there is no more data.
 
 	if r.pos+l > len(r.out) {
+		if r.pgs != nil {
+			b, err := r.readBytesFromSlices(l)
+			return string(b), err
+		}
 		return "", io.EOF
 	}
 
@@ -212,7 +501,7 @@ func (r *Reader) peekByteFromReader() (byte, error) {
 
 }
 
-func (r *Reader) readByteFromReader() (byte, error) {
+func (r *Reader) peekBytesFromReader() ([]byte, error) {
 
 	// Initialise the underlying buffer if needed.
 
@@ -220,30 +509,22 @@ func (r *Reader) readByteFromReader() (byte, error) {
 		r.buf = r.arr[0:]
 	}
 
-	// Fill the buffer with data if there is not enough.
+	// Fill the buffer with data if there is none.
 
 	if r.sze == 0 || r.pos+1 > r.sze {
 		err := r.fill()
 		if err != nil {
-			return byte(0), err
+			return nil, err
 		}
 	}
 
-	// Get the data from the underlying buffer.
-
-	b := r.buf[r.pos]
-
-	// Advance the buffer position.
-
-	r.pos += 1
-
 	// Everything went ok.
 
-	return b, nil
+	return r.buf[r.pos:r.sze], nil
 
 }
 
-func (r *Reader) readBytesFromReader(l int) ([]byte, error) {
+func (r *Reader) readByteFromReader() (byte, error) {
 
 	// Initialise the underlying buffer if needed.
 
@@ -251,34 +532,22 @@ func (r *Reader) readBytesFromReader(l int) ([]byte, error) {
 		r.buf = r.arr[0:]
 	}
 
-	// Initialise the byte slice for returning.
-
-	b := make([]byte, l)
-
-	// Loop through until we have filled the byte slice.
-
-	for p := 0; l > 0; {
-
-		// Fill the buffer with data if there is not enough.
+	// Fill the buffer with data if there is not enough.
 
-		if r.sze == 0 || r.pos+l > r.sze {
-			err := r.fill()
-			if err != nil {
-				return nil, err
-			}
+	if r.sze == 0 || r.pos+1 > r.sze {
+		err := r.fill()
+		if err != nil {
+			return byte(0), err
 		}
+	}
 
-		// Get the data from the underlying buffer.
-
-		n := copy(b[p:], r.buf[r.pos:])
+	// Get the data from the underlying buffer.
 
-		// Advance the buffer position.
+	b := r.buf[r.pos]
 
-		r.pos += n
-		p += n
-		l -= n
+	// Advance the buffer position.
 
-	}
+	r.pos += 1
 
 	// Everything went ok.
 
@@ -286,7 +555,7 @@ func (r *Reader) readBytesFromReader(l int) ([]byte, error) {
 
 }
 
-func (r *Reader) readStringFromReader(l int) (string, error) {
+func (r *Reader) readBytesFromReader(l int) ([]byte, error) {
 
 	// Initialise the underlying buffer if needed.
 
@@ -294,41 +563,60 @@ func (r *Reader) readStringFromReader(l int) (string, error) {
 		r.buf = r.arr[0:]
 	}
 
-	// Initialise the byte slice for returning.
+	// Initialise the byte slice for returning, which
+	// grows as the data arrives for long lengths, so
+	// that a corrupt length can not allocate much more
+	// memory than the data which is actually available.
 
-	b := make([]byte, l)
+	c := l
+	if c > maxPrealloc {
+		c = maxPrealloc
+	}
+
+	b := make([]byte, 0, c)
 
 	// Loop through until we have filled the byte slice.
 
-	for p := 0; l > 0; {
+	for len(b) < l {
 
 		// Fill the buffer with data if there is not enough.
 
-		if r.sze == 0 || r.pos+l > r.sze {
+		if r.pos >= r.sze {
 			err := r.fill()
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 		}
 
 		// Get the data from the underlying buffer.
 
-		n := copy(b[p:], r.buf[r.pos:])
+		n := r.sze - r.pos
+		if n > l-len(b) {
+			n = l - len(b)
+		}
+
+		b = append(b, r.buf[r.pos:r.pos+n]...)
 
 		// Advance the buffer position.
 
 		r.pos += n
-		p += n
-		l -= n
 
 	}
 
 	// Everything went ok.
 
-	return string(b), nil
+	return b, nil
 
 }
 
+func (r *Reader) readStringFromReader(l int) (string, error) {
+	b, err := r.readBytesFromReader(l)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
 func (r *Reader) fill() error {
 	if r.pos > 0 && r.pos < r.sze {
 		copy(r.buf, r.buf[r.pos:r.sze])
@@ -337,10 +625,13 @@ func (r *Reader) fill() error {
 		r.sze = 0
 	}
 	n, err := r.rdr.Read(r.buf[r.sze:])
-	if err != nil {
-		return err
-	}
 	r.sze += n
 	r.pos = 0
-	return nil
+	if n > 0 {
+		return nil
+	}
+	if err != nil {
+		r.err = err
+	}
+	return err
 }
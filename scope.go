@@ -0,0 +1,294 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"io"
+	"math"
+)
+
+// Prefix specifies how the length of a
+// scope is encoded before its contents.
+type Prefix int
+
+const (
+	// PrefixUvarint encodes the length as a uvarint.
+	// As the width of the length is not known until
+	// the scope ends, its contents are moved along
+	// to make room for it, so deeply nested scopes
+	// copy their contents once for each level.
+	PrefixUvarint Prefix = iota
+	// PrefixUint8 encodes the length as a single byte.
+	PrefixUint8
+	// PrefixUint16 encodes the length as a big-endian uint16.
+	PrefixUint16
+	// PrefixUint32 encodes the length as a big-endian uint32.
+	PrefixUint32
+	// PrefixUint64 encodes the length as a big-endian uint64.
+	PrefixUint64
+)
+
+// scopeZeros is used to reserve space
+// for a fixed size length prefix.
+var scopeZeros [8]byte
+
+// Scope represents a length-prefixed section
+// of data which is being written to a Writer.
+type Scope struct {
+	wtr *Writer
+	knd Prefix
+	dep int
+	off int
+}
+
+// scope holds the state of the Writer while
+// one or more scopes are open. Data written in
+// an open scope is held in a byte slice until
+// the length of the scope is known.
+
+type scope struct {
+	dep int
+	beg int
+	pos int
+	str bool
+	buf []byte
+	sum hash.Hash
+	tee io.Writer
+}
+
+// BeginScope starts a new scope, the length of
+// which is written before its contents when End
+// is called. Scopes can be nested, and must be
+// ended in the reverse order to which they were
+// begun. When writing to a byte slice, the length
+// is patched into the byte slice. When writing
+// to an io.Writer, the data is buffered until
+// the outermost scope ends. Any checksum or tee
+// sees the data once the outermost scope ends.
+// Flush only writes the data which was written
+// before the outermost scope began. If the Writer has already failed, the scope
+// does nothing, and End returns the error.
+func (w *Writer) BeginScope(k Prefix) Scope {
+
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+
+	// Return an empty scope if the Writer has failed.
+
+	if w.err != nil || w.asy != nil && w.asy.failed() {
+		w.fault()
+		return Scope{wtr: w}
+	}
+
+	// Suspend the Writer when the outermost scope begins.
+
+	if w.scp.dep == 0 {
+		w.scp.sum, w.sum = w.sum, nil
+		w.scp.tee, w.tee = w.tee, nil
+		w.scp.str = w.out == nil
+		if w.scp.str {
+			w.scp.pos, w.pos = w.pos, 0
+			w.scp.buf = w.scp.buf[:0]
+			w.out = &w.scp.buf
+		}
+		w.scp.beg = w.pos
+	}
+
+	w.scp.dep++
+
+	s := Scope{wtr: w, knd: k, dep: w.scp.dep, off: w.pos}
+
+	// Reserve space for a fixed size length.
+
+	*w.out = append((*w.out)[:w.pos], scopeZeros[:k.size()]...)
+	w.pos += k.size()
+
+	// Everything went ok.
+
+	return s
+
+}
+
+// End ends the scope, writing the length of all
+// of the data which was written since the scope
+// began. Returns ErrScopeOrder if an inner scope
+// has not yet been ended, and ErrScopeTooLarge if
+// the length does not fit in the length prefix.
+func (s Scope) End() error {
+
+	w := s.wtr
+
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+
+	// Report the error if the Writer had failed.
+
+	if s.dep == 0 {
+		return w.fault()
+	}
+
+	// Ensure this is the innermost open scope.
+
+	if s.dep != w.scp.dep {
+		return ErrScopeOrder
+	}
+
+	w.scp.dep--
+
+	// Write the length of the scope.
+
+	b := (*w.out)[:w.pos]
+	l := uint64(w.pos - s.off - s.knd.size())
+
+	switch s.knd {
+	case PrefixUvarint:
+		n := binary.PutUvarint(w.tmp[:], l)
+		b = append(b, w.tmp[:n]...)
+		copy(b[s.off+n:], b[s.off:len(b)-n])
+		copy(b[s.off:], w.tmp[:n])
+		*w.out = b
+		w.pos += n
+	case PrefixUint8:
+		if l > math.MaxUint8 {
+			w.err = ErrScopeTooLarge
+		} else {
+			b[s.off] = byte(l)
+		}
+	case PrefixUint16:
+		if l > math.MaxUint16 {
+			w.err = ErrScopeTooLarge
+		} else {
+			binary.BigEndian.PutUint16(b[s.off:], uint16(l))
+		}
+	case PrefixUint32:
+		if l > math.MaxUint32 {
+			w.err = ErrScopeTooLarge
+		} else {
+			binary.BigEndian.PutUint32(b[s.off:], uint32(l))
+		}
+	case PrefixUint64:
+		binary.BigEndian.PutUint64(b[s.off:], l)
+	}
+
+	// Resume the Writer when the outermost scope ends.
+
+	if w.scp.dep == 0 {
+		return w.endScope()
+	}
+
+	return w.err
+
+}
+
+func (p Prefix) size() int {
+	switch p {
+	case PrefixUint8:
+		return 1
+	case PrefixUint16:
+		return 2
+	case PrefixUint32:
+		return 4
+	case PrefixUint64:
+		return 8
+	}
+	return 0
+}
+
+func (w *Writer) endScope() error {
+
+	// Restore the suspended hooks.
+
+	w.sum, w.scp.sum = w.scp.sum, nil
+	w.tee, w.scp.tee = w.scp.tee, nil
+
+	v := (*w.out)[w.scp.beg:w.pos]
+
+	if w.scp.str {
+		w.out = nil
+		w.pos = w.scp.pos
+	}
+
+	if w.err != nil {
+		return w.err
+	}
+
+	// Write the buffered data to the io.Writer.
+
+	if w.scp.str {
+		err := w.writeBytesToWriter(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Mirror the data which was written in the scope.
+
+	if w.sum != nil || w.tee != nil {
+		err := w.mirror(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.scp.str {
+		return w.policy(w.nwl && bytes.IndexByte(v, '\n') >= 0)
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// suspended calls the function with the data
+// which was buffered before the outermost scope
+// began in place of the scope buffer, so that
+// the data can be flushed while a scope is open
+// when writing to an io.Writer.
+
+func (w *Writer) suspended(fn func() error) error {
+
+	if w.scp.dep == 0 || !w.scp.str {
+		return fn()
+	}
+
+	out, pos := w.out, w.pos
+	w.out, w.pos = nil, w.scp.pos
+
+	err := fn()
+
+	w.scp.pos = w.pos
+	w.out, w.pos = out, pos
+
+	// Everything went ok.
+
+	return err
+
+}
+
+func (w *Writer) resetScope() {
+	if w.scp.dep > 0 {
+		w.scp.dep = 0
+		w.sum, w.scp.sum = w.scp.sum, nil
+		w.tee, w.scp.tee = w.scp.tee, nil
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// scopeWrite writes a record containing an
+// array of maps, each within its own scope.
+
+func scopeWrite(w *Writer) {
+	rec := w.BeginScope(PrefixUint32)
+	w.WriteString("record")
+	arr := w.BeginScope(PrefixUvarint)
+	for i := 0; i < 3; i++ {
+		obj := w.BeginScope(PrefixUint8)
+		w.WriteBytes(txt[:10*i])
+		obj.End()
+	}
+	arr.End()
+	w.WriteBytes(big)
+	rec.End()
+}
+
+// scopeCheck reads and verifies a record
+// which was written using scopeWrite.
+
+func scopeCheck(r *Reader) {
+	b, _ := r.ReadBytes(4)
+	rec := r.Limit(int(binary.BigEndian.Uint32(b)))
+	s, _ := rec.ReadString(6)
+	So(s, ShouldEqual, "record")
+	l, _ := binary.ReadUvarint(rec)
+	arr := rec.Limit(int(l))
+	for i := 0; i < 3; i++ {
+		n, _ := arr.ReadByte()
+		So(n, ShouldEqual, 10*i)
+		o, _ := arr.ReadBytes(int(n))
+		So(o, ShouldResemble, txt[:10*i])
+	}
+	_, err := arr.ReadByte()
+	So(err, ShouldNotBeNil)
+	o, _ := rec.ReadBytes(len(big))
+	So(o, ShouldResemble, big)
+	So(rec.Close(), ShouldBeNil)
+}
+
+func TestScope(t *testing.T) {
+
+	Convey("Scopes should patch lengths when writing to a byte slice", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.WriteByte(1)
+		scopeWrite(w)
+		w.WriteByte(2)
+		r := NewReaderBytes(b)
+		x, _ := r.ReadByte()
+		So(x, ShouldEqual, 1)
+		scopeCheck(r)
+		x, _ = r.ReadByte()
+		So(x, ShouldEqual, 2)
+	})
+
+	Convey("Scopes should buffer data when writing to an io.Writer", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		w.WriteByte(1)
+		scopeWrite(w)
+		w.WriteByte(2)
+		w.Flush()
+		r := NewReader(b)
+		x, _ := r.ReadByte()
+		So(x, ShouldEqual, 1)
+		scopeCheck(r)
+		x, _ = r.ReadByte()
+		So(x, ShouldEqual, 2)
+	})
+
+	Convey("Scopes should write identical data in both modes", t, func() {
+		var b []byte
+		c := bytes.NewBuffer(nil)
+		w := NewWriterBytes(&b)
+		scopeWrite(w)
+		w = NewWriter(c)
+		scopeWrite(w)
+		w.Flush()
+		So(c.Bytes(), ShouldResemble, b)
+	})
+
+	Convey("Scopes should mirror data in order once the outermost scope ends", t, func() {
+		var b []byte
+		c := bytes.NewBuffer(nil)
+		w := NewWriterBytes(&b)
+		w.Tee(c)
+		w.WriteByte(1)
+		s := w.BeginScope(PrefixUvarint)
+		w.WriteBytes(txt)
+		So(c.Len(), ShouldEqual, 1)
+		So(s.End(), ShouldBeNil)
+		So(c.Bytes(), ShouldResemble, b)
+	})
+
+	Convey("Scopes should only be ended in order", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		o := w.BeginScope(PrefixUvarint)
+		i := w.BeginScope(PrefixUvarint)
+		So(o.End(), ShouldEqual, ErrScopeOrder)
+		So(i.End(), ShouldBeNil)
+		So(o.End(), ShouldBeNil)
+		So(b, ShouldResemble, []byte{1, 0})
+	})
+
+	Convey("Scopes should fail when the length does not fit in the prefix", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		s := w.BeginScope(PrefixUint8)
+		w.WriteBytes(txt)
+		So(s.End(), ShouldEqual, ErrScopeTooLarge)
+		So(w.Err(), ShouldEqual, ErrScopeTooLarge)
+	})
+
+	Convey("Scopes should do nothing when the Writer has already failed", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		s := w.BeginScope(PrefixUint8)
+		w.WriteBytes(txt)
+		So(s.End(), ShouldEqual, ErrScopeTooLarge)
+		n := len(b)
+		s = w.BeginScope(PrefixUvarint)
+		So(w.WriteByte(1), ShouldEqual, ErrScopeTooLarge)
+		So(s.End(), ShouldEqual, ErrScopeTooLarge)
+		So(len(b), ShouldEqual, n)
+		e := errors.New("failed")
+		c := bytes.NewBuffer(nil)
+		w = NewWriter(&faultWriter{lim: 0, err: e})
+		w.Tee(c)
+		w.WriteBytes(big)
+		s = w.BeginScope(PrefixUvarint)
+		So(s.End(), ShouldEqual, e)
+		So(w.BeginScope(PrefixUint32).End(), ShouldEqual, e)
+	})
+
+	Convey("Scopes should not lose buffered data when flushing with a scope open", t, func() {
+		c := bytes.NewBuffer(nil)
+		w := NewWriter(c)
+		w.WriteString("hello")
+		s := w.BeginScope(PrefixUint8)
+		w.WriteString("abc")
+		So(w.Buffered(), ShouldEqual, 9)
+		So(w.Flush(), ShouldBeNil)
+		So(c.String(), ShouldEqual, "hello")
+		So(w.Buffered(), ShouldEqual, 4)
+		So(s.End(), ShouldBeNil)
+		So(w.Flush(), ShouldBeNil)
+		So(c.String(), ShouldEqual, "hello\x03abc")
+		So(w.Buffered(), ShouldEqual, 0)
+	})
+
+	Convey("Scopes should cause Close to fail when a scope is open", t, func() {
+		c := bytes.NewBuffer(nil)
+		w := NewWriter(c)
+		w.WriteString("hello")
+		w.BeginScope(PrefixUint8)
+		w.WriteString("abc")
+		So(w.Close(), ShouldEqual, ErrScopeOrder)
+		So(c.String(), ShouldEqual, "hello")
+		So(w.Err(), ShouldEqual, ErrClosed)
+		var b []byte
+		w = NewWriterBytes(&b)
+		w.BeginScope(PrefixUint8)
+		So(w.Close(), ShouldEqual, ErrScopeOrder)
+	})
+
+	Convey("Scopes should be discarded when the Writer is reset", t, func() {
+		var b []byte
+		c := bytes.NewBuffer(nil)
+		w := NewWriter(c)
+		w.Tee(c)
+		w.BeginScope(PrefixUvarint)
+		w.WriteBytes(txt)
+		w.ResetBytes(&b)
+		w.WriteByte(1)
+		So(b, ShouldResemble, []byte{1})
+		So(c.Bytes(), ShouldResemble, []byte{1})
+	})
+
+}
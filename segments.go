@@ -0,0 +1,148 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+const segmentSize = 16 * writerSize
+
+var segmentPool = sync.Pool{
+	New: func() interface{} {
+		return new([segmentSize]byte)
+	},
+}
+
+// NewWriterSegments creates a new Writer which
+// writes to a list of fixed size segments, taken
+// from a shared pool, instead of one contiguous
+// byte slice. The segments are returned to the
+// pool when the Writer is next reset.
+func NewWriterSegments() *Writer {
+	w := &Writer{}
+	w.ResetSegments()
+	return w
+}
+
+// ResetSegments resets the Writer, returning any
+// existing segments to the pool, and instructs it
+// to write to a new list of segments. Any data
+// returned from Segments or Bytes must no longer
+// be used once the Writer has been reset.
+func (w *Writer) ResetSegments() error {
+	w.ResetBytes(nil)
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	w.seg = true
+	w.buf = segmentPool.Get().(*[segmentSize]byte)[:]
+	return nil
+}
+
+// Segments returns the data which has been written
+// to the Writer, as a list of byte slices. This
+// returns nil if the Writer is not writing to a
+// list of segments.
+func (w *Writer) Segments() [][]byte {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	return w.segments()
+}
+
+// Bytes returns the data which has been written to
+// the Writer, as a single byte slice. If the data
+// spans more than one segment, then the segments
+// are concatenated into a newly allocated slice.
+func (w *Writer) Bytes() []byte {
+
+	s := w.Segments()
+
+	// Return a single segment directly.
+
+	if len(s) == 1 {
+		return s[0]
+	}
+
+	// Otherwise concatenate the segments.
+
+	n := 0
+	for _, v := range s {
+		n += len(v)
+	}
+
+	b := make([]byte, 0, n)
+	for _, v := range s {
+		b = append(b, v...)
+	}
+
+	// Everything went ok.
+
+	return b
+
+}
+
+// WriteTo writes the data which has been written
+// to the Writer to the specified io.Writer, one
+// segment at a time. The segments are retained
+// until the Writer is next reset.
+func (w *Writer) WriteTo(i io.Writer) (int64, error) {
+	b := net.Buffers(w.Segments())
+	return b.WriteTo(i)
+}
+
+func (w *Writer) segments() [][]byte {
+	if !w.seg {
+		return nil
+	}
+	s := w.sgs[:len(w.sgs):len(w.sgs)]
+	if w.pos > 0 {
+		s = append(s, w.buf[:w.pos])
+	}
+	return s
+}
+
+// rotate stores the current segment once it
+// is full, and starts writing to a new one.
+
+func (w *Writer) rotate() error {
+	if w.pos == len(w.buf) {
+		w.sgs = append(w.sgs, w.buf)
+		w.buf = segmentPool.Get().(*[segmentSize]byte)[:]
+		w.pos = 0
+	}
+	return nil
+}
+
+// release returns all of the segments to the
+// pool, and stops writing to segments.
+
+func (w *Writer) release() {
+	if w.seg {
+		for i, b := range w.sgs {
+			segmentPool.Put((*[segmentSize]byte)(b))
+			w.sgs[i] = nil
+		}
+		segmentPool.Put((*[segmentSize]byte)(w.buf))
+		w.sgs = w.sgs[:0]
+		w.buf = nil
+		w.seg = false
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSegments(t *testing.T) {
+
+	Convey("Segments Writer should write data into fixed size segments", t, func() {
+		w := NewWriterSegments()
+		for i := 0; i < 10; i++ {
+			chunkWriteBytes(w, big)
+			chunkWriteString(w, string(txt))
+		}
+		var x []byte
+		for i := 0; i < 10; i++ {
+			x = append(x, big...)
+			x = append(x, txt...)
+		}
+		s := w.Segments()
+		So(len(s), ShouldEqual, (len(x)+segmentSize-1)/segmentSize)
+		for _, v := range s[:len(s)-1] {
+			So(len(v), ShouldEqual, segmentSize)
+		}
+		So(bytes.Join(s, nil), ShouldResemble, x)
+		So(w.Bytes(), ShouldResemble, x)
+		So(w.Buffered(), ShouldEqual, 0)
+	})
+
+	Convey("Segments Writer should return a single segment without copying", t, func() {
+		w := NewWriterSegments()
+		So(w.Segments(), ShouldBeEmpty)
+		w.WriteBytes(txt)
+		s := w.Segments()
+		So(len(s), ShouldEqual, 1)
+		So(&w.Bytes()[0], ShouldEqual, &s[0][0])
+	})
+
+	Convey("Segments Writer should not change returned segments on later writes", t, func() {
+		w := NewWriterSegments()
+		w.WriteBytes(big)
+		s := w.Segments()
+		o := bytes.Join(s, nil)
+		chunkWriteBytes(w, big)
+		So(bytes.Join(s, nil), ShouldResemble, o)
+	})
+
+	Convey("Segments Writer should write all segments to an io.Writer", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriterSegments()
+		for i := 0; i < 10; i++ {
+			w.WriteBytes(big)
+		}
+		n, err := w.WriteTo(b)
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, 10*len(big))
+		So(b.Bytes(), ShouldResemble, bytes.Repeat(big, 10))
+		So(w.Bytes(), ShouldResemble, bytes.Repeat(big, 10))
+	})
+
+	Convey("Segments Writer should support checksums and scopes", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.Checksum(NewCRC32C())
+		scopeWrite(w)
+		w.WriteChecksum()
+		s := NewWriterSegments()
+		s.Checksum(NewCRC32C())
+		scopeWrite(s)
+		s.WriteChecksum()
+		So(s.Bytes(), ShouldResemble, b)
+	})
+
+	Convey("Segments Writer should be reusable", t, func() {
+		w := NewWriterSegments()
+		for i := 0; i < 5; i++ {
+			So(w.ResetSegments(), ShouldBeNil)
+			chunkWriteBytes(w, big)
+			So(w.Bytes(), ShouldResemble, big)
+		}
+		b := bytes.NewBuffer(nil)
+		w.Reset(b)
+		w.WriteBytes(txt)
+		w.Flush()
+		So(b.Bytes(), ShouldResemble, txt)
+		So(w.Segments(), ShouldBeNil)
+	})
+
+}
@@ -0,0 +1,99 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// faultWriter accepts at most max bytes per call,
+// and fails once lim bytes have been written.
+
+type faultWriter struct {
+	bytes.Buffer
+	max int
+	lim int
+	err error
+}
+
+func (f *faultWriter) Write(p []byte) (int, error) {
+	if f.lim >= 0 && f.Len() >= f.lim {
+		return 0, f.err
+	}
+	if f.max > 0 && len(p) > f.max {
+		p = p[:f.max]
+	}
+	if f.lim >= 0 && f.Len()+len(p) > f.lim {
+		p = p[:f.lim-f.Len()]
+		n, _ := f.Buffer.Write(p)
+		return n, f.err
+	}
+	return f.Buffer.Write(p)
+}
+
+func TestShortWrite(t *testing.T) {
+
+	Convey("Writer should retry short writes when flushing", t, func() {
+		f := &faultWriter{max: 7, lim: -1}
+		w := NewWriter(f)
+		chunkWriteBytes(w, txt)
+		So(w.Flush(), ShouldBeNil)
+		So(w.Buffered(), ShouldEqual, 0)
+		So(f.Bytes(), ShouldResemble, txt)
+	})
+
+	Convey("Writer should retry short writes when writing large data", t, func() {
+		f := &faultWriter{max: 100, lim: -1}
+		w := NewWriter(f)
+		w.WriteString("header")
+		w.WriteBytes(big)
+		w.WriteString(string(big))
+		So(w.Flush(), ShouldBeNil)
+		So(f.Bytes(), ShouldResemble, append(append([]byte("header"), big...), big...))
+	})
+
+	Convey("Writer should retain unwritten data when a flush fails", t, func() {
+		f := &faultWriter{max: 0, lim: 300, err: errors.New("disk full")}
+		w := NewWriter(f)
+		w.WriteBytes(txt[:500])
+		So(w.Buffered(), ShouldEqual, 500)
+		So(w.Flush(), ShouldEqual, f.err)
+		So(f.Len(), ShouldEqual, 300)
+		So(w.Buffered(), ShouldEqual, 200)
+		So(f.Bytes(), ShouldResemble, txt[:300])
+	})
+
+	Convey("Writer should error if no progress is made", t, func() {
+		f := &faultWriter{max: 0, lim: 300}
+		w := NewWriter(f)
+		w.WriteBytes(txt[:500])
+		So(w.Flush(), ShouldEqual, io.ErrShortWrite)
+		So(w.Buffered(), ShouldEqual, 200)
+		So(w.WriteByte(1), ShouldEqual, io.ErrShortWrite)
+	})
+
+	Convey("Writer should error if no progress is made when writing large data", t, func() {
+		f := &faultWriter{max: 0, lim: 3000}
+		w := NewWriter(f)
+		So(w.WriteBytes(big), ShouldEqual, io.ErrShortWrite)
+		So(f.Bytes(), ShouldResemble, big[:3000])
+	})
+
+}
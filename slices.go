@@ -0,0 +1,113 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"io"
+)
+
+// NewReaderSlices creates a new Reader which
+// reads from a list of byte slices in turn, as
+// if they had been concatenated. Any read which
+// falls within a single byte slice returns a
+// subslice of it, and data is copied only when
+// a read spans more than one byte slice.
+func NewReaderSlices(p [][]byte) *Reader {
+	r := &Reader{}
+	r.ResetSlices(p)
+	return r
+}
+
+// ResetSlices resets the Reader, and instructs
+// it to read from the specified byte slices.
+func (r *Reader) ResetSlices(p [][]byte) error {
+	r.ResetBytes([]byte{})
+	r.pgs = p
+	r.next()
+	return nil
+}
+
+// next moves to the next byte slice which
+// is not empty, returning false if there
+// are no more byte slices to read from.
+
+func (r *Reader) next() bool {
+	for len(r.pgs) > 0 {
+		p := r.pgs[0]
+		r.pgs = r.pgs[1:]
+		if len(p) > 0 {
+			r.out = p
+			r.pos = 0
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reader) readBytesFromSlices(l int) ([]byte, error) {
+
+	// Return an error if there is not enough data.
+
+	n := len(r.out) - r.pos
+	for _, p := range r.pgs {
+		n += len(p)
+	}
+
+	if n < l {
+		return nil, io.EOF
+	}
+
+	// Move to the next slice if this one is used up.
+
+	if r.pos == len(r.out) {
+		r.next()
+	}
+
+	// Return a subslice if the data is in this slice.
+
+	if r.pos+l <= len(r.out) {
+		b := r.out[r.pos : r.pos+l]
+		r.pos += l
+		return b, nil
+	}
+
+	// Otherwise copy the data across the slices.
+
+	b := make([]byte, l)
+
+	for p := 0; p < l; {
+		if r.pos == len(r.out) {
+			r.next()
+		}
+		c := copy(b[p:], r.out[r.pos:])
+		r.pos += c
+		p += c
+	}
+
+	// Everything went ok.
+
+	return b, nil
+
+}
+
+func (r *Reader) discardFromSlices(n int) (int, error) {
+	for d := 0; ; {
+		c, err := r.discardFromBytes(n - d)
+		d += c
+		if err != io.EOF || !r.next() {
+			return d, err
+		}
+	}
+}
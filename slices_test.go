@@ -0,0 +1,125 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// pages splits the specified data into
+// pages of the specified size, including
+// some empty pages.
+
+func pages(b []byte, n int) [][]byte {
+	var p [][]byte
+	for len(b) > n {
+		p = append(p, b[:n], nil)
+		b = b[n:]
+	}
+	return append(p, b, []byte{})
+}
+
+func TestSlices(t *testing.T) {
+
+	Convey("Slices Reader should read across page boundaries", t, func() {
+		r := NewReaderSlices(pages(big, 333))
+		o := chunkReadBytes(r, len(big))
+		So(o, ShouldResemble, big)
+		_, err := r.ReadByte()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Slices Reader should read across page boundaries (as a string)", t, func() {
+		r := NewReaderSlices(pages(big, 7))
+		o := chunkReadString(r, len(big))
+		So(o, ShouldEqual, string(big))
+	})
+
+	Convey("Slices Reader should read single bytes across pages", t, func() {
+		r := NewReaderSlices(pages(txt, 3))
+		for i := range txt {
+			b, _ := r.PeekByte()
+			So(b, ShouldEqual, txt[i])
+			b, _ = r.ReadByte()
+			So(b, ShouldEqual, txt[i])
+		}
+		_, err := r.PeekByte()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Slices Reader should not copy reads within a single page", t, func() {
+		p := pages(big, 100)
+		r := NewReaderSlices(p)
+		o, _ := r.ReadBytes(100)
+		So(&o[0], ShouldEqual, &p[0][0])
+		o, _ = r.ReadBytes(50)
+		So(&o[0], ShouldEqual, &p[2][0])
+		o, _ = r.ReadBytes(100)
+		So(o, ShouldResemble, big[150:250])
+		So(&o[0], ShouldNotEqual, &p[2][50])
+	})
+
+	Convey("Slices Reader should not consume data when there is too little", t, func() {
+		r := NewReaderSlices(pages(txt, 10))
+		_, err := r.ReadBytes(len(txt) + 1)
+		So(err, ShouldEqual, io.EOF)
+		o, _ := r.ReadBytes(len(txt))
+		So(o, ShouldResemble, txt)
+	})
+
+	Convey("Slices Reader should discard and mirror across pages", t, func() {
+		c := bytes.NewBuffer(nil)
+		r := NewReaderSlices(pages(big, 100))
+		r.Tee(c)
+		n, err := r.Discard(250)
+		So(n, ShouldEqual, 250)
+		So(err, ShouldBeNil)
+		o, _ := r.ReadBytes(10)
+		So(o, ShouldResemble, big[250:260])
+		n, err = r.Discard(len(big))
+		So(n, ShouldEqual, len(big)-260)
+		So(err, ShouldEqual, io.EOF)
+		So(c.Bytes(), ShouldResemble, big)
+	})
+
+	Convey("Slices Reader should support sections", t, func() {
+		r := NewReaderSlices(pages(big, 100))
+		c := r.Limit(250)
+		o, _ := c.ReadBytes(150)
+		So(o, ShouldResemble, big[:150])
+		So(c.Close(), ShouldBeNil)
+		o, _ = r.ReadBytes(10)
+		So(o, ShouldResemble, big[250:260])
+	})
+
+	Convey("Slices Reader should be reusable", t, func() {
+		r := NewReaderSlices(nil)
+		_, err := r.ReadByte()
+		So(err, ShouldEqual, io.EOF)
+		for i := 0; i < 5; i++ {
+			r.ResetSlices(pages(txt, 10+i))
+			o := chunkReadBytes(r, len(txt))
+			So(o, ShouldResemble, txt)
+		}
+		r.ResetBytes(txt)
+		o := chunkReadBytes(r, len(txt))
+		So(o, ShouldResemble, txt)
+	})
+
+}
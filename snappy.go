@@ -0,0 +1,571 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// snappyBlockSize is the largest amount of
+	// uncompressed data in a single chunk.
+	snappyBlockSize = 65536
+	// snappyMaxEncoded is the largest size of a
+	// compressed block of snappyBlockSize bytes.
+	snappyMaxEncoded = 32 + snappyBlockSize + snappyBlockSize/6
+	// snappyTableBits is the size of the hash
+	// table which is used to find matches.
+	snappyTableBits = 14
+)
+
+// Chunk types of the framing format.
+const (
+	snappyCompressed   = 0x00
+	snappyUncompressed = 0x01
+	snappySkippable    = 0x80
+	snappyStream       = 0xff
+)
+
+// snappyMagic is the stream identifier chunk
+// which starts every framed stream.
+var snappyMagic = []byte("\xff\x06\x00\x00sNaPpY")
+
+type snappyCodec struct{}
+
+func (snappyCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level < HuffmanOnly || level > BestCompression {
+		return nil, ErrInvalidLevel
+	}
+	return &snappyWriter{
+		dst: w,
+		lvl: level,
+		buf: make([]byte, 0, snappyBlockSize),
+	}, nil
+}
+
+func (snappyCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return &snappyReader{src: r}, nil
+}
+
+// snappyWriter compresses data into chunks of
+// the Snappy framing format, each of which holds
+// up to snappyBlockSize bytes of data.
+
+type snappyWriter struct {
+	dst io.Writer
+	lvl int
+	hdr bool
+	buf []byte
+	out []byte
+	err error
+	tab [1 << snappyTableBits]uint16
+}
+
+func (w *snappyWriter) Write(p []byte) (int, error) {
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	t := 0
+
+	for len(p) > 0 {
+
+		// Compress whole blocks without copying them.
+
+		if len(w.buf) == 0 && len(p) >= snappyBlockSize {
+			if err := w.chunk(p[:snappyBlockSize]); err != nil {
+				return t, err
+			}
+			p = p[snappyBlockSize:]
+			t += snappyBlockSize
+			continue
+		}
+
+		// Otherwise buffer the data until a block is full.
+
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		t += n
+
+		if len(w.buf) == cap(w.buf) {
+			if err := w.chunk(w.buf); err != nil {
+				return t, err
+			}
+			w.buf = w.buf[:0]
+		}
+
+	}
+
+	// Everything went ok.
+
+	return t, nil
+
+}
+
+func (w *snappyWriter) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) > 0 {
+		if err := w.chunk(w.buf); err != nil {
+			return err
+		}
+		w.buf = w.buf[:0]
+	}
+	return nil
+}
+
+func (w *snappyWriter) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.err = ErrClosed
+	return nil
+}
+
+func (w *snappyWriter) Reset(i io.Writer) {
+	w.dst = i
+	w.hdr = false
+	w.buf = w.buf[:0]
+	w.err = nil
+}
+
+// chunk writes a block of data as a single chunk,
+// preceded by the stream identifier if this is
+// the first chunk. Data which does not compress
+// well is written uncompressed.
+
+func (w *snappyWriter) chunk(b []byte) error {
+
+	w.out = w.out[:0]
+
+	if !w.hdr {
+		w.out = append(w.out, snappyMagic...)
+		w.hdr = true
+	}
+
+	// Reserve space for the chunk header.
+
+	h := len(w.out)
+	w.out = append(w.out, 0, 0, 0, 0, 0, 0, 0, 0)
+
+	// Compress the data unless it would not save
+	// at least an eighth of its size.
+
+	t := byte(snappyUncompressed)
+
+	if w.lvl != NoCompression {
+		w.out = snappyEncode(w.out, b, &w.tab)
+		if len(w.out)-h-8 < len(b)-len(b)/8 {
+			t = snappyCompressed
+		} else {
+			w.out = w.out[:h+8]
+		}
+	}
+
+	if t == snappyUncompressed {
+		w.out = append(w.out, b...)
+	}
+
+	// Fill in the chunk header.
+
+	l := len(w.out) - h - 4
+	w.out[h] = t
+	w.out[h+1] = byte(l)
+	w.out[h+2] = byte(l >> 8)
+	w.out[h+3] = byte(l >> 16)
+	binary.LittleEndian.PutUint32(w.out[h+4:], snappyChecksum(b))
+
+	// Write the chunk in a single call.
+
+	n, err := w.dst.Write(w.out)
+	if err == nil && n < len(w.out) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		w.err = err
+		return err
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// snappyReader decompresses the chunks of
+// the Snappy framing format, checking the
+// checksum of each chunk.
+
+type snappyReader struct {
+	src io.Reader
+	mag bool
+	buf []byte
+	off int
+	cmp []byte
+	dec []byte
+	hdr [4]byte
+	err error
+}
+
+func (r *snappyReader) Read(p []byte) (int, error) {
+
+	// Move to the next chunk if needed.
+
+	for r.off >= len(r.buf) {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.next()
+	}
+
+	// Copy the data from the current chunk.
+
+	n := copy(p, r.buf[r.off:])
+	r.off += n
+
+	// Everything went ok.
+
+	return n, nil
+
+}
+
+func (r *snappyReader) Close() error {
+	r.err = ErrClosed
+	return nil
+}
+
+func (r *snappyReader) Reset(i io.Reader) error {
+	r.src = i
+	r.mag = false
+	r.buf = nil
+	r.off = 0
+	r.err = nil
+	return nil
+}
+
+// next reads chunks until one which contains
+// data is found, skipping padding and any
+// skippable chunks. Returns io.EOF at the end
+// of the stream, and ErrInvalidSnappy if the
+// stream is not valid.
+
+func (r *snappyReader) next() error {
+
+	for {
+
+		if _, err := io.ReadFull(r.src, r.hdr[:]); err != nil {
+			return err
+		}
+
+		t := r.hdr[0]
+		l := int(r.hdr[1]) | int(r.hdr[2])<<8 | int(r.hdr[3])<<16
+
+		// The stream must start with its identifier.
+
+		if !r.mag && t != snappyStream {
+			return ErrInvalidSnappy
+		}
+
+		switch {
+
+		case t == snappyStream:
+			if l != len(snappyMagic)-4 {
+				return ErrInvalidSnappy
+			}
+			if err := r.fill(l); err != nil {
+				return err
+			}
+			if string(r.cmp) != string(snappyMagic[4:]) {
+				return ErrInvalidSnappy
+			}
+			r.mag = true
+
+		case t == snappyCompressed || t == snappyUncompressed:
+			if l < 4 || l-4 > snappyMaxEncoded {
+				return ErrInvalidSnappy
+			}
+			if err := r.fill(l); err != nil {
+				return err
+			}
+			if t == snappyCompressed {
+				d, err := snappyDecode(r.dec, r.cmp[4:])
+				if err != nil {
+					return err
+				}
+				r.dec, r.buf = d, d
+			} else {
+				if l-4 > snappyBlockSize {
+					return ErrInvalidSnappy
+				}
+				r.buf = r.cmp[4:]
+			}
+			r.off = 0
+			if snappyChecksum(r.buf) != binary.LittleEndian.Uint32(r.cmp) {
+				r.buf = nil
+				return ErrChecksumMismatch
+			}
+			return nil
+
+		case t >= snappySkippable:
+			if n, _ := io.CopyN(io.Discard, r.src, int64(l)); n < int64(l) {
+				return io.ErrUnexpectedEOF
+			}
+
+		default:
+			return ErrInvalidSnappy
+
+		}
+
+	}
+
+}
+
+// fill reads the body of a chunk.
+
+func (r *snappyReader) fill(l int) error {
+	if cap(r.cmp) < l {
+		r.cmp = make([]byte, l, snappyMaxEncoded+4)
+	}
+	r.cmp = r.cmp[:l]
+	_, err := io.ReadFull(r.src, r.cmp)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// snappyChecksum returns the masked CRC-32C
+// checksum of a chunk of uncompressed data.
+
+func snappyChecksum(b []byte) uint32 {
+	c := crc32.Checksum(b, castagnoli)
+	return (c>>15 | c<<17) + 0xa282ead8
+}
+
+// snappyHash hashes four bytes of data into
+// an index in the match table.
+
+func snappyHash(u uint32) uint32 {
+	return u * 0x1e35a7bd >> (32 - snappyTableBits)
+}
+
+// snappyEncode appends a block of at most
+// snappyBlockSize bytes to dst in the Snappy
+// block format. Each position is hashed by its
+// next four bytes, and any earlier position with
+// the same four bytes is used as a match.
+
+func snappyEncode(dst, src []byte, tab *[1 << snappyTableBits]uint16) []byte {
+
+	var hdr [binary.MaxVarintLen64]byte
+
+	dst = append(dst, hdr[:binary.PutUvarint(hdr[:], uint64(len(src)))]...)
+
+	// Short blocks are written as a literal.
+
+	if len(src) < 17 {
+		return snappyLiteral(dst, src)
+	}
+
+	for i := range tab {
+		tab[i] = 0
+	}
+
+	// Stop looking for matches near the end, so
+	// that four bytes can always be loaded.
+
+	lim := len(src) - 15
+	nxt := 0
+	skp := 32
+
+	for s := 1; s <= lim; {
+
+		u := binary.LittleEndian.Uint32(src[s:])
+		h := snappyHash(u)
+		c := int(tab[h])
+		tab[h] = uint16(s)
+
+		// Step further each time nothing matches,
+		// so that incompressible data is fast.
+
+		if u != binary.LittleEndian.Uint32(src[c:]) {
+			s += skp >> 5
+			skp++
+			continue
+		}
+
+		// Write the data before the match, and
+		// then extend the match forwards.
+
+		dst = snappyLiteral(dst, src[nxt:s])
+
+		b, o := s, s-c
+		for s, c = s+4, c+4; s < len(src) && src[s] == src[c]; s, c = s+1, c+1 {
+		}
+
+		dst = snappyCopy(dst, o, s-b)
+		nxt, skp = s, 32
+
+	}
+
+	// Write any remaining data as a literal.
+
+	return snappyLiteral(dst, src[nxt:])
+
+}
+
+// snappyLiteral appends a literal element.
+
+func snappyLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := len(lit) - 1
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2)
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	default:
+		dst = append(dst, 62<<2, byte(n), byte(n>>8), byte(n>>16))
+	}
+	return append(dst, lit...)
+}
+
+// snappyCopy appends copy elements for a match
+// of the specified length, at the specified
+// offset, which is less than snappyBlockSize.
+
+func snappyCopy(dst []byte, o, l int) []byte {
+	for l >= 68 {
+		dst = append(dst, 63<<2|2, byte(o), byte(o>>8))
+		l -= 64
+	}
+	if l > 64 {
+		dst = append(dst, 59<<2|2, byte(o), byte(o>>8))
+		l -= 60
+	}
+	if l >= 12 || o >= 2048 {
+		return append(dst, byte(l-1)<<2|2, byte(o), byte(o>>8))
+	}
+	return append(dst, byte(o>>8)<<5|byte(l-4)<<2|1, byte(o))
+}
+
+// snappyDecode decodes a block in the Snappy
+// block format into dst, reusing its space if
+// possible. Returns ErrInvalidSnappy if the
+// block is not valid, or decodes to more than
+// snappyBlockSize bytes.
+
+func snappyDecode(dst, src []byte) ([]byte, error) {
+
+	v, s := binary.Uvarint(src)
+	if s <= 0 || v > snappyBlockSize {
+		return nil, ErrInvalidSnappy
+	}
+
+	if cap(dst) < int(v) {
+		dst = make([]byte, v, snappyBlockSize)
+	}
+	dst = dst[:v]
+
+	d := 0
+
+	for s < len(src) {
+
+		var l, o int
+
+		switch t := src[s]; t & 3 {
+
+		case 0:
+			l = int(t >> 2)
+			s++
+			if l >= 60 {
+				w := l - 59
+				if w > len(src)-s {
+					return nil, ErrInvalidSnappy
+				}
+				l = 0
+				for i := 0; i < w; i++ {
+					l |= int(src[s+i]) << (8 * i)
+				}
+				s += w
+			}
+			l++
+			if l > len(dst)-d || l > len(src)-s {
+				return nil, ErrInvalidSnappy
+			}
+			copy(dst[d:], src[s:s+l])
+			d += l
+			s += l
+			continue
+
+		case 1:
+			if len(src)-s < 2 {
+				return nil, ErrInvalidSnappy
+			}
+			l = 4 + int(t>>2&7)
+			o = int(t&0xe0)<<3 | int(src[s+1])
+			s += 2
+
+		case 2:
+			if len(src)-s < 3 {
+				return nil, ErrInvalidSnappy
+			}
+			l = 1 + int(t>>2)
+			o = int(binary.LittleEndian.Uint16(src[s+1:]))
+			s += 3
+
+		case 3:
+			if len(src)-s < 5 {
+				return nil, ErrInvalidSnappy
+			}
+			l = 1 + int(t>>2)
+			o = int(binary.LittleEndian.Uint32(src[s+1:]))
+			s += 5
+
+		}
+
+		// Copy the match byte by byte, as it
+		// may overlap the data being written.
+
+		if o <= 0 || o > d || l > len(dst)-d {
+			return nil, ErrInvalidSnappy
+		}
+
+		for i := d; i < d+l; i++ {
+			dst[i] = dst[i-o]
+		}
+
+		d += l
+
+	}
+
+	if d != len(dst) {
+		return nil, ErrInvalidSnappy
+	}
+
+	// Everything went ok.
+
+	return dst, nil
+
+}
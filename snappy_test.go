@@ -0,0 +1,128 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+	"testing/quick"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// snappyCompress compresses data at the
+// specified level, using the Snappy codec.
+
+func snappyCompress(v []byte, level int) []byte {
+	b := bytes.NewBuffer(nil)
+	w, _ := NewCompressedWriter(b, Snappy, level)
+	w.WriteBytes(v)
+	w.Close()
+	return b.Bytes()
+}
+
+// snappyDecompress decompresses all of
+// the data in a Snappy framed stream.
+
+func snappyDecompress(v []byte) ([]byte, error) {
+	z, _ := Snappy.NewReader(iotest.HalfReader(bytes.NewReader(v)))
+	return ioutil.ReadAll(z)
+}
+
+func TestSnappy(t *testing.T) {
+
+	Convey("Snappy should decode blocks with literals and copies", t, func() {
+		v, err := snappyDecode(nil, []byte{10, 0x00, 'a', 0x15, 0x01})
+		So(err, ShouldBeNil)
+		So(string(v), ShouldEqual, "aaaaaaaaaa")
+		v, err = snappyDecode(nil, []byte{7, 0x04, 'a', 'b', 0x0a, 0x02, 0x00, 0x07, 0x03, 0x00, 0x00, 0x00})
+		So(err, ShouldBeNil)
+		So(string(v), ShouldEqual, "ababaab")
+	})
+
+	Convey("Snappy should write the stream identifier and masked checksums", t, func() {
+		c := crc32.Checksum([]byte("hello"), crc32.MakeTable(crc32.Castagnoli))
+		c = (c>>15 | c<<17) + 0xa282ead8
+		So(snappyCompress([]byte("hello"), DefaultCompression), ShouldResemble, []byte{
+			0xff, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y',
+			0x01, 0x09, 0x00, 0x00, byte(c), byte(c >> 8), byte(c >> 16), byte(c >> 24),
+			'h', 'e', 'l', 'l', 'o',
+		})
+		So(snappyCompress(nil, DefaultCompression), ShouldBeEmpty)
+	})
+
+	Convey("Snappy should round trip data of any size", t, func() {
+		for _, n := range []int{0, 1, 16, 17, 100, 65535, 65536, 65537, len(big), 3 * 65536} {
+			v := bytes.Repeat(big, 4)[:n]
+			for _, l := range []int{NoCompression, BestSpeed, DefaultCompression} {
+				o, err := snappyDecompress(snappyCompress(v, l))
+				So(err, ShouldBeNil)
+				So(bytes.Equal(o, v), ShouldBeTrue)
+			}
+		}
+		So(quick.Check(func(v []byte) bool {
+			o, err := snappyDecompress(snappyCompress(v, DefaultCompression))
+			return err == nil && bytes.Equal(o, v)
+		}, nil), ShouldBeNil)
+	})
+
+	Convey("Snappy should compress repetitive data", t, func() {
+		So(len(snappyCompress(big, DefaultCompression)), ShouldBeLessThan, len(big)/10)
+		So(len(snappyCompress(big, NoCompression)), ShouldBeGreaterThan, len(big))
+	})
+
+	Convey("Snappy should skip padding and skippable chunks", t, func() {
+		b := snappyCompress(txt, DefaultCompression)
+		b = append(b[:10:10], append([]byte{0xfe, 0x02, 0x00, 0x00, 0, 0, 0x80, 0x00, 0x00, 0x00}, b[10:]...)...)
+		o, err := snappyDecompress(b)
+		So(err, ShouldBeNil)
+		So(o, ShouldResemble, txt)
+	})
+
+	Convey("Snappy should return errors for invalid streams", t, func() {
+		b := snappyCompress(txt, NoCompression)
+		_, err := snappyDecompress(b[10:])
+		So(err, ShouldEqual, ErrInvalidSnappy)
+		_, err = snappyDecompress(b[:len(b)-1])
+		So(err, ShouldEqual, io.ErrUnexpectedEOF)
+		c := append([]byte(nil), b...)
+		c[len(c)-1]++
+		_, err = snappyDecompress(c)
+		So(err, ShouldEqual, ErrChecksumMismatch)
+		c = append(b[:10:10], 0x02, 0x00, 0x00, 0x00)
+		_, err = snappyDecompress(c)
+		So(err, ShouldEqual, ErrInvalidSnappy)
+		_, err = snappyDecode(nil, []byte{4, 0x01, 0x00})
+		So(err, ShouldEqual, ErrInvalidSnappy)
+		_, err = snappyDecode(nil, []byte{2, 0x04, 'a'})
+		So(err, ShouldEqual, ErrInvalidSnappy)
+		_, err = snappyDecode(nil, []byte{0x80, 0x80, 0x08})
+		So(err, ShouldEqual, ErrInvalidSnappy)
+		_, err = NewCompressedWriter(bytes.NewBuffer(nil), Snappy, 100)
+		So(err, ShouldEqual, ErrInvalidLevel)
+	})
+
+	Convey("Snappy should reject invalid blocks without panicking", t, func() {
+		So(quick.Check(func(v []byte) bool {
+			o, err := snappyDecode(nil, v)
+			return err != nil || len(o) <= snappyBlockSize
+		}, nil), ShouldBeNil)
+	})
+
+}
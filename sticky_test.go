@@ -0,0 +1,114 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err == io.EOF {
+		return n, e.err
+	}
+	return n, err
+}
+
+func TestSticky(t *testing.T) {
+
+	Convey("Writer should return the same error after the underlying io.Writer fails", t, func() {
+		b := &syncBuffer{}
+		w := NewWriter(b)
+		So(w.WriteBytes(txt), ShouldBeNil)
+		So(w.Err(), ShouldBeNil)
+		b.err = errors.New("disk full")
+		So(w.Flush(), ShouldEqual, b.err)
+		So(w.Err(), ShouldEqual, b.err)
+		b.err = nil
+		So(w.WriteByte(1), ShouldEqual, w.Err())
+		So(w.WriteBytes(txt), ShouldEqual, w.Err())
+		So(w.WriteString("test"), ShouldEqual, w.Err())
+		So(w.Flush(), ShouldEqual, w.Err())
+		So(b.Len(), ShouldEqual, 0)
+		e := w.Err()
+		So(w.Close(), ShouldEqual, e)
+		So(w.Err(), ShouldEqual, ErrClosed)
+	})
+
+	Convey("Writer should allow errors to be checked once after many writes", t, func() {
+		b := &syncBuffer{err: errors.New("disk full")}
+		w := NewWriter(b)
+		for i := 0; i < 100; i++ {
+			w.WriteBytes(txt)
+			w.WriteString("test")
+			w.WriteByte('\n')
+		}
+		So(w.Err(), ShouldEqual, b.err)
+	})
+
+	Convey("Writer should clear the error when reset", t, func() {
+		b := &syncBuffer{err: errors.New("disk full")}
+		w := NewWriter(b)
+		w.WriteBytes(big)
+		So(w.Err(), ShouldNotBeNil)
+		c := bytes.NewBuffer(nil)
+		w.Reset(c)
+		So(w.Err(), ShouldBeNil)
+		So(w.WriteBytes(big), ShouldBeNil)
+		So(w.Flush(), ShouldBeNil)
+		So(c.Bytes(), ShouldResemble, big)
+	})
+
+	Convey("Reader should return the same error after the underlying io.Reader fails", t, func() {
+		e := errors.New("connection reset")
+		r := NewReader(&errReader{bytes.NewReader(txt), e})
+		o, err := r.ReadBytes(len(txt))
+		So(err, ShouldBeNil)
+		So(o, ShouldResemble, txt)
+		_, err = r.ReadByte()
+		So(err, ShouldEqual, e)
+		_, err = r.PeekByte()
+		So(err, ShouldEqual, e)
+		_, err = r.ReadString(1)
+		So(err, ShouldEqual, e)
+		So(r.Err(), ShouldEqual, e)
+		r.Reset(bytes.NewReader(txt))
+		So(r.Err(), ShouldBeNil)
+		o, err = r.ReadBytes(len(txt))
+		So(err, ShouldBeNil)
+		So(o, ShouldResemble, txt)
+	})
+
+	Convey("Reader should not fail when reading past the end of a byte slice", t, func() {
+		r := NewReaderBytes(txt)
+		_, err := r.ReadBytes(len(txt) + 1)
+		So(err, ShouldEqual, io.EOF)
+		So(r.Err(), ShouldBeNil)
+		o, err := r.ReadBytes(len(txt))
+		So(err, ShouldBeNil)
+		So(o, ShouldResemble, txt)
+	})
+
+}
@@ -0,0 +1,235 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"sync"
+)
+
+// SyncWriter represents a Writer which can be
+// shared safely between multiple goroutines.
+// Data is written in transactions, which are
+// never interleaved with other transactions.
+type SyncWriter struct {
+	mtx sync.RWMutex
+	wtr *Writer
+	lck sync.Mutex
+	err error
+	cls bool
+	que chan *syncTxn
+	don chan struct{}
+}
+
+// syncTxn represents a single transaction, which
+// is encoded into its own byte slice before it
+// is written to the shared Writer.
+
+type syncTxn struct {
+	w Writer
+	b []byte
+	a chan error
+}
+
+var syncTxns = sync.Pool{
+	New: func() interface{} {
+		return new(syncTxn)
+	},
+}
+
+// NewSyncWriter creates a new SyncWriter which
+// writes each transaction to the specified Writer
+// while holding a lock.
+func NewSyncWriter(w *Writer) *SyncWriter {
+	return &SyncWriter{wtr: w}
+}
+
+// NewSyncWriterQueue creates a new SyncWriter which
+// writes each transaction to the specified Writer
+// from a single background goroutine, fed by a
+// queue of the specified size. The Writer is
+// flushed whenever the queue becomes empty. If
+// the queue is full, transactions block until
+// there is space in the queue.
+func NewSyncWriterQueue(w *Writer, n int) *SyncWriter {
+	s := &SyncWriter{
+		wtr: w,
+		que: make(chan *syncTxn, n),
+		don: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Txn runs the specified function with a Writer
+// which buffers a single transaction. If the
+// function returns nil, then the transaction is
+// written to the shared Writer in its entirety,
+// without being interleaved with any other
+// transaction. If the function returns an error,
+// then nothing is written, and the error is
+// returned. The Writer must not be retained
+// after the function returns.
+func (s *SyncWriter) Txn(fn func(tx *Writer) error) error {
+
+	// Encode the transaction into its own buffer.
+
+	t := syncTxns.Get().(*syncTxn)
+	t.b = t.b[:0]
+	t.w.ResetBytes(&t.b)
+
+	err := fn(&t.w)
+	if err != nil {
+		syncTxns.Put(t)
+		return err
+	}
+
+	// Write the transaction to the shared Writer.
+
+	if s.que == nil {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		defer syncTxns.Put(t)
+		if s.cls {
+			return ErrClosed
+		}
+		return s.wtr.WriteBytes(t.b)
+	}
+
+	// Or queue the transaction for the flusher.
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.cls {
+		syncTxns.Put(t)
+		return ErrClosed
+	}
+	if err := s.fault(); err != nil {
+		syncTxns.Put(t)
+		return err
+	}
+	s.que <- t
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// Flush flushes any buffered data to the io.Writer
+// underlying the shared Writer. If a background
+// flusher is used, then this waits until all of
+// the previously queued transactions are written.
+func (s *SyncWriter) Flush() error {
+
+	// Flush the shared Writer while holding the lock.
+
+	if s.que == nil {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		if s.cls {
+			return ErrClosed
+		}
+		return s.wtr.Flush()
+	}
+
+	// Or wait for the flusher to flush the Writer.
+
+	s.mtx.RLock()
+	if s.cls {
+		s.mtx.RUnlock()
+		return ErrClosed
+	}
+	a := make(chan error, 1)
+	s.que <- &syncTxn{a: a}
+	s.mtx.RUnlock()
+
+	return <-a
+
+}
+
+// Close waits for any queued transactions to be
+// written, and then closes the shared Writer.
+func (s *SyncWriter) Close() error {
+
+	s.mtx.Lock()
+
+	// Ensure the SyncWriter is not already closed.
+
+	if s.cls {
+		s.mtx.Unlock()
+		return ErrClosed
+	}
+
+	s.cls = true
+
+	// Close the shared Writer while holding the lock.
+
+	if s.que == nil {
+		defer s.mtx.Unlock()
+		return s.wtr.Close()
+	}
+
+	// Or wait for the flusher to finish first.
+
+	close(s.que)
+	s.mtx.Unlock()
+	<-s.don
+
+	return s.wtr.Close()
+
+}
+
+func (s *SyncWriter) run() {
+
+	defer close(s.don)
+
+	for t := range s.que {
+
+		// Flush the Writer if this is a flush request.
+
+		if t.a != nil {
+			t.a <- s.fail(s.wtr.Flush())
+			continue
+		}
+
+		// Write the transaction to the Writer.
+
+		s.fail(s.wtr.WriteBytes(t.b))
+		syncTxns.Put(t)
+
+		// Flush the Writer if the queue is empty.
+
+		if len(s.que) == 0 {
+			s.fail(s.wtr.Flush())
+		}
+
+	}
+
+}
+
+func (s *SyncWriter) fail(err error) error {
+	if err != nil {
+		s.lck.Lock()
+		defer s.lck.Unlock()
+		s.err = err
+	}
+	return err
+}
+
+func (s *SyncWriter) fault() error {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+	return s.err
+}
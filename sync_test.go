@@ -0,0 +1,135 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func syncWrite(s *SyncWriter, goroutines, messages int) {
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for m := 0; m < messages; m++ {
+				s.Txn(func(tx *Writer) error {
+					tx.WriteByte(byte(g))
+					for i := 0; i < 10; i++ {
+						tx.WriteBytes(txt[:m%50])
+					}
+					return tx.WriteByte(byte(g))
+				})
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func syncCheck(b []byte, goroutines, messages int) {
+	r := NewReaderBytes(b)
+	c := make([]int, goroutines)
+	for i := 0; i < goroutines*messages; i++ {
+		g, err := r.ReadByte()
+		So(err, ShouldBeNil)
+		m := c[g]
+		for i := 0; i < 10; i++ {
+			o, _ := r.ReadBytes(m % 50)
+			So(o, ShouldResemble, txt[:m%50])
+		}
+		e, _ := r.ReadByte()
+		So(e, ShouldEqual, g)
+		c[g]++
+	}
+	_, err := r.ReadByte()
+	So(err, ShouldNotBeNil)
+}
+
+func TestSyncWriter(t *testing.T) {
+
+	Convey("SyncWriter should never interleave transactions", t, func() {
+		b := &syncBuffer{}
+		s := NewSyncWriter(NewWriter(b))
+		syncWrite(s, 8, 100)
+		So(s.Close(), ShouldBeNil)
+		syncCheck(b.Bytes(), 8, 100)
+	})
+
+	Convey("SyncWriter should never interleave transactions (using a background flusher)", t, func() {
+		b := &syncBuffer{}
+		s := NewSyncWriterQueue(NewWriter(b), 4)
+		syncWrite(s, 8, 100)
+		So(s.Close(), ShouldBeNil)
+		syncCheck(b.Bytes(), 8, 100)
+	})
+
+	Convey("SyncWriter should not write anything from a failed transaction", t, func() {
+		b := &syncBuffer{}
+		s := NewSyncWriter(NewWriter(b))
+		e := errors.New("failed")
+		So(s.Txn(func(tx *Writer) error {
+			tx.WriteBytes(txt)
+			return e
+		}), ShouldEqual, e)
+		So(s.Flush(), ShouldBeNil)
+		So(b.Len(), ShouldEqual, 0)
+	})
+
+	Convey("SyncWriter should flush all queued transactions", t, func() {
+		b := &syncBuffer{}
+		w := NewWriter(b)
+		s := NewSyncWriterQueue(w, 100)
+		for i := 0; i < 50; i++ {
+			So(s.Txn(func(tx *Writer) error {
+				return tx.WriteBytes(txt[:10])
+			}), ShouldBeNil)
+		}
+		So(s.Flush(), ShouldBeNil)
+		So(b.Len(), ShouldEqual, 500)
+		So(s.Close(), ShouldBeNil)
+	})
+
+	Convey("SyncWriter should return errors from the background flusher", t, func() {
+		b := &syncBuffer{err: errors.New("disk full")}
+		s := NewSyncWriterQueue(NewWriter(b), 1)
+		s.Txn(func(tx *Writer) error {
+			return tx.WriteBytes(txt)
+		})
+		So(s.Flush(), ShouldEqual, b.err)
+		So(s.Txn(func(tx *Writer) error {
+			return tx.WriteBytes(txt)
+		}), ShouldEqual, b.err)
+	})
+
+	Convey("SyncWriter should return ErrClosed after closing", t, func() {
+		for _, s := range []*SyncWriter{
+			NewSyncWriter(NewWriter(bytes.NewBuffer(nil))),
+			NewSyncWriterQueue(NewWriter(bytes.NewBuffer(nil)), 1),
+		} {
+			So(s.Close(), ShouldBeNil)
+			So(s.Close(), ShouldEqual, ErrClosed)
+			So(s.Flush(), ShouldEqual, ErrClosed)
+			So(s.Txn(func(tx *Writer) error {
+				return nil
+			}), ShouldEqual, ErrClosed)
+		}
+	})
+
+}
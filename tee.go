@@ -0,0 +1,115 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"io"
+)
+
+// Tee instructs the Writer to mirror all data
+// written from this point onwards to the specified
+// io.Writer, such as a hash.Hash. Passing nil
+// disables mirroring. Data is mirrored once it
+// has been written, so data which fails to be
+// written is not mirrored. Any error returned by
+// the io.Writer is returned from the write call,
+// and from all later calls on the Writer.
+func (w *Writer) Tee(h io.Writer) {
+	w.tee = h
+}
+
+// Tee instructs the Reader to mirror all data
+// read from this point onwards to the specified
+// io.Writer, such as a hash.Hash. Passing nil
+// disables mirroring. Peeked bytes are not
+// mirrored until they have been read. Any error
+// returned by the io.Writer is returned from
+// the read call, and from all later calls on
+// the Reader.
+func (r *Reader) Tee(h io.Writer) {
+	r.tee = h
+}
+
+func (w *Writer) mirror(v []byte) error {
+	if w.sum != nil {
+		w.sum.Write(v)
+	}
+	if w.tee != nil {
+		_, err := w.tee.Write(v)
+		if err != nil {
+			w.err = err
+		}
+		return err
+	}
+	return nil
+}
+
+func (w *Writer) mirrorString(v string) error {
+	if w.sum != nil {
+		io.WriteString(w.sum, v)
+	}
+	if w.tee != nil {
+		_, err := io.WriteString(w.tee, v)
+		if err != nil {
+			w.err = err
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Reader) mirrorByte(b byte, err error) (byte, error) {
+	if err == nil {
+		r.tmp[0] = b
+		err = r.mirror(r.tmp[:1])
+	}
+	return b, err
+}
+
+func (r *Reader) mirrorBytes(b []byte, err error) ([]byte, error) {
+	if err == nil {
+		err = r.mirror(b)
+	}
+	return b, err
+}
+
+func (r *Reader) mirrorString(s string, err error) (string, error) {
+	if err == nil {
+		if r.sum != nil {
+			io.WriteString(r.sum, s)
+		}
+		if r.tee != nil {
+			_, err = io.WriteString(r.tee, s)
+			if err != nil {
+				r.err = err
+			}
+		}
+	}
+	return s, err
+}
+
+func (r *Reader) mirror(b []byte) error {
+	if r.sum != nil {
+		r.sum.Write(b)
+	}
+	if r.tee != nil {
+		_, err := r.tee.Write(b)
+		if err != nil {
+			r.err = err
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("failed")
+}
+
+func TestTee(t *testing.T) {
+
+	Convey("Writer should mirror all written data to an io.Writer", t, func() {
+		b := bytes.NewBuffer(nil)
+		c := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		w.Tee(c)
+		chunkWriteString(w, string(txt))
+		w.Flush()
+		So(c.Bytes(), ShouldResemble, txt)
+		So(b.Bytes(), ShouldResemble, txt)
+	})
+
+	Convey("Writer should mirror all written data to an io.Writer (writing to a byte slice)", t, func() {
+		var b []byte
+		h := sha256.New()
+		w := NewWriterBytes(&b)
+		w.Tee(h)
+		chunkWriteBytes(w, txt)
+		s := sha256.Sum256(txt)
+		So(h.Sum(nil), ShouldResemble, s[:])
+	})
+
+	Convey("Reader should mirror only consumed data to an io.Writer", t, func() {
+		c := bytes.NewBuffer(nil)
+		r := NewReader(bytes.NewReader(txt))
+		r.Tee(c)
+		r.PeekByte()
+		So(c.Len(), ShouldEqual, 0)
+		o := chunkReadBytes(r, len(txt))
+		So(o, ShouldResemble, txt)
+		So(c.Bytes(), ShouldResemble, txt)
+	})
+
+	Convey("Reader should mirror only consumed data to an io.Writer (reading from a byte slice)", t, func() {
+		h := sha256.New()
+		r := NewReaderBytes(txt)
+		r.Tee(h)
+		chunkReadString(r, len(txt))
+		s := sha256.Sum256(txt)
+		So(h.Sum(nil), ShouldResemble, s[:])
+	})
+
+	Convey("Tee should include checksum bytes which are written and read", t, func() {
+		var b []byte
+		c := bytes.NewBuffer(nil)
+		w := NewWriterBytes(&b)
+		w.Checksum(NewCRC32C())
+		w.Tee(c)
+		w.WriteBytes(txt)
+		w.WriteChecksum()
+		So(c.Bytes(), ShouldResemble, b)
+		c.Reset()
+		r := NewReaderBytes(b)
+		r.Checksum(NewCRC32C())
+		r.Tee(c)
+		r.ReadBytes(len(txt))
+		So(r.ReadChecksum(), ShouldBeNil)
+		So(c.Bytes(), ShouldResemble, b)
+	})
+
+	Convey("Tee should return errors from the mirrored io.Writer", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.Tee(failWriter{})
+		So(w.WriteByte(1), ShouldNotBeNil)
+		So(w.WriteString("test"), ShouldNotBeNil)
+		r := NewReaderBytes(txt)
+		r.Tee(failWriter{})
+		_, e := r.ReadBytes(5)
+		So(e, ShouldNotBeNil)
+		r.Tee(nil)
+		_, e = r.ReadBytes(5)
+		So(e, ShouldNotBeNil)
+		r.ResetBytes(txt)
+		_, e = r.ReadBytes(5)
+		So(e, ShouldBeNil)
+	})
+
+	Convey("Tee should not mirror data which failed to be written", t, func() {
+		c := bytes.NewBuffer(nil)
+		h := NewCRC32C()
+		w := NewWriter(failWriter{})
+		w.Checksum(h)
+		w.Tee(c)
+		So(w.WriteBytes(txt[:10]), ShouldBeNil)
+		So(w.WriteBytes(big), ShouldNotBeNil)
+		So(w.WriteString("test"), ShouldNotBeNil)
+		So(w.WriteByte(1), ShouldNotBeNil)
+		So(c.Bytes(), ShouldResemble, txt[:10])
+		s := NewCRC32C()
+		s.Write(txt[:10])
+		So(h.Sum(nil), ShouldResemble, s.Sum(nil))
+	})
+
+}
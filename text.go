@@ -0,0 +1,250 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// encoder encodes whole blocks of data as text,
+// and decoder decodes them, returning the number
+// of decoded bytes.
+
+type encoder func(dst, src []byte)
+
+type decoder func(dst, src []byte) (int, error)
+
+// hexEncode adapts hex.Encode to an encoder.
+
+func hexEncode(dst, src []byte) {
+	hex.Encode(dst, src)
+}
+
+// WriteHex writes data as lowercase hexadecimal
+// text, encoding it directly into the buffer, or
+// byte slice, without any intermediate copy.
+func (w *Writer) WriteHex(v []byte) error {
+	return w.writeText(v, 1, hex.EncodedLen, hexEncode)
+}
+
+// WriteBase64 writes data as base64 text using the
+// specified encoding, such as base64.StdEncoding,
+// base64.URLEncoding, or one of the raw encodings,
+// encoding it directly into the buffer, or byte
+// slice, without any intermediate copy. The data
+// is padded as a whole, so any number of bytes can
+// be written with one call.
+func (w *Writer) WriteBase64(e *base64.Encoding, v []byte) error {
+	return w.writeText(v, 3, e.EncodedLen, e.Encode)
+}
+
+// WriteBase32 writes data as base32 text using the
+// specified encoding, such as base32.StdEncoding,
+// base32.HexEncoding, or an encoding without padding,
+// encoding it directly into the buffer, or byte
+// slice, without any intermediate copy.
+func (w *Writer) WriteBase32(e *base32.Encoding, v []byte) error {
+	return w.writeText(v, 5, e.EncodedLen, e.Encode)
+}
+
+// ReadHex reads hexadecimal text, and returns
+// the specified number of decoded bytes. Returns
+// ErrInvalidText if the text is not valid.
+func (r *Reader) ReadHex(n int) ([]byte, error) {
+	return r.readText(n, 1, hex.EncodedLen, hex.Decode)
+}
+
+// ReadBase64 reads base64 text using the specified
+// encoding, and returns the specified number of
+// decoded bytes, which must be the number of bytes
+// which were written. Returns ErrInvalidText if the
+// text is not valid.
+func (r *Reader) ReadBase64(e *base64.Encoding, n int) ([]byte, error) {
+	return r.readText(n, 3, e.EncodedLen, e.Decode)
+}
+
+// ReadBase32 reads base32 text using the specified
+// encoding, and returns the specified number of
+// decoded bytes, which must be the number of bytes
+// which were written. Returns ErrInvalidText if the
+// text is not valid.
+func (r *Reader) ReadBase32(e *base32.Encoding, n int) ([]byte, error) {
+	return r.readText(n, 5, e.EncodedLen, e.Decode)
+}
+
+// writeText encodes data in chunks of whole blocks
+// of the specified size, straight into the space
+// which is available in the buffer, flushing it
+// each time it is full. When writing to a byte
+// slice it is grown, and all of the data is
+// encoded in one go.
+
+func (w *Writer) writeText(v []byte, blk int, size func(int) int, enc encoder) error {
+
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+
+	if w.err != nil || w.asy != nil && w.asy.failed() {
+		return w.fault()
+	}
+
+	// Encode directly into the byte slice.
+
+	if w.out != nil {
+		n := size(len(v))
+		if w.pos+n >= len(*w.out) {
+			if w.pos+n < cap(*w.out) {
+				*w.out = (*w.out)[:w.pos+n]
+			} else {
+				bs := make([]byte, len(*w.out)+n, len(*w.out)+n+writerSize)
+				copy(bs, (*w.out)[:w.pos])
+				*w.out = bs
+			}
+		}
+		t := (*w.out)[w.pos : w.pos+n]
+		enc(t, v)
+		if w.sum != nil || w.tee != nil {
+			if err := w.mirror(t); err != nil {
+				*w.out = (*w.out)[:w.pos]
+				return err
+			}
+		}
+		w.pos += n
+		*w.out = (*w.out)[:w.pos]
+		return nil
+	}
+
+	// Initialise the underlying buffer if needed.
+
+	if w.buf == nil {
+		w.buf = w.arr[0:]
+	}
+
+	// Encode as many whole blocks as fit in the
+	// buffer, and flush it when it is full.
+
+	for len(v) > 0 {
+
+		m := (len(w.buf) - w.pos) / size(blk) * blk
+		if m > len(v) {
+			m = len(v)
+		}
+
+		// When less than one block fits, encode a
+		// single block into the scratch space, and
+		// write it in the usual way, which fills
+		// the buffer before flushing or rotating it.
+
+		if m == 0 {
+			if m = blk; m > len(v) {
+				m = len(v)
+			}
+			t := w.tmp[:size(m)]
+			enc(t, v[:m])
+			if err := w.writeBytesToWriter(t); err != nil {
+				return err
+			}
+			if w.sum != nil || w.tee != nil {
+				if err := w.mirror(t); err != nil {
+					return err
+				}
+			}
+			v = v[m:]
+			continue
+		}
+
+		n := size(m)
+		t := w.buf[w.pos : w.pos+n]
+		enc(t, v[:m])
+
+		if w.sum != nil || w.tee != nil {
+			if err := w.mirror(t); err != nil {
+				return err
+			}
+		}
+
+		w.pos += n
+		v = v[m:]
+
+	}
+
+	// Everything went ok.
+
+	return w.policy(false)
+
+}
+
+// readText decodes the specified number of bytes,
+// from text in blocks of the specified size. Whole
+// blocks are decoded straight from the data which
+// is buffered, and blocks which are split across
+// two fills of the buffer are read separately.
+
+func (r *Reader) readText(n, blk int, size func(int) int, dec decoder) ([]byte, error) {
+
+	b := make([]byte, n)
+
+	for d := b; len(d) > 0; {
+
+		p, err := r.PeekBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		// Find the whole blocks which are buffered.
+
+		m := len(p) / size(blk) * blk
+		if m > len(d) {
+			m = len(d)
+		}
+
+		buf := m > 0
+
+		if buf {
+			p = p[:size(m)]
+		} else {
+			if m = blk; m > len(d) {
+				m = len(d)
+			}
+			if p, err = r.readFixed(size(m)); err != nil {
+				return nil, err
+			}
+		}
+
+		// Decode the blocks into the output.
+
+		if c, err := dec(d[:m], p); err != nil || c != m {
+			return nil, ErrInvalidText
+		}
+
+		if buf {
+			if _, err := r.Discard(len(p)); err != nil {
+				return nil, err
+			}
+		}
+
+		d = d[m:]
+
+	}
+
+	// Everything went ok.
+
+	return b, nil
+
+}
@@ -0,0 +1,175 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+	"testing/iotest"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// textCodec writes and reads data using one
+// of the text encodings, and encodes it
+// using the standard library.
+
+type textCodec struct {
+	name string
+	std  func(v []byte) string
+	wrt  func(w *Writer, v []byte) error
+	red  func(r *Reader, n int) ([]byte, error)
+}
+
+var textCodecs = []textCodec{
+	{
+		"hex",
+		hex.EncodeToString,
+		(*Writer).WriteHex,
+		(*Reader).ReadHex,
+	},
+	{
+		"base64 std",
+		base64.StdEncoding.EncodeToString,
+		func(w *Writer, v []byte) error { return w.WriteBase64(base64.StdEncoding, v) },
+		func(r *Reader, n int) ([]byte, error) { return r.ReadBase64(base64.StdEncoding, n) },
+	},
+	{
+		"base64 raw url",
+		base64.RawURLEncoding.EncodeToString,
+		func(w *Writer, v []byte) error { return w.WriteBase64(base64.RawURLEncoding, v) },
+		func(r *Reader, n int) ([]byte, error) { return r.ReadBase64(base64.RawURLEncoding, n) },
+	},
+	{
+		"base32 std",
+		base32.StdEncoding.EncodeToString,
+		func(w *Writer, v []byte) error { return w.WriteBase32(base32.StdEncoding, v) },
+		func(r *Reader, n int) ([]byte, error) { return r.ReadBase32(base32.StdEncoding, n) },
+	},
+	{
+		"base32 hex raw",
+		base32.HexEncoding.WithPadding(base32.NoPadding).EncodeToString,
+		func(w *Writer, v []byte) error {
+			return w.WriteBase32(base32.HexEncoding.WithPadding(base32.NoPadding), v)
+		},
+		func(r *Reader, n int) ([]byte, error) {
+			return r.ReadBase32(base32.HexEncoding.WithPadding(base32.NoPadding), n)
+		},
+	},
+}
+
+// textSizes are the lengths of data which are
+// written, covering partial blocks and data
+// which is larger than the buffer.
+
+var textSizes = []int{0, 1, 2, 3, 4, 5, 7, 100, 1021, 3000}
+
+func TestText(t *testing.T) {
+
+	for _, c := range textCodecs {
+
+		c := c
+
+		Convey("Writer should write "+c.name+" text to a byte slice", t, func() {
+			var b []byte
+			w := NewWriterBytes(&b)
+			var s string
+			for _, n := range textSizes {
+				So(c.wrt(w, big[:n]), ShouldBeNil)
+				w.WriteByte('.')
+				s += c.std(big[:n]) + "."
+			}
+			So(string(b), ShouldEqual, s)
+		})
+
+		Convey("Writer should write "+c.name+" text to an io.Writer", t, func() {
+			o := bytes.NewBuffer(nil)
+			m := bytes.NewBuffer(nil)
+			w := NewWriter(o)
+			w.Tee(m)
+			var s string
+			for _, n := range textSizes {
+				So(c.wrt(w, big[:n]), ShouldBeNil)
+				w.WriteByte('.')
+				s += c.std(big[:n]) + "."
+			}
+			So(w.Flush(), ShouldBeNil)
+			So(o.String(), ShouldEqual, s)
+			So(m.String(), ShouldEqual, s)
+		})
+
+		Convey("Reader should read "+c.name+" text", t, func() {
+			var s string
+			for _, n := range textSizes {
+				s += c.std(big[:n]) + "."
+			}
+			for _, r := range []*Reader{
+				NewReaderBytes([]byte(s)),
+				NewReader(bytes.NewReader([]byte(s))),
+				NewReader(iotest.HalfReader(bytes.NewReader([]byte(s)))),
+			} {
+				for _, n := range textSizes {
+					v, err := c.red(r, n)
+					So(err, ShouldBeNil)
+					So(v, ShouldResemble, big[:n])
+					d, _ := r.ReadByte()
+					So(d, ShouldEqual, '.')
+				}
+			}
+		})
+
+	}
+
+	Convey("Writer should write text to a nearly full segment or async buffer", t, func() {
+		for _, c := range textCodecs {
+			for _, f := range []int{1, 2, 3, 5, 7} {
+				w := NewWriterSegments()
+				w.WriteBytes(big[:segmentSize-f])
+				So(c.wrt(w, big[:30]), ShouldBeNil)
+				So(string(w.Bytes()), ShouldEqual, string(big[:segmentSize-f])+c.std(big[:30]))
+				o := bytes.NewBuffer(nil)
+				w = NewWriterAsync(o, 2)
+				w.WriteBytes(big[:asyncSize-f])
+				So(c.wrt(w, big[:30]), ShouldBeNil)
+				So(w.Close(), ShouldBeNil)
+				So(o.String(), ShouldEqual, string(big[:asyncSize-f])+c.std(big[:30]))
+			}
+		}
+	})
+
+	Convey("Writer should not allocate when writing to a byte slice with capacity", t, func() {
+		b := make([]byte, 0, 10000)
+		w := NewWriterBytes(&b)
+		n := testing.AllocsPerRun(100, func() {
+			w.ResetBytes(&b)
+			w.WriteHex(txt)
+			w.WriteBase64(base64.StdEncoding, txt)
+		})
+		So(n, ShouldEqual, 0)
+	})
+
+	Convey("Reader should return ErrInvalidText for invalid text", t, func() {
+		_, err := NewReaderBytes([]byte("0g")).ReadHex(1)
+		So(err, ShouldEqual, ErrInvalidText)
+		_, err = NewReaderBytes([]byte("QQ==")).ReadBase64(base64.StdEncoding, 2)
+		So(err, ShouldEqual, ErrInvalidText)
+		_, err = NewReaderBytes([]byte("QUJD")).ReadBase64(base64.URLEncoding, 4)
+		So(err, ShouldNotBeNil)
+	})
+
+}
@@ -0,0 +1,42 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build bumpdebug
+// +build bumpdebug
+
+package bump
+
+import (
+	"runtime"
+)
+
+// leaked is called by the finalizer of a Writer
+// which still contains data which has not been
+// flushed, and is replaced in tests.
+
+var leaked = func(w *Writer) {
+	panic("bump: Writer with unflushed data was garbage collected")
+}
+
+// track registers a finalizer which panics if
+// the Writer is garbage collected while it still
+// contains data which has not been flushed.
+func track(w *Writer) *Writer {
+	runtime.SetFinalizer(w, func(w *Writer) {
+		if w.pos > 0 && w.out == nil && !w.seg && w.err == nil {
+			leaked(w)
+		}
+	})
+	return w
+}
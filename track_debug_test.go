@@ -0,0 +1,69 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build bumpdebug
+// +build bumpdebug
+
+package bump
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// collect runs the garbage collector until the
+// leaked channel receives, or a second passes.
+
+func collect(c chan struct{}) bool {
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+		select {
+		case <-c:
+			return true
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	return false
+}
+
+func TestTrack(t *testing.T) {
+
+	c := make(chan struct{}, 10)
+
+	old := leaked
+	leaked = func(w *Writer) { c <- struct{}{} }
+	defer func() { leaked = old }()
+
+	Convey("Writer with unflushed data should be detected when collected", t, func() {
+		func() {
+			w := NewWriter(bytes.NewBuffer(nil))
+			w.WriteString("unflushed")
+		}()
+		So(collect(c), ShouldBeTrue)
+	})
+
+	Convey("Writer which was flushed should not be detected when collected", t, func() {
+		func() {
+			w := NewWriter(bytes.NewBuffer(nil))
+			w.WriteString("flushed")
+			w.Flush()
+		}()
+		So(collect(c), ShouldBeFalse)
+	})
+
+}
@@ -0,0 +1,244 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// WriteBool writes a boolean as a single
+// byte, which is either 0 or 1.
+func (w *Writer) WriteBool(v bool) error {
+	if v {
+		return w.WriteByte(1)
+	}
+	return w.WriteByte(0)
+}
+
+// WriteUint8 writes an unsigned 8-bit integer.
+func (w *Writer) WriteUint8(v uint8) error {
+	return w.WriteByte(v)
+}
+
+// WriteUint16 writes an unsigned 16-bit
+// integer in big-endian byte order.
+func (w *Writer) WriteUint16(v uint16) error {
+	binary.BigEndian.PutUint16(w.tmp[:], v)
+	return w.WriteBytes(w.tmp[:2])
+}
+
+// WriteUint32 writes an unsigned 32-bit
+// integer in big-endian byte order.
+func (w *Writer) WriteUint32(v uint32) error {
+	binary.BigEndian.PutUint32(w.tmp[:], v)
+	return w.WriteBytes(w.tmp[:4])
+}
+
+// WriteUint64 writes an unsigned 64-bit
+// integer in big-endian byte order.
+func (w *Writer) WriteUint64(v uint64) error {
+	binary.BigEndian.PutUint64(w.tmp[:], v)
+	return w.WriteBytes(w.tmp[:8])
+}
+
+// WriteInt8 writes a signed 8-bit integer.
+func (w *Writer) WriteInt8(v int8) error {
+	return w.WriteByte(uint8(v))
+}
+
+// WriteInt16 writes a signed 16-bit
+// integer in big-endian byte order.
+func (w *Writer) WriteInt16(v int16) error {
+	return w.WriteUint16(uint16(v))
+}
+
+// WriteInt32 writes a signed 32-bit
+// integer in big-endian byte order.
+func (w *Writer) WriteInt32(v int32) error {
+	return w.WriteUint32(uint32(v))
+}
+
+// WriteInt64 writes a signed 64-bit
+// integer in big-endian byte order.
+func (w *Writer) WriteInt64(v int64) error {
+	return w.WriteUint64(uint64(v))
+}
+
+// WriteFloat32 writes a 32-bit floating point
+// number in big-endian IEEE 754 format.
+func (w *Writer) WriteFloat32(v float32) error {
+	return w.WriteUint32(math.Float32bits(v))
+}
+
+// WriteFloat64 writes a 64-bit floating point
+// number in big-endian IEEE 754 format.
+func (w *Writer) WriteFloat64(v float64) error {
+	return w.WriteUint64(math.Float64bits(v))
+}
+
+// WriteUvarint writes an unsigned integer
+// using a variable length encoding, which is
+// compatible with encoding/binary.
+func (w *Writer) WriteUvarint(v uint64) error {
+	n := binary.PutUvarint(w.tmp[:], v)
+	return w.WriteBytes(w.tmp[:n])
+}
+
+// WriteVarint writes a signed integer using
+// a zig-zag variable length encoding, which
+// is compatible with encoding/binary.
+func (w *Writer) WriteVarint(v int64) error {
+	n := binary.PutVarint(w.tmp[:], v)
+	return w.WriteBytes(w.tmp[:n])
+}
+
+// ReadBool reads a boolean which was written
+// using WriteBool. Any non-zero byte is read
+// as true.
+func (r *Reader) ReadBool() (bool, error) {
+	b, err := r.ReadByte()
+	return b != 0, err
+}
+
+// ReadUint8 reads an unsigned 8-bit integer.
+func (r *Reader) ReadUint8() (uint8, error) {
+	return r.ReadByte()
+}
+
+// ReadUint16 reads an unsigned 16-bit
+// integer in big-endian byte order.
+func (r *Reader) ReadUint16() (uint16, error) {
+	b, err := r.readFixed(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+// ReadUint32 reads an unsigned 32-bit
+// integer in big-endian byte order.
+func (r *Reader) ReadUint32() (uint32, error) {
+	b, err := r.readFixed(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// ReadUint64 reads an unsigned 64-bit
+// integer in big-endian byte order.
+func (r *Reader) ReadUint64() (uint64, error) {
+	b, err := r.readFixed(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// ReadInt8 reads a signed 8-bit integer.
+func (r *Reader) ReadInt8() (int8, error) {
+	b, err := r.ReadByte()
+	return int8(b), err
+}
+
+// ReadInt16 reads a signed 16-bit
+// integer in big-endian byte order.
+func (r *Reader) ReadInt16() (int16, error) {
+	v, err := r.ReadUint16()
+	return int16(v), err
+}
+
+// ReadInt32 reads a signed 32-bit
+// integer in big-endian byte order.
+func (r *Reader) ReadInt32() (int32, error) {
+	v, err := r.ReadUint32()
+	return int32(v), err
+}
+
+// ReadInt64 reads a signed 64-bit
+// integer in big-endian byte order.
+func (r *Reader) ReadInt64() (int64, error) {
+	v, err := r.ReadUint64()
+	return int64(v), err
+}
+
+// ReadFloat32 reads a 32-bit floating point
+// number in big-endian IEEE 754 format.
+func (r *Reader) ReadFloat32() (float32, error) {
+	v, err := r.ReadUint32()
+	return math.Float32frombits(v), err
+}
+
+// ReadFloat64 reads a 64-bit floating point
+// number in big-endian IEEE 754 format.
+func (r *Reader) ReadFloat64() (float64, error) {
+	v, err := r.ReadUint64()
+	return math.Float64frombits(v), err
+}
+
+// ReadUvarint reads an unsigned integer which
+// was written using WriteUvarint.
+func (r *Reader) ReadUvarint() (uint64, error) {
+	return binary.ReadUvarint(r)
+}
+
+// ReadVarint reads a signed integer which
+// was written using WriteVarint.
+func (r *Reader) ReadVarint() (int64, error) {
+	return binary.ReadVarint(r)
+}
+
+// ReadLength reads a uvarint length or count,
+// returning ErrFrameTooLarge if it is larger than
+// any frame or value which can be read.
+func (r *Reader) ReadLength() (int, error) {
+	l, err := r.ReadUvarint()
+	if err != nil {
+		return 0, err
+	}
+	if l > maxFrameSize {
+		return 0, ErrFrameTooLarge
+	}
+	return int(l), nil
+}
+
+// readFixed reads a small fixed number of bytes,
+// without allocating. The returned slice is only
+// valid until the next call on the Reader.
+
+func (r *Reader) readFixed(n int) ([]byte, error) {
+
+	// Return a subslice when reading from bytes.
+
+	if r.out != nil {
+		return r.ReadBytes(n)
+	}
+
+	// Otherwise read each byte into the scratch space.
+
+	for i := 0; i < n; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		r.fix[i] = b
+	}
+
+	// Everything went ok.
+
+	return r.fix[:n], nil
+
+}
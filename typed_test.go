@@ -0,0 +1,120 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func typedWrite(w *Writer) {
+	w.WriteBool(true)
+	w.WriteUint8(math.MaxUint8)
+	w.WriteUint16(math.MaxUint16 - 1)
+	w.WriteUint32(math.MaxUint32 - 1)
+	w.WriteUint64(math.MaxUint64 - 1)
+	w.WriteInt8(math.MinInt8)
+	w.WriteInt16(math.MinInt16)
+	w.WriteInt32(math.MinInt32)
+	w.WriteInt64(math.MinInt64)
+	w.WriteFloat32(math.Pi)
+	w.WriteFloat64(math.E)
+	w.WriteUvarint(math.MaxUint64)
+	w.WriteVarint(math.MinInt64)
+}
+
+func typedCheck(r *Reader) {
+	b, _ := r.ReadBool()
+	So(b, ShouldBeTrue)
+	u8, _ := r.ReadUint8()
+	So(u8, ShouldEqual, math.MaxUint8)
+	u16, _ := r.ReadUint16()
+	So(u16, ShouldEqual, math.MaxUint16-1)
+	u32, _ := r.ReadUint32()
+	So(u32, ShouldEqual, math.MaxUint32-1)
+	u64, _ := r.ReadUint64()
+	So(u64, ShouldEqual, uint64(math.MaxUint64-1))
+	i8, _ := r.ReadInt8()
+	So(i8, ShouldEqual, math.MinInt8)
+	i16, _ := r.ReadInt16()
+	So(i16, ShouldEqual, math.MinInt16)
+	i32, _ := r.ReadInt32()
+	So(i32, ShouldEqual, math.MinInt32)
+	i64, _ := r.ReadInt64()
+	So(i64, ShouldEqual, math.MinInt64)
+	f32, _ := r.ReadFloat32()
+	So(f32, ShouldEqual, float32(math.Pi))
+	f64, _ := r.ReadFloat64()
+	So(f64, ShouldEqual, math.E)
+	uv, _ := r.ReadUvarint()
+	So(uv, ShouldEqual, uint64(math.MaxUint64))
+	iv, _ := r.ReadVarint()
+	So(iv, ShouldEqual, math.MinInt64)
+	_, err := r.ReadUint16()
+	So(err, ShouldEqual, io.EOF)
+}
+
+func TestTyped(t *testing.T) {
+
+	Convey("Typed values should round trip through a byte slice", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		typedWrite(w)
+		So(len(b), ShouldEqual, 1+1+2+4+8+1+2+4+8+4+8+10+10)
+		typedCheck(NewReaderBytes(b))
+	})
+
+	Convey("Typed values should round trip through an io.Writer", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		typedWrite(w)
+		w.Flush()
+		typedCheck(NewReader(b))
+	})
+
+	Convey("Typed values should be big-endian and compatible with encoding/binary", t, func() {
+		var b []byte
+		w := NewWriterBytes(&b)
+		w.WriteUint32(0x01020304)
+		w.WriteUvarint(300)
+		w.WriteVarint(-300)
+		So(b[:4], ShouldResemble, []byte{1, 2, 3, 4})
+		u, n := binary.Uvarint(b[4:])
+		So(u, ShouldEqual, 300)
+		i, _ := binary.Varint(b[4+n:])
+		So(i, ShouldEqual, -300)
+	})
+
+	Convey("Typed values should be mirrored to a checksum", t, func() {
+		b := bytes.NewBuffer(nil)
+		w := NewWriter(b)
+		w.Checksum(NewCRC32C())
+		typedWrite(w)
+		w.WriteChecksum()
+		w.Flush()
+		r := NewReader(b)
+		r.Checksum(NewCRC32C())
+		c := r.Limit(b.Len() - 4)
+		typedCheck(c)
+		So(c.Close(), ShouldBeNil)
+		So(r.ReadChecksum(), ShouldBeNil)
+	})
+
+}
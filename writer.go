@@ -15,7 +15,14 @@
 package bump
 
 import (
+	"bytes"
+	"encoding/binary"
+	"hash"
 	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
 )
 
 const writerSize = 1024
@@ -27,6 +34,28 @@ type Writer struct {
 	buf []byte
 	out *[]byte
 	wtr io.Writer
+	dst io.Writer
+	zip io.WriteCloser
+	cdc Codec
+	lvl int
+	sum hash.Hash
+	tee io.Writer
+	hsh []byte
+	tmp [binary.MaxVarintLen64]byte
+	vec net.Buffers
+	vba [2][]byte
+	err error
+	kep bool
+	max int
+	nwl bool
+	ivl time.Duration
+	arm bool
+	tmr *time.Timer
+	mtx *sync.Mutex
+	asy *async
+	scp scope
+	seg bool
+	sgs [][]byte
 	arr [writerSize]byte
 }
 
@@ -40,7 +69,7 @@ type stringer interface {
 // NewWriter creates a new Writer which
 // writes to an underlying io.Writer.
 func NewWriter(w io.Writer) *Writer {
-	return &Writer{wtr: w}
+	return track(&Writer{wtr: w})
 }
 
 // NewWriterBytes creates a new Writer
@@ -50,20 +79,57 @@ func NewWriterBytes(b *[]byte) *Writer {
 }
 
 // Reset resets the Writer, and instructs it
-// to write to the specified io.Writer.
+// to write to the specified io.Writer. If the
+// Writer was created with NewCompressedWriter
+// then the data will continue to be compressed.
 func (w *Writer) Reset(i io.Writer) error {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	w.resetScope()
+	w.release()
 	w.pos = 0
+	w.err = nil
 	w.wtr = i
 	w.out = nil
+	if w.sum != nil {
+		w.sum.Reset()
+	}
+	if w.asy != nil {
+		w.wtr = nil
+		w.dst = i
+		w.asy.reset(i)
+	}
+	if w.cdc != nil {
+		return w.resetCodec(i)
+	}
 	return nil
 }
 
 // ResetBytes resets the Writer, and instructs
 // it to write to the specified byte slice.
 func (w *Writer) ResetBytes(b *[]byte) error {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	w.resetScope()
+	w.release()
 	w.pos = 0
+	w.err = nil
 	w.out = b
 	w.wtr = nil
+	w.dst = nil
+	w.zip = nil
+	w.cdc = nil
+	if w.asy != nil {
+		w.asy.stop()
+		w.asy = nil
+	}
+	if w.sum != nil {
+		w.sum.Reset()
+	}
 	return nil
 }
 
@@ -71,7 +137,181 @@ func (w *Writer) ResetBytes(b *[]byte) error {
 // to the underlying io.Writer. When writing
 // to a byte slice, this function does not
 // do anything, as data is written immediately.
+// If the Writer was created with a Codec, then
+// any pending compressed data is also flushed.
 func (w *Writer) Flush() error {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if w.asy != nil {
+		return w.suspended(w.wait)
+	}
+	return w.suspended(w.flushAll)
+}
+
+// Close flushes any remaining buffered data
+// to the underlying io.Writer. If the Writer
+// was created with a Codec, then the
+// compressed stream is also finalised. Finally
+// the underlying io.Writer is closed, if it is
+// an io.Closer, unless disabled using
+// CloseUnderlying. Once closed, all methods
+// return ErrClosed until the Writer is reset.
+// If a scope is still open, then the data which
+// was written before it began is flushed, and
+// ErrScopeOrder is returned.
+func (w *Writer) Close() error {
+
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+
+	// Stop any background flushing.
+
+	if w.tmr != nil {
+		w.tmr.Stop()
+		w.arm = false
+	}
+
+	// Ensure the Writer is not already closed.
+
+	if w.err == ErrClosed {
+		return ErrClosed
+	}
+
+	// Flush the data in the buffer.
+
+	err := w.err
+	if err == nil {
+		err = w.suspended(w.flush)
+	}
+
+	// Report any scope which has not been ended.
+
+	if err == nil && w.scp.dep > 0 {
+		err = ErrScopeOrder
+	}
+
+	// Finalise the compressed stream.
+
+	if w.zip != nil {
+		if e := w.zip.Close(); err == nil {
+			err = e
+		}
+	}
+
+	// Wait for any asynchronous writes.
+
+	if w.asy != nil {
+		if e := w.asy.stop(); err == nil {
+			err = e
+		}
+	}
+
+	// Close the underlying writer if needed.
+
+	if !w.kep {
+		dst := w.wtr
+		if w.zip != nil || w.asy != nil {
+			dst = w.dst
+		}
+		if c, ok := dst.(io.Closer); ok {
+			if e := c.Close(); err == nil {
+				err = e
+			}
+		}
+	}
+
+	// Prevent the Writer from being used.
+
+	w.err = ErrClosed
+
+	return err
+
+}
+
+// Err returns the error which caused the Writer
+// to fail, if any. Once the underlying io.Writer
+// has returned an error, the same error is
+// returned from all later calls on the Writer,
+// until it is reset. This allows a long series
+// of writes to be checked for errors only once.
+// After Close, Err returns ErrClosed.
+func (w *Writer) Err() error {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	return w.err
+}
+
+// CloseUnderlying specifies whether Close should
+// also close the underlying io.Writer, if it is
+// an io.Closer. This is enabled by default.
+func (w *Writer) CloseUnderlying(v bool) {
+	w.kep = !v
+}
+
+func (w *Writer) resetCodec(i io.Writer) error {
+
+	// Reuse the existing compressor if possible.
+
+	if z, ok := w.zip.(writeResetter); ok {
+		z.Reset(i)
+		w.wtr = w.zip
+		w.dst = i
+		return nil
+	}
+
+	// Otherwise create a new compressor.
+
+	z, err := w.cdc.NewWriter(i, w.lvl)
+	if err != nil {
+		w.err = err
+		return err
+	}
+
+	w.wtr = z
+	w.zip = z
+	w.dst = i
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+func (w *Writer) flushAll() error {
+
+	// Flush the data in the buffer.
+
+	err := w.flush()
+	if err != nil {
+		return err
+	}
+
+	// Flush the compressor if possible.
+
+	if f, ok := w.zip.(flusher); ok {
+		err = f.Flush()
+		if err != nil {
+			w.err = err
+		}
+		return err
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+func (w *Writer) flush() error {
 
 	// Don't flush if there is no data.
 
@@ -85,22 +325,48 @@ func (w *Writer) Flush() error {
 		return nil
 	}
 
-	// Write the data to the underlying writer.
+	// Start a new segment if writing to segments.
 
-	n, err := w.wtr.Write(w.buf[:w.pos])
-	if err != nil {
-		return err
+	if w.seg {
+		return w.rotate()
 	}
 
-	// If not all data was sent, then error.
+	// Hand the buffer over if writing asynchronously.
 
-	if n < w.pos {
-		return io.ErrShortWrite
+	if w.asy != nil {
+		return w.swap()
 	}
 
-	// Reset the buffer position.
+	// Write the data to the underlying writer,
+	// retrying for as long as progress is made.
 
-	w.pos = 0
+	for w.pos > 0 {
+
+		n, err := w.wtr.Write(w.buf[:w.pos])
+		if n > w.pos {
+			n = w.pos
+		}
+
+		// Shift any unwritten data down the buffer.
+
+		if n > 0 {
+			copy(w.buf, w.buf[n:w.pos])
+			w.pos -= n
+		}
+
+		if err != nil {
+			w.err = err
+			return err
+		}
+
+		// If no data was sent, then error.
+
+		if n == 0 {
+			w.err = io.ErrShortWrite
+			return w.err
+		}
+
+	}
 
 	// Everything went ok.
 
@@ -108,31 +374,119 @@ func (w *Writer) Flush() error {
 
 }
 
+// Buffered returns the number of bytes which have
+// been written to the Writer, but which have not
+// yet been committed to the underlying io.Writer.
+// If a flush fails, these bytes are retained.
+// The data held back while a scope is open is
+// also counted.
+func (w *Writer) Buffered() int {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	if w.seg {
+		return 0
+	}
+	if w.scp.dep > 0 && w.scp.str {
+		return w.scp.pos + w.pos
+	}
+	if w.out != nil {
+		return 0
+	}
+	return w.pos
+}
+
 // WriteByte writes a single byte to the
 // underlying io.Writer, or byte slice.
 func (w *Writer) WriteByte(v byte) error {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	if w.err != nil || w.asy != nil && w.asy.failed() {
+		return w.fault()
+	}
 	if w.out != nil {
-		return w.writeByteToBytes(v)
+		err := w.writeByteToBytes(v)
+		if err != nil || w.sum == nil && w.tee == nil {
+			return err
+		}
+		w.tmp[0] = v
+		return w.mirror(w.tmp[:1])
+	}
+	err := w.writeByteToWriter(v)
+	if err != nil {
+		return err
+	}
+	if w.sum != nil || w.tee != nil {
+		w.tmp[0] = v
+		err = w.mirror(w.tmp[:1])
+		if err != nil {
+			return err
+		}
 	}
-	return w.writeByteToWriter(v)
+	return w.policy(w.nwl && v == '\n')
 }
 
 // WriteBytes writes a slice of bytes to the
 // underlying io.Writer, or byte slice.
 func (w *Writer) WriteBytes(v []byte) error {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	if w.err != nil || w.asy != nil && w.asy.failed() {
+		return w.fault()
+	}
 	if w.out != nil {
-		return w.writeBytesToBytes(v)
+		err := w.writeBytesToBytes(v)
+		if err != nil || w.sum == nil && w.tee == nil {
+			return err
+		}
+		return w.mirror(v)
 	}
-	return w.writeBytesToWriter(v)
+	err := w.writeBytesToWriter(v)
+	if err != nil {
+		return err
+	}
+	if w.sum != nil || w.tee != nil {
+		err = w.mirror(v)
+		if err != nil {
+			return err
+		}
+	}
+	return w.policy(w.nwl && bytes.IndexByte(v, '\n') >= 0)
 }
 
 // WriteString writes a string to the
 // underlying io.Writer, or byte slice.
 func (w *Writer) WriteString(v string) error {
+	if w.mtx != nil {
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+	}
+	if w.err != nil || w.asy != nil && w.asy.failed() {
+		return w.fault()
+	}
 	if w.out != nil {
-		return w.writeStringToBytes(v)
+		err := w.writeStringToBytes(v)
+		if err != nil || w.sum == nil && w.tee == nil {
+			return err
+		}
+		return w.mirrorString(v)
+	}
+	err := w.writeStringToWriter(v)
+	if err != nil {
+		return err
 	}
-	return w.writeStringToWriter(v)
+	if w.sum != nil || w.tee != nil {
+		err = w.mirrorString(v)
+		if err != nil {
+			return err
+		}
+	}
+	return w.policy(w.nwl && strings.IndexByte(v, '\n') >= 0)
 }
 
 func (w *Writer) writeByteToBytes(v byte) error {
@@ -242,7 +596,7 @@ func (w *Writer) writeByteToWriter(v byte) error {
 	// Flush the buffer if no space is remaining.
 
 	if w.pos >= len(w.buf) {
-		err := w.Flush()
+		err := w.flush()
 		if err != nil {
 			return err
 		}
@@ -270,25 +624,21 @@ func (w *Writer) writeBytesToWriter(v []byte) error {
 		w.buf = w.arr[0:]
 	}
 
-	// Write directly to the underlying writer.
+	// Write large slices alongside any buffered data.
+
+	if len(v) >= len(w.buf) && w.asy == nil && !w.seg {
+		return w.writev(v)
+	}
+
+	// Fill the buffer and flush it if needed.
 
 	for len(v) > len(w.buf)-w.pos {
-		if w.pos == 0 {
-			n, err := w.wtr.Write(v)
-			if err != nil {
-				return err
-			}
-			v = v[n:]
-		} else {
-			n := copy(w.buf[w.pos:], v)
-			w.pos += n
-			v = v[n:]
-		}
-		if w.pos >= writerSize {
-			err := w.Flush()
-			if err != nil {
-				return err
-			}
+		n := copy(w.buf[w.pos:], v)
+		w.pos += n
+		v = v[n:]
+		err := w.flush()
+		if err != nil {
+			return err
 		}
 	}
 
@@ -308,6 +658,13 @@ func (w *Writer) writeBytesToWriter(v []byte) error {
 
 func (w *Writer) writeStringToWriter(s string) error {
 
+	// Buffer all of the data if writing asynchronously,
+	// or if writing to segments.
+
+	if w.asy != nil || w.seg {
+		return w.writeStringToStringer(nil, s)
+	}
+
 	// Attempt to write the string directly.
 
 	if i, ok := w.wtr.(stringer); ok {
@@ -316,7 +673,11 @@ func (w *Writer) writeStringToWriter(s string) error {
 
 	// Convert the string to a slice of bytes.
 
-	v := []byte(s)
+	return w.writeBytesToWriter([]byte(s))
+
+}
+
+func (w *Writer) writeStringToStringer(i stringer, s string) error {
 
 	// Initialise the underlying buffer if needed.
 
@@ -324,31 +685,43 @@ func (w *Writer) writeStringToWriter(s string) error {
 		w.buf = w.arr[0:]
 	}
 
-	// Write directly to the underlying writer.
+	// Write large strings directly after any buffered data.
 
-	for len(v) > len(w.buf)-w.pos {
-		if w.pos == 0 {
-			n, err := w.wtr.Write(v)
-			if err != nil {
-				return err
-			}
-			v = v[n:]
-		} else {
-			n := copy(w.buf[w.pos:], v)
-			w.pos += n
-			v = v[n:]
+	if len(s) >= len(w.buf) && i != nil {
+		err := w.flush()
+		if err != nil {
+			return err
 		}
-		if w.pos >= writerSize {
-			err := w.Flush()
+		for len(s) > 0 {
+			n, err := i.WriteString(s)
 			if err != nil {
+				w.err = err
 				return err
 			}
+			if n == 0 {
+				w.err = io.ErrShortWrite
+				return w.err
+			}
+			s = s[n:]
+		}
+		return nil
+	}
+
+	// Fill the buffer and flush it if needed.
+
+	for len(s) > len(w.buf)-w.pos {
+		n := copy(w.buf[w.pos:], s)
+		w.pos += n
+		s = s[n:]
+		err := w.flush()
+		if err != nil {
+			return err
 		}
 	}
 
 	// Insert any remaining bytes into the buffer.
 
-	n := copy(w.buf[w.pos:], v)
+	n := copy(w.buf[w.pos:], s)
 
 	// Increment the current buffer position.
 
@@ -360,43 +733,90 @@ func (w *Writer) writeStringToWriter(s string) error {
 
 }
 
-func (w *Writer) writeStringToStringer(i stringer, s string) error {
+// writev writes any buffered data followed by the
+// borrowed slice, keeping any buffered data which
+// was not written if an error is returned.
 
-	// Initialise the underlying buffer if needed.
+func (w *Writer) writev(v []byte) error {
 
-	if w.buf == nil {
-		w.buf = w.arr[0:]
+	// Only use vectored I/O on connections, which
+	// write all of the data or return an error.
+
+	if _, ok := w.wtr.(net.Conn); !ok {
+		err := w.flush()
+		if err != nil {
+			return err
+		}
+		return w.write(v)
 	}
 
-	// Write directly to the underlying writer.
+	// Gather the buffered data and the borrowed slice.
 
-	for len(s) > len(w.buf)-w.pos {
-		if w.pos == 0 {
-			n, err := i.WriteString(s)
-			if err != nil {
-				return err
-			}
-			s = s[n:]
-		} else {
-			n := copy(w.buf[w.pos:], s)
-			w.pos += n
-			s = s[n:]
-		}
-		if w.pos >= writerSize {
-			err := w.Flush()
-			if err != nil {
-				return err
-			}
-		}
+	w.vec = append(w.vba[:0], w.buf[:w.pos], v)
+	if w.pos == 0 {
+		w.vec = w.vec[1:]
 	}
 
-	// Insert any remaining bytes into the buffer.
+	// Write all of the data in a single call, which uses
+	// writev on connections which support vectored I/O.
 
-	n := copy(w.buf[w.pos:], s)
+	n, err := w.vec.WriteTo(w.wtr)
 
-	// Increment the current buffer position.
+	// Release the reference to the borrowed slice.
 
-	w.pos += n
+	w.vba[1] = nil
+	w.vec = nil
+
+	// Shift any unwritten data down the buffer.
+
+	if int(n) < w.pos {
+		copy(w.buf, w.buf[n:w.pos])
+		w.pos -= int(n)
+	} else {
+		w.pos = 0
+	}
+
+	if err != nil {
+		w.err = err
+		return err
+	}
+
+	// Everything went ok.
+
+	return nil
+
+}
+
+// write writes the slice to the underlying writer,
+// retrying short writes which make progress.
+
+func (w *Writer) write(v []byte) error {
+
+	// Write the data to the underlying writer,
+	// retrying for as long as progress is made.
+
+	t := 0
+
+	for t < len(v) {
+
+		n, err := w.wtr.Write(v[t:])
+		if n > len(v)-t {
+			n = len(v) - t
+		}
+		t += n
+		if err != nil {
+			w.err = err
+			return err
+		}
+
+		// If no data was sent, then error.
+
+		if n == 0 {
+			w.err = io.ErrShortWrite
+			return w.err
+		}
+
+	}
 
 	// Everything went ok.
 
@@ -0,0 +1,147 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bump
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var big []byte // 90600
+
+func init() {
+
+	big = bytes.Repeat(txt, 100)
+
+}
+
+type countWriter struct {
+	bytes.Buffer
+	calls [][]byte
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	c.calls = append(c.calls, p)
+	return c.Buffer.Write(p)
+}
+
+// shortConn is a connection which accepts a
+// limited number of bytes, and then fails.
+
+type shortConn struct {
+	net.Conn
+	bytes.Buffer
+	lim int
+}
+
+func (c *shortConn) Write(p []byte) (int, error) {
+	if len(p) > c.lim {
+		p = p[:c.lim]
+	}
+	c.lim -= len(p)
+	c.Buffer.Write(p)
+	if c.lim == 0 {
+		return len(p), io.ErrClosedPipe
+	}
+	return len(p), nil
+}
+
+func TestWritev(t *testing.T) {
+
+	Convey("Writer should pass large slices through without copying", t, func() {
+		c := &countWriter{}
+		w := NewWriter(c)
+		w.WriteString("header")
+		w.WriteBytes(big)
+		So(len(c.calls), ShouldEqual, 2)
+		So(c.calls[0], ShouldResemble, []byte("header"))
+		So(&c.calls[1][0], ShouldEqual, &big[0])
+		w.Flush()
+		So(c.Bytes(), ShouldResemble, append([]byte("header"), big...))
+	})
+
+	Convey("Writer should buffer small slices before flushing", t, func() {
+		c := &countWriter{}
+		w := NewWriter(c)
+		for i := 0; i < 100; i++ {
+			w.WriteBytes(txt[:100])
+		}
+		w.Flush()
+		So(len(c.calls), ShouldEqual, 10)
+		So(c.Len(), ShouldEqual, 100*100)
+	})
+
+	Convey("Writer should write header and payload messages over a TCP connection", t, func() {
+		l, e := net.Listen("tcp", "127.0.0.1:0")
+		if e != nil {
+			t.Skip("tcp listener unavailable:", e)
+		}
+		defer l.Close()
+		d := make(chan []byte)
+		go func() {
+			c, e := l.Accept()
+			if e != nil {
+				d <- nil
+				return
+			}
+			b, _ := ioutil.ReadAll(c)
+			c.Close()
+			d <- b
+		}()
+		c, e := net.Dial("tcp", l.Addr().String())
+		So(e, ShouldBeNil)
+		w := NewWriter(c)
+		for i := 0; i < 10; i++ {
+			So(w.WriteFrame(big, nil), ShouldBeNil)
+			So(w.WriteString("trailer"), ShouldBeNil)
+		}
+		So(w.Flush(), ShouldBeNil)
+		c.Close()
+		var x []byte
+		v := NewWriterBytes(&x)
+		for i := 0; i < 10; i++ {
+			v.WriteFrame(big, nil)
+			v.WriteString("trailer")
+		}
+		So(<-d, ShouldResemble, x)
+	})
+
+	Convey("Writer should keep the buffered data which was not written before a failure", t, func() {
+		for _, c := range []struct{ hdr, lim, pos int }{
+			{6, 3, 3},
+			{6, 6, 0},
+			{6, 1006, 0},
+			{0, 1000, 0},
+		} {
+			o := &shortConn{lim: c.lim}
+			w := NewWriter(o)
+			w.WriteBytes(big[:c.hdr])
+			So(w.writev(big), ShouldEqual, io.ErrClosedPipe)
+			So(w.pos, ShouldEqual, c.pos)
+			So(bytes.Equal(o.Bytes(), append(big[:c.hdr:c.hdr], big...)[:c.lim]), ShouldBeTrue)
+			So(w.WriteByte(0), ShouldEqual, io.ErrClosedPipe)
+		}
+		o := bytes.NewBuffer(nil)
+		w := NewWriter(o)
+		So(w.writev(big), ShouldBeNil)
+		So(bytes.Equal(o.Bytes(), big), ShouldBeTrue)
+	})
+
+}